@@ -0,0 +1,130 @@
+// Команда rollup-backfill пересобирает почасовые и посуточные счетчики кликов
+// по сырым данным click_analytics. Нужна после миграции 0020 для кликов, записанных
+// до нее, и для исправления счетчиков. Текущие сутки не пересчитываются —
+// их ведет сервер. Запуск без флагов отмечает полную пересборку, после которой
+// сервер начинает удалять клики старше CLICK_RETENTION_DAYS.
+//
+//	rollup-backfill                     # все ссылки за все время
+//	rollup-backfill -link 42            # одна ссылка
+//	rollup-backfill -from 2024-01-01 -to 2024-02-01
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+	"url-short/internal/config"
+	"url-short/internal/repositories"
+
+	_ "github.com/lib/pq"
+)
+
+// chunkDays — за сколько суток пересчитываются счетчики в одной транзакции.
+// Транзакция держит блокировки строк счетчиков ссылки, поэтому не должна быть долгой.
+const chunkDays = 7
+
+func main() {
+	linkID := flag.Int("link", 0, "ID ссылки; 0 — все ссылки")
+	fromFlag := flag.String("from", "", "первый день, ГГГГ-ММ-ДД; по умолчанию — день первого клика")
+	toFlag := flag.String("to", "", "день после последнего, ГГГГ-ММ-ДД; по умолчанию — завтра")
+	flag.Parse()
+
+	var from, to time.Time
+	var err error
+	if *fromFlag != "" {
+		if from, err = time.Parse(time.DateOnly, *fromFlag); err != nil {
+			log.Fatalf("[FATAL] Некорректная дата -from: %v", err)
+		}
+	}
+	to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if *toFlag != "" {
+		if to, err = time.Parse(time.DateOnly, *toFlag); err != nil {
+			log.Fatalf("[FATAL] Некорректная дата -to: %v", err)
+		}
+	}
+
+	cfg := config.LoadConfig()
+	db, err := sql.Open("postgres", fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName,
+	))
+	if err != nil {
+		log.Fatalf("[FATAL] Ошибка подключения: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("[FATAL] Ошибка аутентификации: %v", err)
+	}
+
+	repo := repositories.NewAnalyticRepository(db)
+	backfilled, err := repo.RollupsBackfilled()
+	if err != nil {
+		log.Fatalf("[FATAL] Ошибка проверки пересборки счетчиков: %v", err)
+	}
+
+	// Сырые клики старше срока хранения уже удалены, и пересчет обнулил бы их счетчики.
+	// Сутки, в которые проходит граница срока, удалены частично, поэтому начинаем со следующих.
+	// До первой полной пересборки сервер клики не удаляет
+	if cfg.ClickRetentionDays > 0 && backfilled {
+		earliest := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-cfg.ClickRetentionDays)
+		if from.Before(earliest) {
+			if !from.IsZero() {
+				log.Printf("[WARN] Сырые клики до %s удалены по сроку хранения, пересчет начнется с этой даты", earliest.Format(time.DateOnly))
+			}
+			from = earliest
+		}
+	}
+
+	ids := []int{*linkID}
+	if *linkID == 0 {
+		if ids, err = repo.RollupLinkIDs(); err != nil {
+			log.Fatalf("[FATAL] Ошибка получения ссылок: %v", err)
+		}
+	}
+
+	started := time.Now()
+	var total int64
+	for _, id := range ids {
+		n, err := rebuildLink(repo, id, from, to)
+		if err != nil {
+			log.Fatalf("[FATAL] Ошибка пересчета ссылки %d: %v", id, err)
+		}
+		total += n
+	}
+	log.Printf("[INFO] Счетчики пересчитаны: ссылок %d, строк %d за %s", len(ids), total, time.Since(started).Round(time.Second))
+
+	if *linkID == 0 && *fromFlag == "" && *toFlag == "" {
+		if err := repo.MarkRollupsBackfilled(); err != nil {
+			log.Fatalf("[FATAL] Ошибка отметки пересборки счетчиков: %v", err)
+		}
+	}
+}
+
+// rebuildLink пересчитывает счетчики ссылки за [from, to) отрезками по chunkDays суток,
+// но не раньше дня первого сохраненного клика.
+func rebuildLink(repo *repositories.AnalyticRepository, linkID int, from, to time.Time) (int64, error) {
+	first, ok, err := repo.FirstClick(linkID)
+	if err != nil || !ok {
+		return 0, err
+	}
+	if day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); day.After(from) {
+		from = day
+	}
+
+	var written int64
+	for start := from; start.Before(to); start = start.AddDate(0, 0, chunkDays) {
+		end := start.AddDate(0, 0, chunkDays)
+		if end.After(to) {
+			end = to
+		}
+		n, err := repo.RebuildRollups(linkID, start, end)
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	log.Printf("[INFO] Ссылка %d: записано строк счетчиков %d", linkID, written)
+	return written, nil
+}
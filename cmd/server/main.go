@@ -2,12 +2,30 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"url-short/internal/blocklist"
+	"url-short/internal/codegen"
 	"url-short/internal/config"
+	"url-short/internal/export"
 	"url-short/internal/handlers"
+	"url-short/internal/i18n"
+	"url-short/internal/mail"
+	"url-short/internal/metadata"
 	"url-short/internal/middleware"
+	"url-short/internal/models"
+	"url-short/internal/privacy"
+	"url-short/internal/qr"
 	"url-short/internal/repositories"
+	"url-short/internal/stream"
+	"url-short/internal/utils"
+	"url-short/internal/webhooks"
 
 	_ "url-short/docs"
 
@@ -47,51 +65,406 @@ func main() {
 	log.Println("Успешное подключение к PostgreSQL")
 
 	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
 	linkRepo := repositories.NewLinkRepository(db)
 	analyticRepo := repositories.NewAnalyticRepository(db)
+	destinationRepo := repositories.NewDestinationRepository(db)
+	conversionRepo := repositories.NewConversionRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	folderRepo := repositories.NewFolderRepository(db)
+	domainRepo := repositories.NewDomainRepository(db)
+	aliasRepo := repositories.NewAliasRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	exportRepo := repositories.NewExportRepository(db)
+
+	caseMode, ok := models.ParseCaseMode(cfg.ShortCodeCase)
+	if !ok {
+		log.Fatalf("[FATAL] Некорректный SHORT_CODE_CASE: %q (ожидается sensitive или insensitive)", cfg.ShortCodeCase)
+	}
+	linkRepo.CaseMode = caseMode
+	if err := linkRepo.ApplyCaseMode(); err != nil {
+		var conflictErr *repositories.CaseConflictError
+		if errors.As(err, &conflictErr) {
+			for _, conflict := range conflictErr.Conflicts {
+				log.Printf("[ERROR] Коды различаются только регистром: %s | Домен: %q", strings.Join(conflict.Codes, ", "), conflict.Domain)
+			}
+		}
+		log.Fatalf("[FATAL] Не удалось включить режим регистра %s: %v", caseMode, err)
+	}
+
+	go func() {
+		n, err := linkRepo.BackfillNormalizedURLs(utils.NormalizeURL, 500)
+		if err != nil {
+			log.Printf("[ERROR] Ошибка заполнения нормализованных адресов: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("[INFO] Заполнены нормализованные адреса ссылок: %d", n)
+		}
+	}()
+
+	go rollupClicks(analyticRepo)
+	go purgeDeletedLinks(linkRepo, cfg)
+	go purgeSessions(sessionRepo)
+
+	dispatcher := webhooks.NewDispatcher(webhookRepo)
+	dispatcher.MaxAttempts = cfg.WebhookMaxAttempts
+	dispatcher.Start()
+
+	exportRetention := time.Duration(cfg.ExportRetentionHours) * time.Hour
+	exportRunner := export.NewRunner(exportRepo, analyticRepo, cfg.ExportDir, exportRetention)
+	if err := exportRunner.Start(); err != nil {
+		log.Fatalf("[FATAL] Не удалось создать каталог выгрузок %s: %v", cfg.ExportDir, err)
+	}
+
+	alphabet, err := codegen.ParseAlphabet(cfg.CodeAlphabet)
+	if err != nil {
+		log.Fatalf("[FATAL] Ошибка настройки генерации кодов: %v", err)
+	}
+	if caseMode == models.CaseInsensitive {
+		// Заглавные буквы в генерируемых кодах лишь создавали бы неотличимые варианты
+		alphabet = codegen.Lowercase(alphabet)
+	}
+	generator, err := codegen.New(cfg.CodeStrategy, alphabet, repositories.NewCodeSequence(db))
+	if err != nil {
+		log.Fatalf("[FATAL] Ошибка настройки генерации кодов: %v", err)
+	}
+	codes := codegen.NewAllocator(generator)
+	codes.Length = cfg.CodeLength
+	codes.MaxLength = cfg.CodeMaxLength
+	if codes.Length < 2 || codes.MaxLength < codes.Length {
+		log.Fatalf("[FATAL] Некорректная длина кодов: CODE_LENGTH=%d, CODE_MAX_LENGTH=%d", codes.Length, codes.MaxLength)
+	}
+	if codes.MaxLength > codegen.ColumnLength {
+		log.Fatalf("[FATAL] CODE_MAX_LENGTH=%d больше ширины столбца short_code (%d)", codes.MaxLength, codegen.ColumnLength)
+	}
+	if counter, ok := generator.(*codegen.CounterGenerator); ok {
+		counter.MaxLength = codes.MaxLength
+	}
+	codes.Metrics.Publish("short_codes")
+
+	authHandler := handlers.NewAuthHandler(userRepo, sessionRepo, cfg)
+	ipAnonymizer, err := privacy.NewIPAnonymizer(cfg.IPMode, cfg.IPHashKey)
+	if err != nil {
+		log.Fatalf("[FATAL] Некорректный IP_MODE %q: %v", cfg.IPMode, err)
+	}
+	if !models.IsAnalyticsMode(cfg.AnalyticsMode) {
+		log.Fatalf("[FATAL] Некорректный ANALYTICS_MODE: %q (ожидается full, respect или consent)", cfg.AnalyticsMode)
+	}
+	if cfg.ClickRetentionDays > 0 {
+		go purgeOldClicks(analyticRepo, cfg)
+	}
 
-	authHandler := handlers.NewAuthHandler(userRepo, cfg)
 	linkHandler := &handlers.LinkHandler{
-		LinkRepo:     linkRepo,
+		LinkRepo:        linkRepo,
+		AnalyticRepo:    analyticRepo,
+		DestinationRepo: destinationRepo,
+		ConversionRepo:  conversionRepo,
+		TagRepo:         tagRepo,
+		FolderRepo:      folderRepo,
+		AliasRepo:       aliasRepo,
+		Codes:           codes,
+		MetadataFetcher: metadata.NewFetcher(),
+		CodeQuarantine:  time.Duration(cfg.CodeQuarantineDays) * 24 * time.Hour,
+
+		DomainRepo:          domainRepo,
+		DefaultDomain:       cfg.AppDomain,
+		UnknownHostRedirect: cfg.UnknownHostRedirect,
+		InactivePageURL:     cfg.InactiveLinkPage,
+		PausedPageURL:       cfg.PausedLinkPage,
+
+		Events:      dispatcher,
+		ClickStream: stream.NewHub(stream.DefaultHistorySize),
+
+		AnalyticsMode:  cfg.AnalyticsMode,
+		ConsentCookie:  cfg.ConsentCookie,
+		IPAnonymizer:   ipAnonymizer,
+		ExposeClickIPs: cfg.ExposeClickIPs,
+	}
+	go notifyExpiredLinks(linkHandler)
+	if cfg.QRLogoPath != "" {
+		logo, err := qr.LoadLogo(cfg.QRLogoPath)
+		if err != nil {
+			log.Printf("[WARN] Не удалось загрузить логотип для QR-кодов: %v", err)
+		} else {
+			linkHandler.QRLogo = logo
+		}
+	}
+	tagHandler := &handlers.TagHandler{TagRepo: tagRepo}
+	folderHandler := &handlers.FolderHandler{FolderRepo: folderRepo}
+	domainHandler := &handlers.DomainHandler{
+		DomainRepo:    domainRepo,
+		Resolver:      net.DefaultResolver,
+		DefaultDomain: cfg.AppDomain,
+	}
+	webhookHandler := &handlers.WebhookHandler{
+		WebhookRepo: webhookRepo,
+		Dispatcher:  dispatcher,
+	}
+	exportHandler := &handlers.ExportHandler{
+		Links:        linkHandler,
 		AnalyticRepo: analyticRepo,
+		ExportRepo:   exportRepo,
+		Runner:       exportRunner,
+		SyncLimit:    int64(cfg.ExportSyncLimit),
+	}
+	conversionHandler := &handlers.ConversionHandler{
+		ConversionRepo: conversionRepo,
+	}
+	var mailer mail.Sender = mail.LogSender{}
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		log.Println("[WARN] SMTP_HOST не задан: письма будут записываться в лог")
+	}
+	accountHandler := &handlers.AccountHandler{
+		UserRepo:       userRepo,
+		LinkRepo:       linkRepo,
+		TagRepo:        tagRepo,
+		AnalyticRepo:   analyticRepo,
+		SessionRepo:    sessionRepo,
+		Mailer:         mailer,
+		AppURL:         cfg.AppURL,
+		CodeQuarantine: time.Duration(cfg.CodeQuarantineDays) * 24 * time.Hour,
 	}
 
 	r := gin.Default()
 
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Locale())
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", middleware.RequestIDHeader)
 		c.Next()
 	})
 
+	r.SetFuncMap(i18n.FuncMap())
 	r.LoadHTMLGlob("web/templates/*.html")
 	r.Static("/static", "./web/static")
 
 	r.GET("/", func(c *gin.Context) {
-		c.HTML(200, "index.html", nil)
+		c.HTML(200, "index.html", gin.H{"Lang": i18n.FromContext(c)})
 	})
 	r.GET("/:short_code", linkHandler.Redirect)
 	api := r.Group("/api")
 	{
 		api.POST("/register", authHandler.Register)
 		api.POST("/login", authHandler.Login)
+		api.GET("/email/confirm", accountHandler.ConfirmEmail)
+		api.POST("/conversions", conversionHandler.TrackConversion)
+		api.GET("/conversions/pixel.gif", conversionHandler.ConversionPixel)
 	}
 
 	authGroup := api.Group("")
-	authGroup.Use(middleware.AuthMiddleware(cfg))
+	authGroup.Use(middleware.AuthMiddleware(cfg, sessionRepo), middleware.UserLocale(userRepo))
 	{
+		authGroup.GET("/links", linkHandler.ListLinks)
 		authGroup.POST("/links", linkHandler.CreateShortLink)
+		authGroup.POST("/links/bulk", linkHandler.BulkCreateLinks)
+		authGroup.GET("/links/export", linkHandler.ExportLinks)
+		authGroup.GET("/links/trash", linkHandler.ListDeletedLinks)
+		authGroup.GET("/links/stream", linkHandler.StreamClicks)
+		authGroup.GET("/links/:short_code", linkHandler.GetLink)
+		authGroup.PATCH("/links/:short_code", linkHandler.UpdateLink)
+		authGroup.DELETE("/links/:short_code", linkHandler.DeleteLink)
+		authGroup.POST("/links/:short_code/restore", linkHandler.RestoreLink)
+		authGroup.GET("/links/:short_code/history", linkHandler.GetLinkHistory)
+		authGroup.POST("/links/:short_code/history/:version/rollback", linkHandler.RollbackLink)
+		authGroup.GET("/links/:short_code/qr", linkHandler.GetLinkQR)
+		authGroup.PUT("/links/:short_code/tags", linkHandler.SetLinkTags)
+		authGroup.PUT("/links/:short_code/folder", linkHandler.SetLinkFolder)
+		authGroup.GET("/links/:short_code/aliases", linkHandler.ListAliases)
+		authGroup.POST("/links/:short_code/aliases", linkHandler.AddAlias)
+		authGroup.DELETE("/links/:short_code/aliases/:id", linkHandler.DeleteAlias)
+		authGroup.POST("/links/:short_code/status", linkHandler.ChangeLinkStatus)
+		authGroup.GET("/links/:short_code/status/history", linkHandler.ListStatusEvents)
+		authGroup.PUT("/links/:short_code/analytics-mode", linkHandler.SetAnalyticsMode)
+		authGroup.GET("/links/:short_code/stream", linkHandler.StreamLinkClicks)
+
+		authGroup.GET("/tags", tagHandler.ListTags)
+		authGroup.POST("/tags", tagHandler.CreateTag)
+		authGroup.PATCH("/tags/:id", tagHandler.RenameTag)
+		authGroup.DELETE("/tags/:id", tagHandler.DeleteTag)
+
+		authGroup.GET("/folders", folderHandler.ListFolders)
+		authGroup.POST("/folders", folderHandler.CreateFolder)
+		authGroup.PATCH("/folders/:id", folderHandler.RenameFolder)
+		authGroup.DELETE("/folders/:id", folderHandler.DeleteFolder)
+
+		authGroup.GET("/domains", domainHandler.ListDomains)
+		authGroup.POST("/domains", domainHandler.AddDomain)
+		authGroup.POST("/domains/:id/verify", domainHandler.VerifyDomain)
+		authGroup.DELETE("/domains/:id", domainHandler.DeleteDomain)
+
+		authGroup.GET("/webhooks", webhookHandler.ListWebhooks)
+		authGroup.POST("/webhooks", webhookHandler.CreateWebhook)
+		authGroup.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		authGroup.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+		authGroup.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhook)
+
+		authGroup.GET("/me", accountHandler.GetProfile)
+		authGroup.PATCH("/me", accountHandler.UpdateProfile)
+		authGroup.DELETE("/me", accountHandler.DeleteAccount)
+		authGroup.POST("/me/password", accountHandler.ChangePassword)
+		authGroup.GET("/me/sessions", accountHandler.ListSessions)
+		authGroup.DELETE("/me/sessions/:id", accountHandler.RevokeSession)
+		authGroup.GET("/me/export", accountHandler.ExportAccount)
+		authGroup.DELETE("/me/transfer", accountHandler.CancelTransfer)
+		authGroup.GET("/me/transfers", accountHandler.ListTransfers)
+		authGroup.POST("/me/transfers/:id/accept", accountHandler.AcceptTransfer)
+		authGroup.DELETE("/me/transfers/:id", accountHandler.DeclineTransfer)
 	}
 
 	statsGroup := api.Group("")
-	statsGroup.Use(middleware.AuthMiddleware(cfg))
+	statsGroup.Use(middleware.AuthMiddleware(cfg, sessionRepo), middleware.UserLocale(userRepo))
 	{
 		statsGroup.GET("/links/:short_code/stats", linkHandler.GetLinkStats)
+		statsGroup.GET("/links/:short_code/stats/timeseries", linkHandler.GetLinkTimeseries)
+		statsGroup.GET("/analytics", linkHandler.GetAggregatedStats)
+		statsGroup.GET("/links/:short_code/clicks/export", exportHandler.ExportLinkClicks)
+		statsGroup.GET("/clicks/export", exportHandler.ExportClicks)
+		statsGroup.GET("/exports", exportHandler.ListExports)
+		statsGroup.GET("/exports/:id", exportHandler.GetExport)
+		statsGroup.GET("/exports/:id/download", exportHandler.DownloadExport)
 	}
 	// swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Список собирается после регистрации маршрутов: код вроде api или static перекрывался бы ими
+	var routePaths []string
+	for _, route := range r.Routes() {
+		routePaths = append(routePaths, route.Path)
+	}
+	var blockedWords []string
+	if cfg.BlocklistPath != "" {
+		if blockedWords, err = blocklist.LoadWords(cfg.BlocklistPath); err != nil {
+			log.Fatalf("[FATAL] Ошибка загрузки списка запрещенных слов: %v", err)
+		}
+	}
+	reserved := append(blocklist.ReservedFromRoutes(routePaths), strings.Split(cfg.ReservedCodes, ",")...)
+	codeBlocklist := blocklist.New(reserved, blockedWords)
+	linkHandler.Blocklist = codeBlocklist
+	codes.Allowed = codeBlocklist.Allowed
+
+	if cfg.DebugAddr != "" {
+		go serveDebug(cfg.DebugAddr)
+	}
+
 	log.Printf("Сервер запущен на http://localhost:%s", cfg.AppPort)
 	if err := r.Run(":" + cfg.AppPort); err != nil {
 		log.Fatalf("[FATAL] Ошибка запуска: %v", err)
 	}
 }
+
+// serveDebug отдает метрики expvar на отдельном внутреннем адресе, чтобы они
+// не были доступны через публичный порт сервиса.
+func serveDebug(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	log.Printf("[INFO] Метрики доступны на http://%s/debug/vars", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("[ERROR] Ошибка запуска слушателя метрик: %v", err)
+	}
+}
+
+// rollupClicks каждые несколько секунд учитывает новые клики в почасовых и
+// посуточных счетчиках. Пачки берутся, пока очередь не опустеет.
+func rollupClicks(analyticRepo *repositories.AnalyticRepository) {
+	const batchSize = 5000
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			n, err := analyticRepo.RollupPending(batchSize)
+			if err != nil {
+				log.Printf("[ERROR] Ошибка учета кликов в счетчиках: %v", err)
+				break
+			}
+			if n < batchSize {
+				break
+			}
+		}
+	}
+}
+
+// purgeDeletedLinks раз в час окончательно удаляет ссылки, срок хранения которых после
+// удаления истек, вместе с их кликами.
+func purgeDeletedLinks(linkRepo *repositories.LinkRepository, cfg *config.Config) {
+	retention := time.Duration(cfg.LinkRetentionDays) * 24 * time.Hour
+	quarantine := time.Duration(cfg.CodeQuarantineDays) * 24 * time.Hour
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := linkRepo.PurgeDeleted(retention, quarantine, 500)
+		if err != nil {
+			log.Printf("[ERROR] Ошибка очистки удаленных ссылок: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] Окончательно удалено ссылок: %d", n)
+		}
+		<-ticker.C
+	}
+}
+
+// purgeSessions раз в час удаляет истекшие и завершенные сессии входа.
+func purgeSessions(sessionRepo *repositories.SessionRepository) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := sessionRepo.DeleteExpired()
+		if err != nil {
+			log.Printf("[ERROR] Ошибка очистки сессий: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] Удалено завершенных сессий: %d", n)
+		}
+		<-ticker.C
+	}
+}
+
+// purgeOldClicks раз в час удаляет сырые клики старше CLICK_RETENTION_DAYS.
+// Почасовые и посуточные счетчики кликов при этом сохраняются. Пока счетчики не
+// пересобраны командой rollup-backfill, клики не удаляются: старые из них есть только в сырых данных.
+func purgeOldClicks(analyticRepo *repositories.AnalyticRepository, cfg *config.Config) {
+	retention := time.Duration(cfg.ClickRetentionDays) * 24 * time.Hour
+	warned := false
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		done, err := analyticRepo.RollupsBackfilled()
+		if err != nil {
+			log.Printf("[ERROR] Ошибка проверки пересборки счетчиков: %v", err)
+			continue
+		}
+		if !done {
+			if !warned {
+				log.Println("[WARN] Счетчики кликов не пересобраны: запустите rollup-backfill, до этого старые клики не удаляются")
+				warned = true
+			}
+			continue
+		}
+
+		n, err := analyticRepo.DeleteOldClicks(retention, 10000)
+		if err != nil {
+			log.Printf("[ERROR] Ошибка удаления старых кликов: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] Удалено кликов старше %d дн.: %d", cfg.ClickRetentionDays, n)
+		}
+	}
+}
+
+// notifyExpiredLinks раз в минуту отправляет вебхукам событие link.expired
+// для ссылок, срок действия которых истек.
+func notifyExpiredLinks(linkHandler *handlers.LinkHandler) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		n, err := linkHandler.NotifyExpired(500)
+		if err != nil {
+			log.Printf("[ERROR] Ошибка отправки событий об истечении ссылок: %v", err)
+		} else if n > 0 {
+			log.Printf("[INFO] Отправлены события об истечении ссылок: %d", n)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package apierror формирует ответы API с ошибками: стабильный машиночитаемый код,
+// HTTP-статус, ошибки отдельных полей и ID запроса.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestIDKey — ключ контекста gin, под которым middleware.RequestID хранит ID запроса.
+const RequestIDKey = "requestID"
+
+// Коды ошибок. Клиенты опираются на них вместо текста, поэтому существующие коды
+// не переименовываются.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeInvalidJSON       = "invalid_json"
+	CodeValidationFailed  = "validation_failed"
+	CodeInvalidParameter  = "invalid_parameter"
+	CodeInvalidCode       = "invalid_code"
+	CodeCodeReserved      = "code_reserved"
+	CodeCodeBlocked       = "code_blocked"
+	CodeInvalidPassword   = "invalid_password"
+	CodeTooManyRows       = "too_many_rows"
+	CodeDomainReserved    = "domain_reserved"
+	CodeEmailTokenInvalid = "email_token_invalid"
+
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeTokenMissing       = "token_missing"
+	CodeTokenInvalid       = "token_invalid"
+	CodeSessionExpired     = "session_expired"
+
+	CodeForbidden        = "forbidden"
+	CodePasswordRequired = "password_required"
+
+	CodeNotFound         = "not_found"
+	CodeLinkNotFound     = "link_not_found"
+	CodeAliasNotFound    = "alias_not_found"
+	CodeVersionNotFound  = "version_not_found"
+	CodeTagNotFound      = "tag_not_found"
+	CodeFolderNotFound   = "folder_not_found"
+	CodeDomainNotFound   = "domain_not_found"
+	CodeWebhookNotFound  = "webhook_not_found"
+	CodeDeliveryNotFound = "delivery_not_found"
+	CodeExportNotFound   = "export_not_found"
+	CodeClickNotFound    = "click_not_found"
+	CodeUserNotFound     = "user_not_found"
+	CodeSessionNotFound  = "session_not_found"
+	CodeTransferNotFound = "transfer_not_found"
+
+	CodeConflict          = "conflict"
+	CodeCodeTaken         = "code_taken"
+	CodeEmailTaken        = "email_taken"
+	CodeUsernameTaken     = "username_taken"
+	CodeTagExists         = "tag_exists"
+	CodeFolderExists      = "folder_exists"
+	CodeDomainExists      = "domain_exists"
+	CodeDomainInUse       = "domain_in_use"
+	CodeInvalidTransition = "invalid_transition"
+	CodeExportNotReady    = "export_not_ready"
+	CodeStatusConflict    = "status_conflict"
+	CodeVersionUnchanged  = "version_unchanged"
+	CodeVersionExpired    = "version_expired"
+
+	CodeGone          = "gone"
+	CodeLinkExpired   = "link_expired"
+	CodeLinkArchived  = "link_archived"
+	CodeExportExpired = "export_expired"
+
+	CodeUnprocessable            = "unprocessable"
+	CodeDomainVerificationFailed = "domain_verification_failed"
+
+	CodeInternal    = "internal_error"
+	CodeMailFailed  = "mail_failed"
+	CodeUnavailable = "unavailable"
+)
+
+func init() {
+	// В ошибках полей нужны имена из JSON, а не из Go-структур
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			switch name {
+			case "-":
+				return ""
+			case "":
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// DefaultCode возвращает общий код для статуса, когда более точного нет.
+func DefaultCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// Respond прерывает обработку запроса и отвечает ошибкой. Пустой code заменяется
+// на DefaultCode(status), message переводится на язык ответа.
+func Respond(c *gin.Context, status int, code, message string) {
+	RespondDetails(c, status, code, message, nil)
+}
+
+// Respondf — как Respond, но текст собирается из переводимого формата.
+func Respondf(c *gin.Context, status int, code, format string, args ...any) {
+	Respond(c, status, code, i18n.Tf(i18n.FromContext(c), format, args...))
+}
+
+// RespondDetails — как Respond, но с ошибками отдельных полей.
+func RespondDetails(c *gin.Context, status int, code, message string, details []models.FieldError) {
+	if code == "" {
+		code = DefaultCode(status)
+	}
+	locale := i18n.FromContext(c)
+	resp := models.ErrorResponse{
+		Error:     i18n.T(locale, message),
+		Code:      code,
+		Status:    status,
+		Details:   Translate(locale, details),
+		RequestID: c.GetString(RequestIDKey),
+	}
+	if status >= 500 {
+		log.Printf("[ERROR] %s %s: %s | Код: %s | Запрос: %s", c.Request.Method, c.Request.URL.Path, message, code, resp.RequestID)
+	}
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// RespondBinding отвечает 400 на ошибку ShouldBindJSON. Ошибки валидатора
+// превращаются в validation_failed с ошибками полей, неразобранный JSON —
+// в invalid_json; message становится общим текстом ответа.
+func RespondBinding(c *gin.Context, err error, message string) {
+	code, details := Describe(err, i18n.FromContext(c))
+	RespondDetails(c, http.StatusBadRequest, code, message, details)
+}
+
+// Field описывает ошибку поля, найденную вручную, а не валидатором.
+func Field(field, rule, message string) models.FieldError {
+	return models.FieldError{Field: field, Rule: rule, Message: message}
+}
+
+// Translate переводит тексты ошибок полей на язык locale.
+func Translate(locale string, details []models.FieldError) []models.FieldError {
+	if len(details) == 0 {
+		return details
+	}
+	translated := make([]models.FieldError, len(details))
+	for i, d := range details {
+		d.Message = i18n.T(locale, d.Message)
+		translated[i] = d
+	}
+	return translated
+}
+
+// Describe разбирает ошибку привязки запроса на код и ошибки полей с текстами на языке locale.
+func Describe(err error, locale string) (string, []models.FieldError) {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		details := make([]models.FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			details = append(details, models.FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: ruleMessage(fe, locale),
+			})
+		}
+		return CodeValidationFailed, details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "$"
+		}
+		return CodeValidationFailed, []models.FieldError{{
+			Field:   field,
+			Rule:    "type",
+			Param:   jsonType(typeErr.Type),
+			Message: i18n.Tf(locale, "Ожидается значение типа %s", jsonType(typeErr.Type)),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return CodeInvalidJSON, nil
+	}
+	return CodeInvalidRequest, nil
+}
+
+// fieldPath убирает из пути имя корневой структуры: CreateLinkRequest.destinations[0].url
+// превращается в destinations[0].url.
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+func ruleMessage(fe validator.FieldError, locale string) string {
+	switch fe.Tag() {
+	case "required":
+		return i18n.T(locale, "Обязательное поле")
+	case "email":
+		return i18n.T(locale, "Некорректный email")
+	case "url", "http_url":
+		return i18n.T(locale, "Некорректный URL")
+	case "fqdn", "hostname":
+		return i18n.T(locale, "Некорректное имя домена")
+	case "oneof":
+		return i18n.Tf(locale, "Допустимые значения: %s", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "min", "gte":
+		switch fe.Kind() {
+		case reflect.String:
+			return i18n.Tf(locale, "Минимальная длина — %s символов", fe.Param())
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return i18n.Tf(locale, "Нужно не меньше %s элементов", fe.Param())
+		}
+		return i18n.Tf(locale, "Значение должно быть не меньше %s", fe.Param())
+	case "max", "lte":
+		switch fe.Kind() {
+		case reflect.String:
+			return i18n.Tf(locale, "Максимальная длина — %s символов", fe.Param())
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return i18n.Tf(locale, "Допускается не больше %s элементов", fe.Param())
+		}
+		return i18n.Tf(locale, "Значение должно быть не больше %s", fe.Param())
+	}
+	return i18n.T(locale, "Некорректное значение")
+}
+
+// jsonType называет Go-тип так, как его видит клиент API.
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	}
+	return "value"
+}
@@ -0,0 +1,125 @@
+package apierror_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type destination struct {
+	URL    string `json:"url" binding:"required,url"`
+	Weight int    `json:"weight" binding:"min=1"`
+}
+
+type request struct {
+	Email        string        `json:"email" binding:"required,email"`
+	Password     string        `json:"password" binding:"required,min=6"`
+	Status       string        `json:"status" binding:"omitempty,oneof=draft active"`
+	Tags         []string      `json:"tags" binding:"max=2"`
+	Destinations []destination `json:"destinations" binding:"omitempty,dive"`
+}
+
+func TestRespondBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode string
+		expected     []models.FieldError
+	}{
+		{
+			name:         "Field rules",
+			body:         `{"email": "nope", "password": "123", "status": "gone", "tags": ["a", "b", "c"]}`,
+			expectedCode: apierror.CodeValidationFailed,
+			expected: []models.FieldError{
+				{Field: "email", Rule: "email", Message: "Некорректный email"},
+				{Field: "password", Rule: "min", Param: "6", Message: "Минимальная длина — 6 символов"},
+				{Field: "status", Rule: "oneof", Param: "draft active", Message: "Допустимые значения: draft, active"},
+				{Field: "tags", Rule: "max", Param: "2", Message: "Допускается не больше 2 элементов"},
+			},
+		},
+		{
+			name:         "Nested fields",
+			body:         `{"email": "a@b.co", "password": "secret", "destinations": [{"url": "https://a.example", "weight": 1}, {"weight": 0}]}`,
+			expectedCode: apierror.CodeValidationFailed,
+			expected: []models.FieldError{
+				{Field: "destinations[1].url", Rule: "required", Message: "Обязательное поле"},
+				{Field: "destinations[1].weight", Rule: "min", Param: "1", Message: "Значение должно быть не меньше 1"},
+			},
+		},
+		{
+			name:         "Wrong type",
+			body:         `{"email": 42}`,
+			expectedCode: apierror.CodeValidationFailed,
+			expected: []models.FieldError{
+				{Field: "email", Rule: "type", Param: "string", Message: "Ожидается значение типа string"},
+			},
+		},
+		{
+			name:         "Malformed JSON",
+			body:         `{"email": `,
+			expectedCode: apierror.CodeInvalidJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set(apierror.RequestIDKey, "req-1")
+
+			var req request
+			err := c.ShouldBindJSON(&req)
+			require.Error(t, err)
+			apierror.RespondBinding(c, err, "Неверные данные")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.True(t, c.IsAborted())
+
+			var resp models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "Неверные данные", resp.Error)
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, http.StatusBadRequest, resp.Status)
+			assert.Equal(t, "req-1", resp.RequestID)
+			assert.Equal(t, tt.expected, resp.Details)
+		})
+	}
+}
+
+func TestRespondDefaultCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{http.StatusNotFound, apierror.CodeNotFound},
+		{http.StatusConflict, apierror.CodeConflict},
+		{http.StatusBadGateway, apierror.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/", nil)
+
+		apierror.Respond(c, tt.status, "", "Ошибка")
+
+		assert.Equal(t, tt.status, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error":"Ошибка","code":%q,"status":%d}`, tt.code, tt.status), w.Body.String())
+	}
+}
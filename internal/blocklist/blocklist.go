@@ -0,0 +1,182 @@
+// Package blocklist решает, можно ли выдать строку как короткий код: запрещает
+// зарезервированные слова (пути приложения вроде api или static) и нежелательные
+// слова, в том числе записанные leetspeak-ом (sh1t, pr0n). Короткие слова, которые
+// бывают частью обычных (cock в cocktail), запрещаются только целиком.
+package blocklist
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	ErrReserved = errors.New("код зарезервирован")
+	ErrBlocked  = errors.New("код содержит недопустимое слово")
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// leet — замены цифр на буквы, на которые они похожи. Единица читается и как i, и как l.
+var leet = strings.NewReplacer("0", "o", "3", "e", "4", "a", "5", "s", "6", "g", "7", "t", "8", "b", "9", "g")
+
+// Blocklist хранит зарезервированные коды и нежелательные слова в нормализованном виде.
+// Нулевой указатель ничего не запрещает.
+type Blocklist struct {
+	reserved map[string]bool
+	// words запрещены в любом месте кода, tokens — только как весь код или его часть
+	words  []string
+	tokens map[string]bool
+}
+
+// New создает список из зарезервированных кодов и нежелательных слов
+// (вдобавок к встроенному словарю).
+func New(reserved, words []string) *Blocklist {
+	b := &Blocklist{reserved: make(map[string]bool, len(reserved)), tokens: make(map[string]bool)}
+	for _, r := range reserved {
+		if r = strings.ToLower(strings.TrimSpace(r)); r != "" {
+			b.reserved[r] = true
+		}
+	}
+	b.addWords(parseWords(strings.NewReader(defaultWords)))
+	b.addWords(words)
+	return b
+}
+
+// addWords добавляет слова без схлопывания повторов: иначе «kkk» превратилось бы в «k».
+// Слово с префиксом = запрещается только целиком.
+func (b *Blocklist) addWords(words []string) {
+	for _, w := range words {
+		w, whole := strings.CutPrefix(w, "=")
+		for _, v := range normalize(w) {
+			switch {
+			case v == "":
+			case whole:
+				b.tokens[v] = true
+			default:
+				b.words = append(b.words, v)
+			}
+		}
+	}
+}
+
+// LoadWords читает слова из файла: по одному в строке, # — комментарий,
+// = в начале — слово запрещается только целиком.
+func LoadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseWords(f), nil
+}
+
+func parseWords(r io.Reader) []string {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words
+}
+
+// ReservedFromRoutes выбирает из путей маршрутов первые статические сегменты:
+// "/api/links/:short_code" → "api". Код с таким именем перекрывался бы маршрутом.
+func ReservedFromRoutes(paths []string) []string {
+	seen := make(map[string]bool)
+	var reserved []string
+	for _, p := range paths {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") || seen[segment] {
+			continue
+		}
+		seen[segment] = true
+		reserved = append(reserved, segment)
+	}
+	return reserved
+}
+
+// Check возвращает ErrReserved или ErrBlocked, если код выдавать нельзя.
+func (b *Blocklist) Check(code string) error {
+	if b == nil {
+		return nil
+	}
+	if b.reserved[strings.ToLower(code)] {
+		return ErrReserved
+	}
+	for _, v := range variants(code) {
+		for _, w := range b.words {
+			if strings.Contains(v, w) {
+				return ErrBlocked
+			}
+		}
+	}
+	if len(b.tokens) > 0 {
+		// Весь код тоже проверяется как одна часть: «c-o-c-k» запрещен, как и «cock»
+		parts := append(strings.FieldsFunc(code, isSeparator), code)
+		for _, part := range parts {
+			for _, v := range variants(part) {
+				if b.tokens[v] {
+					return ErrBlocked
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isSeparator сообщает, разделяет ли символ части кода.
+func isSeparator(r rune) bool {
+	return r == '-' || r == '_'
+}
+
+// Allowed — Check в виде предиката для фильтра сгенерированных кодов.
+func (b *Blocklist) Allowed(code string) bool {
+	return b.Check(code) == nil
+}
+
+// normalize приводит строку к нижнему регистру без - и _ и заменяет leetspeak
+// буквами; для 1 возвращаются оба варианта, с i и с l.
+func normalize(s string) []string {
+	s = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(s))
+	s = leet.Replace(s)
+
+	forms := []string{strings.ReplaceAll(s, "1", "i")}
+	if strings.Contains(s, "1") {
+		forms = append(forms, strings.ReplaceAll(s, "1", "l"))
+	}
+	return forms
+}
+
+// variants дополняет формы normalize теми же формами со схлопнутыми
+// повторами букв («fuuuck» → «fuck»).
+func variants(s string) []string {
+	forms := normalize(s)
+	for _, f := range forms {
+		if c := squeeze(f); c != f {
+			forms = append(forms, c)
+		}
+	}
+	return forms
+}
+
+// squeeze схлопывает подряд идущие одинаковые символы.
+func squeeze(s string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range s {
+		if i == 0 || r != prev {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
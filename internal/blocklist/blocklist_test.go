@@ -0,0 +1,93 @@
+package blocklist_test
+
+import (
+	"testing"
+	"url-short/internal/blocklist"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlocklist_Check(t *testing.T) {
+	b := blocklist.New([]string{"api", "Static", "admin"}, []string{"badword"})
+
+	tests := []struct {
+		code     string
+		expected error
+	}{
+		{code: "promo", expected: nil},
+		{code: "analytics", expected: nil},
+		{code: "api", expected: blocklist.ErrReserved},
+		{code: "STATIC", expected: blocklist.ErrReserved},
+		{code: "api2", expected: nil},
+		{code: "xShitx", expected: blocklist.ErrBlocked},
+		{code: "sh1t", expected: blocklist.ErrBlocked},
+		{code: "5h17", expected: blocklist.ErrBlocked},
+		{code: "b-1-t-c-h", expected: blocklist.ErrBlocked},
+		{code: "fuuuck", expected: blocklist.ErrBlocked},
+		{code: "my_BADW0RD", expected: blocklist.ErrBlocked},
+		{code: "kk", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.expected, b.Check(tt.code))
+		})
+	}
+}
+
+func TestBlocklist_WholeWords(t *testing.T) {
+	b := blocklist.New(nil, []string{"=spam"})
+
+	tests := []struct {
+		code     string
+		expected error
+	}{
+		// Короткие слова словаря внутри обычных слов не мешают
+		{code: "cocktail", expected: nil},
+		{code: "peacock", expected: nil},
+		{code: "grapes", expected: nil},
+		{code: "lebanon", expected: nil},
+		{code: "scunthorpe", expected: nil},
+		{code: "saltwater", expected: nil},
+		{code: "rebate", expected: nil},
+		{code: "shuiskiy", expected: nil},
+		{code: "spamalot", expected: nil},
+		// Целиком или отдельной частью кода они запрещены
+		{code: "cock", expected: blocklist.ErrBlocked},
+		{code: "C0CK", expected: blocklist.ErrBlocked},
+		{code: "c-o-c-k", expected: blocklist.ErrBlocked},
+		{code: "big-cock", expected: blocklist.ErrBlocked},
+		{code: "rape_", expected: blocklist.ErrBlocked},
+		{code: "eban", expected: blocklist.ErrBlocked},
+		{code: "cuuunt", expected: blocklist.ErrBlocked},
+		{code: "hui", expected: blocklist.ErrBlocked},
+		{code: "promo-huy", expected: blocklist.ErrBlocked},
+		{code: "spam", expected: blocklist.ErrBlocked},
+		{code: "no-5pam", expected: blocklist.ErrBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.expected, b.Check(tt.code))
+		})
+	}
+}
+
+func TestBlocklist_NilAllowsEverything(t *testing.T) {
+	var b *blocklist.Blocklist
+	assert.NoError(t, b.Check("api"))
+	assert.True(t, b.Allowed("api"))
+}
+
+func TestReservedFromRoutes(t *testing.T) {
+	reserved := blocklist.ReservedFromRoutes([]string{
+		"/",
+		"/:short_code",
+		"/api/links",
+		"/api/links/:short_code",
+		"/static/*filepath",
+		"/swagger/*any",
+		"/debug/vars",
+	})
+	assert.Equal(t, []string{"api", "static", "swagger", "debug"}, reserved)
+}
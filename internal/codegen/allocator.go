@@ -0,0 +1,97 @@
+package codegen
+
+import (
+	"errors"
+	"expvar"
+	"log"
+)
+
+const (
+	DefaultLength      = 6
+	DefaultMaxLength   = 12
+	DefaultMaxAttempts = 5
+
+	// ColumnLength — ширина столбца links.short_code; длиннее коды не сохранить.
+	ColumnLength = 20
+)
+
+var ErrCodeSpaceExhausted = errors.New("не удалось подобрать свободный код")
+
+// Metrics — счетчики работы аллокатора. Значения доступны через expvar после Publish.
+type Metrics struct {
+	Generated   expvar.Int // выдано свободных кодов
+	Collisions  expvar.Int // кандидатов, оказавшихся занятыми
+	Rejected    expvar.Int // кандидатов, отброшенных фильтром Allowed
+	Escalations expvar.Int // переходов на большую длину
+	Exhausted   expvar.Int // отказов после всех попыток
+}
+
+// Publish регистрирует счетчики в expvar под именем name. Вызывать один раз на имя.
+func (m *Metrics) Publish(name string) {
+	vars := new(expvar.Map).Init()
+	vars.Set("generated", &m.Generated)
+	vars.Set("collisions", &m.Collisions)
+	vars.Set("rejected", &m.Rejected)
+	vars.Set("escalations", &m.Escalations)
+	vars.Set("exhausted", &m.Exhausted)
+	expvar.Publish(name, vars)
+}
+
+// Allocator подбирает свободный код: делает до MaxAttempts попыток на каждой длине,
+// начиная с Length, и удлиняет код до MaxLength, когда короткие коды заняты.
+type Allocator struct {
+	Generator   CodeGenerator
+	Length      int
+	MaxLength   int
+	MaxAttempts int
+	Metrics     *Metrics
+
+	// Allowed, если задан, отбраковывает кандидатов до проверки занятости
+	// (например, коды с нежелательными словами). Отбракованный кандидат тратит попытку.
+	Allowed func(code string) bool
+}
+
+func NewAllocator(generator CodeGenerator) *Allocator {
+	return &Allocator{
+		Generator:   generator,
+		Length:      DefaultLength,
+		MaxLength:   DefaultMaxLength,
+		MaxAttempts: DefaultMaxAttempts,
+		Metrics:     &Metrics{},
+	}
+}
+
+// Allocate возвращает код, для которого taken вернул false.
+func (a *Allocator) Allocate(taken func(code string) (bool, error)) (string, error) {
+	for length := a.Length; length <= a.MaxLength; length++ {
+		if length > a.Length {
+			a.Metrics.Escalations.Add(1)
+			log.Printf("[WARN] Коды длины %d заняты, длина увеличена до %d", length-1, length)
+		}
+
+		for attempt := 0; attempt < a.MaxAttempts; attempt++ {
+			code, err := a.Generator.Generate(length)
+			if err != nil {
+				return "", err
+			}
+			if a.Allowed != nil && !a.Allowed(code) {
+				a.Metrics.Rejected.Add(1)
+				log.Printf("[DEBUG] Кандидат в короткие коды отклонен фильтром")
+				continue
+			}
+			exists, err := taken(code)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				a.Metrics.Generated.Add(1)
+				return code, nil
+			}
+			a.Metrics.Collisions.Add(1)
+			log.Printf("[DEBUG] Коллизия короткого кода: %s", code)
+		}
+	}
+
+	a.Metrics.Exhausted.Add(1)
+	return "", ErrCodeSpaceExhausted
+}
@@ -0,0 +1,153 @@
+// Package codegen подбирает короткие коды ссылок. Стратегия генерации
+// (CodeGenerator) отделена от проверки уникальности (Allocator): аллокатор
+// ограничивает число попыток, увеличивает длину кода при коллизиях и
+// ведет счетчики в Metrics.
+package codegen
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Base62 — цифры и латиница в обоих регистрах.
+	Base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// Base57 — Base62 без символов, которые путают при печати и наборе: 0, O, 1, l, I.
+	Base57 = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+	StrategyRandom  = "random"
+	StrategyCounter = "counter"
+	StrategyWords   = "words"
+)
+
+var (
+	ErrInvalidAlphabet = errors.New("алфавит должен состоять минимум из двух различных символов [0-9A-Za-z]")
+	ErrUnknownStrategy = errors.New("неизвестная стратегия генерации кодов")
+)
+
+// CodeGenerator выдает кандидата в короткие коды заданной длины.
+// Уникальность кандидата проверяет Allocator.
+type CodeGenerator interface {
+	Generate(length int) (string, error)
+}
+
+// Sequence — монотонный источник чисел для CounterGenerator (например, sequence в PostgreSQL).
+type Sequence interface {
+	Next() (uint64, error)
+}
+
+// ParseAlphabet принимает имя алфавита (base62, base57) или сам набор символов.
+func ParseAlphabet(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", "base62":
+		return Base62, nil
+	case "base57":
+		return Base57, nil
+	}
+
+	seen := make(map[rune]bool, len(s))
+	for _, r := range s {
+		if !strings.ContainsRune(Base62, r) || seen[r] {
+			return "", ErrInvalidAlphabet
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return "", ErrInvalidAlphabet
+	}
+	return s, nil
+}
+
+// Lowercase оставляет в алфавите только цифры и строчные буквы — для режима, в котором
+// коды сравниваются без учета регистра. Заглавные буквы отбрасываются, а не заменяются:
+// иначе в Base57 вернулась бы исключенная из него «l». Алфавит из одних заглавных
+// переводится в нижний регистр целиком.
+func Lowercase(alphabet string) string {
+	var b strings.Builder
+	for _, r := range alphabet {
+		if !unicode.IsUpper(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() < 2 {
+		return strings.ToLower(alphabet)
+	}
+	return b.String()
+}
+
+// New создает генератор по имени стратегии. seq нужен только стратегии counter.
+// Стратегия words алфавит не использует: ее коды всегда строчные.
+func New(strategy, alphabet string, seq Sequence) (CodeGenerator, error) {
+	switch strategy {
+	case "", StrategyRandom, StrategyCounter:
+	case StrategyWords:
+		return WordGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, strategy)
+	}
+
+	alphabet, err := ParseAlphabet(alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if strategy == StrategyCounter {
+		if seq == nil {
+			return nil, errors.New("для стратегии counter нужен источник последовательности")
+		}
+		g := NewCounterGenerator(seq)
+		g.Alphabet = alphabet
+		return g, nil
+	}
+	return &RandomGenerator{Alphabet: alphabet}, nil
+}
+
+// RandomGenerator выбирает символы алфавита равновероятно с помощью crypto/rand.
+type RandomGenerator struct {
+	Alphabet string
+}
+
+func (g *RandomGenerator) Generate(length int) (string, error) {
+	return randomString(g.Alphabet, length)
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	size := big.NewInt(int64(len(alphabet)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// WordGenerator собирает произносимые коды из чередующихся согласных и гласных
+// («bakuzi»). В наборе нет букв, похожих на цифры, и неоднозначных при диктовке.
+type WordGenerator struct{}
+
+const (
+	consonants = "bdfghjkmnprstvz"
+	vowels     = "aeiu"
+)
+
+func (WordGenerator) Generate(length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		set := consonants
+		if i%2 == 1 {
+			set = vowels
+		}
+		c, err := randomString(set, 1)
+		if err != nil {
+			return "", err
+		}
+		b[i] = c[0]
+	}
+	return string(b), nil
+}
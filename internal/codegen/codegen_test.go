@@ -0,0 +1,230 @@
+package codegen_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"url-short/internal/codegen"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlphabet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{input: "", expected: codegen.Base62},
+		{input: "base57", expected: codegen.Base57},
+		{input: "BASE62", expected: codegen.Base62},
+		{input: "abc123", expected: "abc123"},
+		{input: "a", wantErr: true},
+		{input: "aab", wantErr: true},
+		{input: "ab_-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			alphabet, err := codegen.ParseAlphabet(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, codegen.ErrInvalidAlphabet)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, alphabet)
+		})
+	}
+}
+
+func TestBase57_HasNoAmbiguousCharacters(t *testing.T) {
+	assert.Len(t, codegen.Base57, 57)
+	assert.False(t, strings.ContainsAny(codegen.Base57, "0O1lI"))
+}
+
+func TestRandomGenerator(t *testing.T) {
+	g := &codegen.RandomGenerator{Alphabet: codegen.Base57}
+	for i := 0; i < 100; i++ {
+		code, err := g.Generate(8)
+		require.NoError(t, err)
+		assert.Len(t, code, 8)
+		for _, r := range code {
+			assert.Contains(t, codegen.Base57, string(r))
+		}
+	}
+}
+
+func TestWordGenerator(t *testing.T) {
+	code, err := codegen.WordGenerator{}.Generate(7)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[bdfghjkmnprstvz][aeiu][bdfghjkmnprstvz][aeiu][bdfghjkmnprstvz][aeiu][bdfghjkmnprstvz]$`, code)
+}
+
+// sequence — последовательность в памяти для тестов счетчиковой стратегии.
+type sequence struct {
+	next uint64
+}
+
+func (s *sequence) Next() (uint64, error) {
+	n := s.next
+	s.next++
+	return n, nil
+}
+
+func TestCounterGenerator_UniqueAndObfuscated(t *testing.T) {
+	g := codegen.NewCounterGenerator(&sequence{})
+
+	seen := make(map[string]bool)
+	var prev string
+	for i := 0; i < 1000; i++ {
+		code, err := g.Generate(4)
+		require.NoError(t, err)
+		assert.Len(t, code, 4)
+		assert.False(t, seen[code], "код %s выдан повторно", code)
+		seen[code] = true
+
+		// Соседние номера не должны давать соседние коды
+		if prev != "" {
+			assert.NotEqual(t, prev[:3], code[:3])
+		}
+		prev = code
+	}
+}
+
+func TestCounterGenerator_GrowsWhenSpaceIsFull(t *testing.T) {
+	// 62^2 = 3844: номер 3844 уже не помещается в двухсимвольный код
+	g := codegen.NewCounterGenerator(&sequence{next: 3844})
+
+	code, err := g.Generate(2)
+	require.NoError(t, err)
+	assert.Len(t, code, 3)
+}
+
+func TestCounterGenerator_StopsAtMaxLength(t *testing.T) {
+	// Алфавит из 5 символов: 5^2 = 25 кодов длины 2, номер 25 требует третий символ
+	tests := []struct {
+		name    string
+		next    uint64
+		wantErr error
+	}{
+		{name: "Last number that fits", next: 24},
+		{name: "First number that does not fit", next: 25, wantErr: codegen.ErrCounterOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := codegen.NewCounterGenerator(&sequence{next: tt.next})
+			g.Alphabet = "abcde"
+			g.MaxLength = 2
+
+			code, err := g.Generate(2)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, code, 2)
+		})
+	}
+}
+
+func TestCounterGenerator_CustomAlphabet(t *testing.T) {
+	// Множитель 5 не взаимно прост с размером алфавита и должен быть заменен:
+	// иначе коды длины 2 не покрыли бы все 25 вариантов
+	g := codegen.NewCounterGenerator(&sequence{})
+	g.Alphabet = "abcde"
+	g.Multiplier = 5
+
+	seen := make(map[string]bool)
+	for i := 0; i < 25; i++ {
+		code, err := g.Generate(2)
+		require.NoError(t, err)
+		assert.Regexp(t, `^[a-e]{2}$`, code)
+		seen[code] = true
+	}
+	assert.Len(t, seen, 25)
+}
+
+func TestLowercase(t *testing.T) {
+	assert.Equal(t, "0123456789abcdefghijklmnopqrstuvwxyz", codegen.Lowercase(codegen.Base62))
+	assert.Equal(t, "23456789abcdefghijkmnopqrstuvwxyz", codegen.Lowercase(codegen.Base57))
+	assert.Equal(t, "abc", codegen.Lowercase("ABC"))
+}
+
+func TestNew(t *testing.T) {
+	_, err := codegen.New("counter", "", nil)
+	assert.Error(t, err)
+
+	_, err = codegen.New("uuid", "", nil)
+	assert.ErrorIs(t, err, codegen.ErrUnknownStrategy)
+
+	g, err := codegen.New("words", "", nil)
+	require.NoError(t, err)
+	assert.IsType(t, codegen.WordGenerator{}, g)
+}
+
+// fixedGenerator выдает коды из списка по порядку.
+type fixedGenerator struct {
+	codes []string
+}
+
+func (g *fixedGenerator) Generate(length int) (string, error) {
+	code := g.codes[0]
+	g.codes = g.codes[1:]
+	return code, nil
+}
+
+func TestAllocator(t *testing.T) {
+	t.Run("Retries on collision", func(t *testing.T) {
+		a := codegen.NewAllocator(&fixedGenerator{codes: []string{"taken", "free"}})
+
+		code, err := a.Allocate(func(code string) (bool, error) { return code == "taken", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "free", code)
+		assert.Equal(t, int64(1), a.Metrics.Collisions.Value())
+		assert.Equal(t, int64(1), a.Metrics.Generated.Value())
+	})
+
+	t.Run("Skips candidates rejected by the filter", func(t *testing.T) {
+		a := codegen.NewAllocator(&fixedGenerator{codes: []string{"bad", "good"}})
+		a.Allowed = func(code string) bool { return code != "bad" }
+
+		code, err := a.Allocate(func(code string) (bool, error) { return false, nil })
+		require.NoError(t, err)
+		assert.Equal(t, "good", code)
+		assert.Equal(t, int64(1), a.Metrics.Rejected.Value())
+		assert.Equal(t, int64(0), a.Metrics.Collisions.Value())
+	})
+
+	t.Run("Escalates length when short codes are taken", func(t *testing.T) {
+		a := codegen.NewAllocator(&codegen.RandomGenerator{Alphabet: codegen.Base62})
+		a.Length, a.MaxLength, a.MaxAttempts = 2, 4, 3
+
+		code, err := a.Allocate(func(code string) (bool, error) { return len(code) < 4, nil })
+		require.NoError(t, err)
+		assert.Len(t, code, 4)
+		assert.Equal(t, int64(2), a.Metrics.Escalations.Value())
+		assert.Equal(t, int64(6), a.Metrics.Collisions.Value())
+	})
+
+	t.Run("Gives up after bounded attempts", func(t *testing.T) {
+		a := codegen.NewAllocator(&codegen.RandomGenerator{Alphabet: codegen.Base62})
+		a.Length, a.MaxLength, a.MaxAttempts = 2, 3, 2
+
+		calls := 0
+		_, err := a.Allocate(func(string) (bool, error) { calls++; return true, nil })
+		assert.ErrorIs(t, err, codegen.ErrCodeSpaceExhausted)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, int64(1), a.Metrics.Exhausted.Value())
+	})
+
+	t.Run("Stops on lookup error", func(t *testing.T) {
+		a := codegen.NewAllocator(&codegen.RandomGenerator{Alphabet: codegen.Base62})
+		dbErr := errors.New("db down")
+
+		_, err := a.Allocate(func(string) (bool, error) { return false, dbErr })
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
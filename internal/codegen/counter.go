@@ -0,0 +1,110 @@
+package codegen
+
+import (
+	"errors"
+	"math/bits"
+)
+
+const (
+	// counterMultiplier взаимно прост с размером алфавита, поэтому x → (x*m + offset) mod base^n —
+	// биекция на кодах длины n: соседние номера дают непохожие коды без коллизий.
+	counterMultiplier = 0x5DEECE66D
+	counterOffset     = 0xB
+)
+
+var ErrCounterOverflow = errors.New("номер последовательности не помещается в код максимальной длины")
+
+// CounterGenerator превращает номер из последовательности в код в алфавите Alphabet.
+// Номера не повторяются, поэтому коллизии возможны только с пользовательскими кодами.
+type CounterGenerator struct {
+	Seq      Sequence
+	Alphabet string
+	// MaxLength ограничивает удлинение кода: номер, которому нужен более длинный код,
+	// дает ErrCounterOverflow
+	MaxLength int
+
+	// Multiplier, если он не взаимно прост с размером алфавита, увеличивается до ближайшего подходящего
+	Multiplier uint64
+	Offset     uint64
+}
+
+func NewCounterGenerator(seq Sequence) *CounterGenerator {
+	return &CounterGenerator{
+		Seq:        seq,
+		Alphabet:   Base62,
+		MaxLength:  DefaultMaxLength,
+		Multiplier: counterMultiplier,
+		Offset:     counterOffset,
+	}
+}
+
+// Generate возвращает код длиной не меньше length; если номер не помещается
+// в пространство кодов этой длины, код удлиняется, но не дальше MaxLength.
+func (g *CounterGenerator) Generate(length int) (string, error) {
+	n, err := g.Seq.Next()
+	if err != nil {
+		return "", err
+	}
+
+	base := uint64(len(g.Alphabet))
+	multiplier := coprime(g.Multiplier, base)
+	for ; ; length++ {
+		if length > g.MaxLength {
+			return "", ErrCounterOverflow
+		}
+		space, ok := pow(base, length)
+		if !ok {
+			return "", ErrCounterOverflow
+		}
+		if n < space {
+			return encode(g.Alphabet, obfuscate(n, multiplier, g.Offset, space), length), nil
+		}
+	}
+}
+
+// pow возвращает base^n; ok == false, если степень не помещается в uint64.
+func pow(base uint64, n int) (uint64, bool) {
+	p := uint64(1)
+	for i := 0; i < n; i++ {
+		hi, lo := bits.Mul64(p, base)
+		if hi != 0 {
+			return 0, false
+		}
+		p = lo
+	}
+	return p, true
+}
+
+// coprime возвращает ближайшее к m число не меньше m, взаимно простое с base.
+func coprime(m, base uint64) uint64 {
+	for gcd(m, base) != 1 {
+		m++
+	}
+	return m
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// obfuscate вычисляет (n*m + offset) mod space без переполнения.
+func obfuscate(n, m, offset, space uint64) uint64 {
+	hi, lo := bits.Mul64(n, m%space)
+	product := bits.Rem64(hi, lo, space)
+	sum, carry := bits.Add64(product, offset%space, 0)
+	return bits.Rem64(carry, sum, space)
+}
+
+// encode записывает число ровно length символами алфавита, дополняя слева его первым символом.
+func encode(alphabet string, n uint64, length int) string {
+	base := uint64(len(alphabet))
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		b[i] = alphabet[n%base]
+		n /= base
+	}
+	return string(b)
+}
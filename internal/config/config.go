@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,78 @@ type Config struct {
 	DBName     string
 	AppPort    string
 	JWTSecret  string
+
+	// DebugAddr — адрес внутреннего слушателя с метриками /debug/vars; пусто — метрики не отдаются.
+	// Не публикуйте этот адрес наружу
+	DebugAddr string
+
+	// AppDomain — основной домен сервиса для коротких ссылок; пусто — любой незарегистрированный хост
+	AppDomain string
+	// UnknownHostRedirect — куда отправлять запросы на незнакомые домены; пусто — отвечать 404
+	UnknownHostRedirect string
+
+	// InactiveLinkPage и PausedLinkPage — страницы для еще не активных и приостановленных
+	// ссылок; пусто — встроенные страницы сервиса
+	InactiveLinkPage string
+	PausedLinkPage   string
+
+	// CodeStrategy — стратегия генерации коротких кодов: random, counter или words
+	CodeStrategy string
+	// CodeAlphabet — алфавит для random и counter: base62, base57 или свой набор символов
+	CodeAlphabet  string
+	CodeLength    int
+	CodeMaxLength int
+	// ShortCodeCase — сравнение коротких кодов: sensitive или insensitive
+	ShortCodeCase string
+	// ReservedCodes — коды через запятую, которые нельзя занять, вдобавок к путям маршрутов
+	ReservedCodes string
+	// BlocklistPath — файл с нежелательными словами вдобавок к встроенному словарю (формат как у
+	// internal/blocklist/words.txt); пусто — только встроенный
+	BlocklistPath string
+
+	// LinkRetentionDays — сколько дней удаленная ссылка и ее клики хранятся до окончательной очистки
+	LinkRetentionDays int
+	// CodeQuarantineDays — сколько дней после удаления код ссылки нельзя занять снова.
+	// Пока ссылка хранится, ее код занят в любом случае
+	CodeQuarantineDays int
+	// WebhookMaxAttempts — сколько раз отправляется событие, прежде чем доставка считается неудачной
+	WebhookMaxAttempts int
+
+	// ExportDir — каталог для файлов фоновых выгрузок кликов
+	ExportDir string
+	// ExportRetentionHours — сколько часов готовый файл выгрузки доступен для скачивания
+	ExportRetentionHours int
+	// ExportSyncLimit — выгрузки больше этого числа кликов выполняются в фоне
+	ExportSyncLimit int
+
+	// IPMode — как хранить IP-адреса кликов: full, truncate (последний октет, /48 для IPv6) или hash.
+	// По умолчанию full, чтобы обновление не меняло уже собираемые данные
+	IPMode string
+	// IPHashKey — секретный ключ HMAC для режима hash
+	IPHashKey string
+	// ClickRetentionDays — сколько дней хранятся сырые клики; счетчики кликов остаются. 0 — бессрочно.
+	// Удаление начинается только после полной пересборки счетчиков командой rollup-backfill
+	ClickRetentionDays int
+	// ExposeClickIPs — отдавать ли IP-адреса кликов в статистике ссылки
+	ExposeClickIPs bool
+	// AnalyticsMode — режим аналитики для ссылок без собственного: full, respect (DNT и Sec-GPC) или consent.
+	// По умолчанию full: учет согласия включается явно, сервером или для отдельной ссылки
+	AnalyticsMode string
+	// ConsentCookie — cookie, в которой сайт сохраняет согласие посетителя на аналитику
+	ConsentCookie string
+
+	// AppURL — внешний адрес сервиса со схемой, для ссылок в письмах
+	AppURL string
+	// SMTPHost — SMTP-сервер для писем; пусто — письма только записываются в лог
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	// MailFrom — адрес отправителя писем
+	MailFrom string
+
+	// QRLogoPath — PNG или JPEG с логотипом для центра QR-кодов; пусто — логотип недоступен
+	QRLogoPath string
 }
 
 func LoadConfig() *Config {
@@ -30,6 +103,43 @@ func LoadConfig() *Config {
 		DBName:     getEnv("DB_NAME", "url_shortener"),
 		AppPort:    getEnv("APP_PORT", "8080"),
 		JWTSecret:  getEnv("JWT_SECRET", "secret"),
+		QRLogoPath: getEnv("QR_LOGO_PATH", ""),
+		DebugAddr:  getEnv("DEBUG_ADDR", "127.0.0.1:6060"),
+
+		AppDomain:           getEnv("APP_DOMAIN", ""),
+		UnknownHostRedirect: getEnv("UNKNOWN_HOST_REDIRECT", ""),
+		InactiveLinkPage:    getEnv("INACTIVE_LINK_PAGE", ""),
+		PausedLinkPage:      getEnv("PAUSED_LINK_PAGE", ""),
+
+		CodeStrategy:  getEnv("CODE_STRATEGY", "random"),
+		CodeAlphabet:  getEnv("CODE_ALPHABET", "base62"),
+		CodeLength:    getEnvInt("CODE_LENGTH", 6),
+		CodeMaxLength: getEnvInt("CODE_MAX_LENGTH", 12),
+		ShortCodeCase: getEnv("SHORT_CODE_CASE", "sensitive"),
+		ReservedCodes: getEnv("RESERVED_CODES", "admin,login,logout,register,signup,help,support,about,www"),
+		BlocklistPath: getEnv("BLOCKLIST_PATH", ""),
+
+		LinkRetentionDays:  getEnvInt("LINK_RETENTION_DAYS", 30),
+		CodeQuarantineDays: getEnvInt("CODE_QUARANTINE_DAYS", 90),
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+
+		ExportDir:            getEnv("EXPORT_DIR", "exports"),
+		ExportRetentionHours: getEnvInt("EXPORT_RETENTION_HOURS", 72),
+		ExportSyncLimit:      getEnvInt("EXPORT_SYNC_LIMIT", 100000),
+
+		IPMode:             getEnv("IP_MODE", "full"),
+		IPHashKey:          getEnv("IP_HASH_KEY", ""),
+		ClickRetentionDays: getEnvInt("CLICK_RETENTION_DAYS", 0),
+		ExposeClickIPs:     getEnvBool("EXPOSE_CLICK_IPS", false),
+		AnalyticsMode:      getEnv("ANALYTICS_MODE", "full"),
+		ConsentCookie:      getEnv("CONSENT_COOKIE", "analytics_consent"),
+
+		AppURL:       getEnv("APP_URL", "http://localhost:8080"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUser:     getEnv("SMTP_USER", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "noreply@localhost"),
 	}
 }
 
@@ -40,3 +150,19 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+)
+
+const (
+	pollInterval    = 30 * time.Second
+	cleanupInterval = time.Hour
+	// staleAfter — через сколько выгрузка в состоянии running считается брошенной
+	staleAfter = time.Hour
+)
+
+// ClickSource читает клики для выгрузки.
+type ClickSource interface {
+	StreamClicks(filter models.ClickExportFilter, fn func(row *models.ClickExportRow) error) error
+}
+
+// WriteClicks выгружает клики по filter в w и возвращает число строк.
+func WriteClicks(source ClickSource, filter models.ClickExportFilter, format string, compress bool, w io.Writer) (int64, error) {
+	writer, err := NewWriter(format, compress, w)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	err = source.StreamClicks(filter, func(row *models.ClickExportRow) error {
+		rows++
+		return writer.Write(row)
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
+
+// Runner выполняет фоновые выгрузки по одной и хранит файлы в Dir до истечения Retention.
+type Runner struct {
+	Jobs      *repositories.ExportRepository
+	Clicks    ClickSource
+	Dir       string
+	Retention time.Duration
+
+	wake chan struct{}
+}
+
+func NewRunner(jobs *repositories.ExportRepository, clicks ClickSource, dir string, retention time.Duration) *Runner {
+	return &Runner{Jobs: jobs, Clicks: clicks, Dir: dir, Retention: retention, wake: make(chan struct{}, 1)}
+}
+
+// Wake сообщает, что в очереди появилась выгрузка.
+func (r *Runner) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start создает каталог для файлов и запускает обработку очереди.
+func (r *Runner) Start() error {
+	if err := os.MkdirAll(r.Dir, 0o750); err != nil {
+		return err
+	}
+	go r.loop()
+	return nil
+}
+
+func (r *Runner) loop() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var cleaned time.Time
+	for {
+		for {
+			job, err := r.Jobs.ClaimNext(staleAfter)
+			if errors.Is(err, repositories.ErrExportNotFound) {
+				break
+			}
+			if err != nil {
+				log.Printf("[ERROR] Ошибка выборки выгрузки: %v", err)
+				break
+			}
+			r.Run(job)
+		}
+
+		if time.Since(cleaned) >= cleanupInterval {
+			r.cleanup()
+			cleaned = time.Now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// Run выполняет выгрузку и сохраняет ее результат.
+func (r *Runner) Run(job *models.ExportJob) {
+	started := time.Now()
+	filter := models.ClickExportFilter{UserID: job.UserID, LinkID: job.LinkID, From: job.From, To: job.To}
+	path := filepath.Join(r.Dir, FileName(fmt.Sprintf("clicks-%d", job.ID), job.Format, job.Gzip))
+
+	rows, size, err := r.writeFile(path, filter, job)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка выгрузки %d: %v", job.ID, err)
+		if err := r.Jobs.Fail(job.ID, "Ошибка выгрузки кликов", r.Retention); err != nil {
+			log.Printf("[ERROR] Ошибка сохранения выгрузки %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	job.Rows = rows
+	job.SizeBytes = size
+	job.FilePath = path
+	if err := r.Jobs.Complete(job, r.Retention); err != nil {
+		log.Printf("[ERROR] Ошибка сохранения выгрузки %d: %v", job.ID, err)
+		os.Remove(path)
+		return
+	}
+	log.Printf("[INFO] Выгрузка %d готова: %d кликов, %d байт за %s", job.ID, rows, size, time.Since(started).Round(time.Millisecond))
+}
+
+// writeFile пишет выгрузку во временный файл и переименовывает его после успеха,
+// чтобы незаконченный файл нельзя было скачать.
+func (r *Runner) writeFile(path string, filter models.ClickExportFilter, job *models.ExportJob) (int64, int64, error) {
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(tmp)
+
+	rows, err := WriteClicks(r.Clicks, filter, job.Format, job.Gzip, f)
+	if err != nil {
+		f.Close()
+		return 0, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, 0, err
+	}
+	return rows, info.Size(), nil
+}
+
+// cleanup удаляет выгрузки с истекшим сроком хранения вместе с файлами.
+func (r *Runner) cleanup() {
+	paths, err := r.Jobs.DeleteExpired()
+	if err != nil {
+		log.Printf("[ERROR] Ошибка очистки выгрузок: %v", err)
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[WARN] Не удалось удалить файл выгрузки %s: %v", path, err)
+		}
+	}
+}
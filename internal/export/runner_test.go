@@ -0,0 +1,58 @@
+package export_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"url-short/internal/export"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_Run(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	dir := t.TempDir()
+	runner := export.NewRunner(repositories.NewExportRepository(db), clicks(3), dir, 72*time.Hour)
+	path := filepath.Join(dir, "clicks-12.csv.gz")
+
+	mock.ExpectQuery("UPDATE export_jobs SET status = 'completed'").
+		WithArgs(int64(3), sqlmock.AnyArg(), path, (72 * time.Hour).Seconds(), 12).
+		WillReturnRows(sqlmock.NewRows([]string{"completed_at", "expires_at"}).AddRow(time.Now(), time.Now().Add(72*time.Hour)))
+
+	job := &models.ExportJob{ID: 12, UserID: 1, Format: models.ExportFormatCSV, Gzip: true}
+	runner.Run(job)
+
+	assert.Equal(t, models.ExportCompleted, job.Status)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), job.SizeBytes)
+	_, err = os.Stat(path + ".part")
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunner_RunUnknownFormat(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	dir := t.TempDir()
+	runner := export.NewRunner(repositories.NewExportRepository(db), clicks(1), dir, time.Hour)
+
+	mock.ExpectExec("UPDATE export_jobs SET status = 'failed'").
+		WithArgs("Ошибка выгрузки кликов", time.Hour.Seconds(), 13).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	runner.Run(&models.ExportJob{ID: 13, UserID: 1, Format: "xlsx"})
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,217 @@
+// Package export записывает клики в форматы выгрузки: CSV, NDJSON и Parquet для
+// загрузки в хранилище данных. Строки пишутся по одной, поэтому выгрузка любого
+// размера не держится в памяти целиком; Parquet буферизует не больше одной группы строк.
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+	"url-short/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// RowGroupSize — сколько кликов попадает в одну группу строк Parquet.
+const RowGroupSize = 10000
+
+var (
+	ErrUnknownFormat     = errors.New("неизвестный формат выгрузки")
+	ErrCompressedParquet = errors.New("файл parquet уже сжат по колонкам, gzip для него не поддерживается")
+)
+
+// Writer записывает строки выгрузки. Close дописывает буферизованные данные,
+// но не закрывает исходный поток.
+type Writer interface {
+	Write(row *models.ClickExportRow) error
+	Close() error
+}
+
+// columns — колонки выгрузки в порядке CSV.
+var columns = []string{
+	"id", "link_code", "clicked_code", "domain", "destination", "link_version", "clicked_at",
+	"source", "location", "device_type", "os", "browser", "referrer", "user_agent",
+}
+
+// NewWriter возвращает Writer формата format; при gzip данные сжимаются.
+func NewWriter(format string, compress bool, w io.Writer) (Writer, error) {
+	if format == models.ExportFormatParquet {
+		if compress {
+			return nil, ErrCompressedParquet
+		}
+		return newParquetWriter(w), nil
+	}
+
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+
+	var writer Writer
+	switch format {
+	case models.ExportFormatCSV:
+		writer = newCSVWriter(w)
+	case models.ExportFormatNDJSON:
+		writer = &ndjsonWriter{enc: json.NewEncoder(w)}
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	if zw != nil {
+		return &gzipWriter{Writer: writer, zw: zw}, nil
+	}
+	return writer, nil
+}
+
+// ContentType возвращает MIME-тип файла выгрузки.
+func ContentType(format string, compress bool) string {
+	switch {
+	case compress:
+		return "application/gzip"
+	case format == models.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case format == models.ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// FileName возвращает имя файла выгрузки с расширением формата.
+func FileName(base, format string, compress bool) string {
+	var ext string
+	switch format {
+	case models.ExportFormatCSV:
+		ext = ".csv"
+	case models.ExportFormatParquet:
+		ext = ".parquet"
+	default:
+		ext = ".ndjson"
+	}
+	if compress {
+		ext += ".gz"
+	}
+	return base + ext
+}
+
+type gzipWriter struct {
+	Writer
+	zw *gzip.Writer
+}
+
+func (w *gzipWriter) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	cw.w.Write(columns)
+	return cw
+}
+
+func (w *csvWriter) Write(row *models.ClickExportRow) error {
+	w.record[0] = strconv.FormatInt(row.ID, 10)
+	w.record[1] = row.LinkCode
+	w.record[2] = row.ClickedCode
+	w.record[3] = row.Domain
+	w.record[4] = row.Destination
+	w.record[5] = strconv.Itoa(row.LinkVersion)
+	w.record[6] = row.ClickedAt.UTC().Format(time.RFC3339)
+	w.record[7] = row.Source
+	w.record[8] = row.Location
+	w.record[9] = row.DeviceType
+	w.record[10] = row.OS
+	w.record[11] = row.Browser
+	w.record[12] = row.Referrer
+	w.record[13] = row.UserAgent
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(row *models.ClickExportRow) error {
+	r := *row
+	r.ClickedAt = r.ClickedAt.UTC()
+	return w.enc.Encode(&r)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// parquetRow — строка выгрузки в схеме Parquet. Повторяющиеся строковые значения
+// кодируются словарем, колонки сжимаются zstd.
+type parquetRow struct {
+	ID          int64     `parquet:"id"`
+	LinkCode    string    `parquet:"link_code,dict"`
+	ClickedCode string    `parquet:"clicked_code,dict"`
+	Domain      string    `parquet:"domain,dict"`
+	Destination string    `parquet:"destination,dict"`
+	LinkVersion int32     `parquet:"link_version"`
+	ClickedAt   time.Time `parquet:"clicked_at,timestamp(millisecond:utc)"`
+	Source      string    `parquet:"source,dict"`
+	Location    string    `parquet:"location,dict"`
+	DeviceType  string    `parquet:"device_type,dict"`
+	OS          string    `parquet:"os,dict"`
+	Browser     string    `parquet:"browser,dict"`
+	Referrer    string    `parquet:"referrer"`
+	UserAgent   string    `parquet:"user_agent,dict"`
+}
+
+type parquetWriter struct {
+	w   *parquet.GenericWriter[parquetRow]
+	row [1]parquetRow
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: parquet.NewGenericWriter[parquetRow](w,
+		parquet.MaxRowsPerRowGroup(RowGroupSize),
+		parquet.Compression(&parquet.Zstd),
+	)}
+}
+
+func (w *parquetWriter) Write(row *models.ClickExportRow) error {
+	w.row[0] = parquetRow{
+		ID:          row.ID,
+		LinkCode:    row.LinkCode,
+		ClickedCode: row.ClickedCode,
+		Domain:      row.Domain,
+		Destination: row.Destination,
+		LinkVersion: int32(row.LinkVersion),
+		ClickedAt:   row.ClickedAt.UTC(),
+		Source:      row.Source,
+		Location:    row.Location,
+		DeviceType:  row.DeviceType,
+		OS:          row.OS,
+		Browser:     row.Browser,
+		Referrer:    row.Referrer,
+		UserAgent:   row.UserAgent,
+	}
+	_, err := w.w.Write(w.row[:])
+	return err
+}
+
+// Close дописывает последнюю группу строк и метаданные файла.
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
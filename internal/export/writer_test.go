@@ -0,0 +1,133 @@
+package export_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+	"url-short/internal/export"
+	"url-short/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceSource отдает заранее заданные клики.
+type sliceSource []models.ClickExportRow
+
+func (s sliceSource) StreamClicks(_ models.ClickExportFilter, fn func(row *models.ClickExportRow) error) error {
+	for i := range s {
+		if err := fn(&s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func clicks(n int) sliceSource {
+	clickedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rows := make(sliceSource, n)
+	for i := range rows {
+		rows[i] = models.ClickExportRow{
+			ID:          int64(i + 1),
+			LinkCode:    "abc",
+			ClickedCode: "abc",
+			Destination: "https://example.com/?a=1,2",
+			LinkVersion: 1,
+			ClickedAt:   clickedAt.Add(time.Duration(i) * time.Minute),
+			Source:      models.ClickSourceLink,
+			Browser:     "Firefox",
+		}
+	}
+	return rows
+}
+
+func TestWriteClicks_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := export.WriteClicks(clicks(2), models.ClickExportFilter{}, models.ExportFormatCSV, false, &buf)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "id", records[0][0])
+	assert.Equal(t, []string{"2", "abc", "abc", "", "https://example.com/?a=1,2", "1", "2026-03-01T12:01:00Z"}, records[2][:7])
+}
+
+func TestWriteClicks_NDJSONGzip(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := export.WriteClicks(clicks(3), models.ClickExportFilter{}, models.ExportFormatNDJSON, true, &buf)
+	require.NoError(t, err)
+
+	zr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(zr)
+	var lines int
+	for scanner.Scan() {
+		var row models.ClickExportRow
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		lines++
+		assert.EqualValues(t, lines, row.ID)
+	}
+	assert.Equal(t, 3, lines)
+}
+
+func TestWriteClicks_Parquet(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := export.WriteClicks(clicks(export.RowGroupSize+5), models.ClickExportFilter{}, models.ExportFormatParquet, false, &buf)
+	require.NoError(t, err)
+	assert.EqualValues(t, export.RowGroupSize+5, n)
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.EqualValues(t, export.RowGroupSize+5, file.NumRows())
+	require.Len(t, file.RowGroups(), 2)
+	assert.EqualValues(t, 5, file.RowGroups()[1].NumRows())
+
+	type row struct {
+		ID          int64     `parquet:"id"`
+		LinkCode    string    `parquet:"link_code"`
+		Destination string    `parquet:"destination"`
+		LinkVersion int32     `parquet:"link_version"`
+		ClickedAt   time.Time `parquet:"clicked_at,timestamp(millisecond:utc)"`
+		Browser     string    `parquet:"browser"`
+	}
+	reader := parquet.NewGenericReader[row](bytes.NewReader(buf.Bytes()))
+	defer reader.Close()
+	rows := make([]row, export.RowGroupSize+5)
+	read, err := reader.Read(rows)
+	if err != io.EOF {
+		require.NoError(t, err)
+	}
+	require.Equal(t, export.RowGroupSize+5, read)
+
+	last := rows[read-1]
+	assert.EqualValues(t, export.RowGroupSize+5, last.ID)
+	assert.Equal(t, "abc", last.LinkCode)
+	assert.Equal(t, "https://example.com/?a=1,2", last.Destination)
+	assert.EqualValues(t, 1, last.LinkVersion)
+	assert.True(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(read-1)*time.Minute).Equal(last.ClickedAt))
+	assert.Equal(t, "Firefox", last.Browser)
+}
+
+func TestNewWriter_ParquetRejectsGzip(t *testing.T) {
+	_, err := export.NewWriter(models.ExportFormatParquet, true, io.Discard)
+	assert.ErrorIs(t, err, export.ErrCompressedParquet)
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := export.NewWriter("xlsx", false, io.Discard)
+	assert.ErrorIs(t, err, export.ErrUnknownFormat)
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "clicks.csv", export.FileName("clicks", models.ExportFormatCSV, false))
+	assert.Equal(t, "clicks.ndjson.gz", export.FileName("clicks", models.ExportFormatNDJSON, true))
+	assert.Equal(t, "clicks.parquet", export.FileName("clicks", models.ExportFormatParquet, false))
+}
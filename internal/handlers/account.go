@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/export"
+	"url-short/internal/mail"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AccountHandler struct {
+	UserRepo     *repositories.UserRepository
+	LinkRepo     *repositories.LinkRepository
+	TagRepo      *repositories.TagRepository
+	AnalyticRepo *repositories.AnalyticRepository
+	SessionRepo  *repositories.SessionRepository
+
+	// Mailer отправляет письма подтверждения email и предложения передать ссылки
+	Mailer mail.Sender
+	// AppURL — внешний адрес сервиса для ссылок в письмах
+	AppURL string
+
+	// CodeQuarantine — сколько коды удаленных вместе с аккаунтом ссылок нельзя занять снова
+	CodeQuarantine time.Duration
+}
+
+// ExportAccount godoc
+// @Summary Выгрузка данных аккаунта
+// @Description ZIP-архив с профилем (profile.json), ссылками, включая удаленные (links.json),
+// @Description тегами (tags.json) и кликами по ссылкам (clicks.ndjson)
+// @Tags account
+// @Security ApiKeyAuth
+// @Produce application/zip
+// @Success 200 {file} binary
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me/export [get]
+func (h *AccountHandler) ExportAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(int)
+	user, err := h.UserRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeUserNotFound, "Пользователь не найден")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка выгрузки аккаунта")
+		return
+	}
+
+	active, err := h.LinkRepo.FindByUserID(userID, models.LinkFilter{})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка выгрузки аккаунта")
+		return
+	}
+	deleted, err := h.LinkRepo.FindDeleted(userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка выгрузки аккаунта")
+		return
+	}
+	tags, err := h.TagRepo.FindByUserID(userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка выгрузки аккаунта")
+		return
+	}
+
+	links := make([]models.LinkSummary, 0, len(active)+len(deleted))
+	for _, link := range active {
+		links = append(links, toLinkSummary(c, link))
+	}
+	for _, link := range deleted {
+		summary := toLinkSummary(c, link)
+		summary.DeletedAt = link.DeletedAt
+		links = append(links, summary)
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%s.zip"`, time.Now().UTC().Format(time.DateOnly)))
+	c.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому об ошибке посреди выгрузки остается только записать в лог
+	zw := zip.NewWriter(c.Writer)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", user.Profile()},
+		{"links.json", links},
+		{"tags.json", tags},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(file.data)
+		}
+		if err != nil {
+			log.Printf("[ERROR] Ошибка выгрузки аккаунта: %v | Пользователь: %d", err, userID)
+			return
+		}
+	}
+
+	w, err := zw.Create("clicks.ndjson")
+	if err != nil {
+		log.Printf("[ERROR] Ошибка выгрузки аккаунта: %v | Пользователь: %d", err, userID)
+		return
+	}
+	filter := models.ClickExportFilter{UserID: userID, To: time.Now().UTC().Add(time.Minute)}
+	rows, err := export.WriteClicks(h.AnalyticRepo, filter, models.ExportFormatNDJSON, false, w)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		log.Printf("[ERROR] Ошибка выгрузки аккаунта: %v | Пользователь: %d", err, userID)
+		return
+	}
+	log.Printf("[INFO] Аккаунт выгружен | Ссылок: %d | Кликов: %d | Пользователь: %d", len(links), rows, userID)
+}
+
+// DeleteAccount godoc
+// @Summary Удалить аккаунт
+// @Description Удаляет аккаунт после повторного ввода пароля: ссылки удаляются вместе с кликами, а их коды на время
+// @Description уходят в карантин. Если указан transfer_to, аккаунт не удаляется сразу: получателю предлагается принять
+// @Description ссылки с кликами, собственные домены, теги и папки, и аккаунт удаляется, когда он примет передачу.
+// @Description Ответ на передачу не зависит от того, зарегистрирован ли получатель
+// @Tags account
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body models.DeleteAccountRequest true "Пароль и получатель ссылок"
+// @Success 202 "Передача предложена получателю"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me [delete]
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	userID := c.MustGet("userID").(int)
+	user, err := h.UserRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeUserNotFound, "Пользователь не найден")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления аккаунта")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeInvalidPassword, "Неверный пароль")
+		return
+	}
+
+	if req.TransferTo != "" {
+		h.offerTransfer(c, user, req.TransferTo)
+		return
+	}
+
+	if !h.deleteAccount(c, userID, nil) {
+		return
+	}
+	log.Printf("[INFO] Аккаунт удален | Пользователь: %d", userID)
+	c.Status(http.StatusNoContent)
+}
+
+// deleteAccount удаляет аккаунт и файлы его выгрузок; transferTo — получатель ссылок.
+func (h *AccountHandler) deleteAccount(c *gin.Context, userID int, transferTo *int) bool {
+	paths, err := h.UserRepo.DeleteAccount(userID, transferTo, h.CodeQuarantine)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeUserNotFound, "Пользователь не найден")
+			return false
+		}
+		log.Printf("[ERROR] Ошибка удаления аккаунта: %v | Пользователь: %d", err, userID)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления аккаунта")
+		return false
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[WARN] Не удалось удалить файл выгрузки %s: %v", path, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,340 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/handlers"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupAccountHandler(t *testing.T) (*handlers.AccountHandler, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+
+	return &handlers.AccountHandler{
+		UserRepo:       repositories.NewUserRepository(db),
+		LinkRepo:       repositories.NewLinkRepository(db),
+		TagRepo:        repositories.NewTagRepository(db),
+		AnalyticRepo:   repositories.NewAnalyticRepository(db),
+		SessionRepo:    repositories.NewSessionRepository(db),
+		Mailer:         &fakeMailer{},
+		AppURL:         "https://sho.rt/",
+		CodeQuarantine: 24 * time.Hour,
+	}, mock, db
+}
+
+// fakeMailer запоминает отправленные письма.
+type fakeMailer struct {
+	sent []sentMail
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+func (m *fakeMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to, subject, body})
+	return nil
+}
+
+func userRows(id int, email, password string) *sqlmock.Rows {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return sqlmock.NewRows([]string{"id", "username", "email", "password_hash", "created_at", "pending_email", "locale"}).
+		AddRow(id, "user", email, string(hash), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "", "")
+}
+
+func TestExportAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, username, email, password_hash, created_at, (.+) FROM users WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(userRows(1, "test@example.com", "secret"))
+	mock.ExpectQuery("FROM links WHERE links.user_id = \\$1 AND links.deleted_at IS NULL").
+		WithArgs(1).
+		WillReturnRows(linkRowsWithTags(linkRow{
+			ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one", Tags: "{sale}",
+		}))
+	mock.ExpectQuery("SELECT (.+), deleted_at FROM links WHERE user_id = \\$1 AND deleted_at IS NOT NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, linkColumns...), "deleted_at")).
+			AddRow(append(linkRow{ID: 11, UserID: 1, OriginalURL: "https://example.com/2", ShortCode: "two"}.values(), time.Now())...))
+	mock.ExpectQuery("FROM tags t").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "count"}).AddRow(3, "sale", 1))
+	mock.ExpectQuery("FROM click_analytics ca JOIN links l (.+) WHERE l.user_id = \\$1").
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "link_code", "clicked_code", "domain", "destination", "link_version", "clicked_at",
+			"source", "location", "device_type", "os", "browser", "referrer", "user_agent",
+		}).AddRow(7, "one", "one", "", "https://example.com/1", 1, time.Now(), "direct", "", "", "", "", "", "curl"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/me/export", nil)
+	c.Set("userID", 1)
+
+	handler.ExportAccount(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+
+	assert.Contains(t, files["profile.json"], `"email": "test@example.com"`)
+	assert.Contains(t, files["links.json"], `"short_code": "one"`)
+	assert.Contains(t, files["links.json"], `"short_code": "two"`)
+	assert.Contains(t, files["links.json"], `"deleted_at"`)
+	assert.Contains(t, files["tags.json"], `"name": "sale"`)
+	assert.Contains(t, files["clicks.ndjson"], `"user_agent":"curl"`)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	offered := `{"message":"Аккаунт будет удален, когда получатель примет передачу"}`
+
+	tests := []struct {
+		name          string
+		requestBody   string
+		mockClosure   func(mock sqlmock.Sqlmock)
+		expectedCode  int
+		expectedBody  string
+		expectedMails int
+	}{
+		{
+			name:        "Delete links",
+			requestBody: `{"password": "secret"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO quarantined_codes").
+					WithArgs(1, float64(24*60*60)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM links WHERE user_id = \\$1").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				expectAccountDeletion(mock, 1)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "Offer transfer",
+			requestBody: `{"password": "secret", "transfer_to": "colleague@example.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectQuery("FROM users WHERE email = ?").
+					WithArgs("colleague@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password_hash"}).
+						AddRow(2, "colleague", "colleague@example.com", "hash"))
+				mock.ExpectExec("INSERT INTO account_transfers (.+) ON CONFLICT \\(from_user_id\\) DO UPDATE").
+					WithArgs(1, 2, float64(7*24*60*60)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("SELECT locale FROM users WHERE id = ?").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow(""))
+			},
+			expectedCode:  http.StatusAccepted,
+			expectedBody:  offered,
+			expectedMails: 1,
+		},
+		{
+			name:        "Unknown recipient looks the same",
+			requestBody: `{"password": "secret", "transfer_to": "nobody@example.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectQuery("FROM users WHERE email = ?").
+					WithArgs("nobody@example.com").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: offered,
+		},
+		{
+			name:        "Wrong password",
+			requestBody: `{"password": "wrong"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: "Неверный пароль",
+		},
+		{
+			name:        "Transfer to self",
+			requestBody: `{"password": "secret", "transfer_to": "Test@Example.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "самому себе",
+		},
+		{
+			name:         "Missing password",
+			requestBody:  `{}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("DELETE", "/api/me", strings.NewReader(tt.requestBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("userID", 1)
+
+			handler.DeleteAccount(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			sent := handler.Mailer.(*fakeMailer).sent
+			assert.Len(t, sent, tt.expectedMails)
+			if tt.expectedMails > 0 {
+				assert.Equal(t, "colleague@example.com", sent[0].to)
+				assert.Contains(t, sent[0].body, "user (test@example.com) удаляет аккаунт")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectAccountDeletion ожидает удаление выгрузок и самого пользователя в конце транзакции.
+func expectAccountDeletion(mock sqlmock.Sqlmock, userID int) {
+	mock.ExpectQuery("DELETE FROM export_jobs WHERE user_id = \\$1 RETURNING file_path").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+	mock.ExpectExec("DELETE FROM users WHERE id = \\$1").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestAcceptTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	transferColumns := []string{"id", "from_user_id", "to_user_id", "username", "email", "count", "expires_at", "created_at"}
+
+	tests := []struct {
+		name         string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM account_transfers t JOIN users u ON u.id = t.from_user_id WHERE t.id = \\$1 AND t.to_user_id = \\$2 AND t.expires_at > NOW\\(\\)").
+					WithArgs(3, 2).
+					WillReturnRows(sqlmock.NewRows(transferColumns).
+						AddRow(3, 1, 2, "user", "test@example.com", 3, time.Now().Add(time.Hour), time.Now()))
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM domains src USING domains dst").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM domains dst USING domains src").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO tags").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE link_tags").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO folders").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE links l SET folder_id").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE domains SET user_id = \\$2").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE links SET user_id = \\$2").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 3))
+				expectAccountDeletion(mock, 1)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name: "Not addressed to the user or expired",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM account_transfers t").
+					WithArgs(3, 2).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/me/transfers/3/accept", nil)
+			c.Params = gin.Params{{Key: "id", Value: "3"}}
+			c.Set("userID", 2)
+
+			handler.AcceptTransfer(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeclineTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM account_transfers WHERE id = \\$1 AND to_user_id = \\$2").
+		WithArgs(3, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/me/transfers/3", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	c.Set("userID", 2)
+
+	handler.DeclineTransfer(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"transfer_not_found"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transferTTL — сколько получатель может принять передачу ссылок.
+const transferTTL = 7 * 24 * time.Hour
+
+// offerTransfer предлагает получателю принять ссылки удаляемого аккаунта. Ответ не
+// зависит от того, зарегистрирован ли адрес, чтобы по нему нельзя было это проверить.
+func (h *AccountHandler) offerTransfer(c *gin.Context, user *models.User, email string) {
+	if strings.EqualFold(email, user.Email) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Нельзя передать ссылки самому себе")
+		return
+	}
+
+	target, err := h.UserRepo.FindByEmail(email)
+	switch {
+	case errors.Is(err, repositories.ErrUserNotFound):
+		log.Printf("[INFO] Получатель ссылок не зарегистрирован, передача не создана | Пользователь: %d", user.ID)
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка передачи ссылок")
+		return
+	default:
+		if err := h.UserRepo.OfferTransfer(user.ID, target.ID, transferTTL); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка передачи ссылок")
+			return
+		}
+		h.notifyTransfer(c, user, target)
+		log.Printf("[INFO] Предложена передача ссылок | Пользователь: %d | Получатель: %d", user.ID, target.ID)
+	}
+
+	c.JSON(http.StatusAccepted, models.TransferOfferResponse{
+		Message: i18n.T(i18n.FromContext(c), "Аккаунт будет удален, когда получатель примет передачу"),
+	})
+}
+
+// notifyTransfer сообщает получателю о предложенной передаче. Ошибка отправки
+// не мешает предложению: оно видно в GET /api/me/transfers.
+func (h *AccountHandler) notifyTransfer(c *gin.Context, from, to *models.User) {
+	locale := i18n.FromContext(c)
+	if l, err := h.UserRepo.FindLocale(to.ID); err == nil && i18n.Supported(l) {
+		locale = l
+	}
+
+	link := strings.TrimRight(h.AppURL, "/") + "/api/me/transfers"
+	body := i18n.Tf(locale, "Здравствуйте, %s!\n\nПользователь %s (%s) удаляет аккаунт и предлагает вам принять его ссылки, "+
+		"собственные домены, теги и папки. Принять или отклонить передачу можно в течение %d дн.:\n%s\n\n"+
+		"Если вы не ждали этого письма, просто проигнорируйте его.\n",
+		to.Username, from.Username, from.Email, int(transferTTL.Hours()/24), link)
+	if err := h.Mailer.Send(to.Email, i18n.T(locale, "Передача ссылок"), body); err != nil {
+		log.Printf("[WARN] Не удалось отправить уведомление о передаче ссылок: %v | Получатель: %d", err, to.ID)
+	}
+}
+
+// CancelTransfer godoc
+// @Summary Отозвать передачу ссылок
+// @Description Отзывает предложение принять ссылки аккаунта; аккаунт остается
+// @Tags account
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /api/me/transfer [delete]
+func (h *AccountHandler) CancelTransfer(c *gin.Context) {
+	if err := h.UserRepo.CancelTransfer(c.MustGet("userID").(int)); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка отзыва передачи")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListTransfers godoc
+// @Summary Предложенные передачи ссылок
+// @Description Действующие предложения других пользователей принять ссылки их аккаунтов
+// @Tags account
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.AccountTransfer
+// @Router /api/me/transfers [get]
+func (h *AccountHandler) ListTransfers(c *gin.Context) {
+	transfers, err := h.UserRepo.FindTransfers(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения передач")
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
+}
+
+// AcceptTransfer godoc
+// @Summary Принять передачу ссылок
+// @Description Ссылки с кликами, собственные домены, теги и папки переходят к текущему пользователю,
+// @Description а аккаунт, который их передал, удаляется
+// @Tags account
+// @Security ApiKeyAuth
+// @Param id path int true "ID передачи"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me/transfers/{id}/accept [post]
+func (h *AccountHandler) AcceptTransfer(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	userID := c.MustGet("userID").(int)
+	transfer, err := h.UserRepo.FindTransfer(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransferNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeTransferNotFound, "Передача не найдена")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска передачи")
+		return
+	}
+
+	if !h.deleteAccount(c, transfer.FromUserID, &userID) {
+		return
+	}
+	log.Printf("[INFO] Аккаунт удален, ссылки переданы | Пользователь: %d | Получатель: %d", transfer.FromUserID, userID)
+	c.Status(http.StatusNoContent)
+}
+
+// DeclineTransfer godoc
+// @Summary Отклонить передачу ссылок
+// @Tags account
+// @Security ApiKeyAuth
+// @Param id path int true "ID передачи"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me/transfers/{id} [delete]
+func (h *AccountHandler) DeclineTransfer(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.UserRepo.DeclineTransfer(c.MustGet("userID").(int), id)
+	switch {
+	case errors.Is(err, repositories.ErrTransferNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeTransferNotFound, "Передача не найдена")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка отклонения передачи")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
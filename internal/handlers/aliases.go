@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAliases godoc
+// @Summary Алиасы ссылки
+// @Description Дополнительные коды, которые ведут туда же, что и основной код
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {array} models.LinkAlias
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/aliases [get]
+func (h *LinkHandler) ListAliases(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	aliases, err := h.AliasRepo.FindByLinkID(link.ID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения алиасов")
+		return
+	}
+	c.JSON(http.StatusOK, aliases)
+}
+
+// AddAlias godoc
+// @Summary Добавить алиас
+// @Description Алиас создается на домене ссылки; переходы по нему учитываются в статистике ссылки с указанием кода
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.AliasRequest true "Код алиаса"
+// @Success 201 {object} models.LinkAlias
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/aliases [post]
+func (h *LinkHandler) AddAlias(c *gin.Context) {
+	var req models.AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if linkErr := h.checkCustomCode(link.DomainID, link.Domain, req.ShortCode, nil); linkErr != nil {
+		linkErr.respond(c)
+		return
+	}
+
+	alias := &models.LinkAlias{
+		LinkID:    link.ID,
+		DomainID:  link.DomainID,
+		ShortCode: req.ShortCode,
+	}
+	if err := h.AliasRepo.Create(alias); err != nil {
+		if errors.Is(err, repositories.ErrAliasExists) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeCodeTaken, "Код уже занят")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка добавления алиаса")
+		return
+	}
+	log.Printf("[INFO] Алиас добавлен: %s → %s", alias.ShortCode, link.ShortCode)
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// DeleteAlias godoc
+// @Summary Удалить алиас
+// @Description Код перестает работать, но клики по нему остаются в статистике ссылки.
+// @Description Занять код снова можно только после карантина (CODE_QUARANTINE_DAYS)
+// @Tags links
+// @Security ApiKeyAuth
+// @Param short_code path string true "Короткий код ссылки"
+// @Param id path int true "ID алиаса"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/aliases/{id} [delete]
+func (h *LinkHandler) DeleteAlias(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	err := h.AliasRepo.Delete(link.ID, id, h.CodeQuarantine)
+	switch {
+	case errors.Is(err, repositories.ErrAliasNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeAliasNotFound, "Алиас не найден")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления алиаса")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,260 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAlias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"short_code": "spring-sale"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "one").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM links (.+) OR EXISTS\\(SELECT 1 FROM link_aliases").
+					WithArgs(nil, "spring-sale").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("INSERT INTO link_aliases").
+					WithArgs(10, nil, "spring-sale").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, time.Now()))
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `"short_code":"spring-sale"`,
+		},
+		{
+			name:        "Code taken by another link",
+			requestBody: `{"short_code": "promo"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(nil, "promo").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"error":"Код уже занят"`,
+		},
+		{
+			name:        "Concurrent alias with the same code",
+			requestBody: `{"short_code": "promo"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT EXISTS").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("INSERT INTO link_aliases").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"error":"Код уже занят"`,
+		},
+		{
+			name:        "Reserved code",
+			requestBody: `{"short_code": "static"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Код зарезервирован сервисом, выберите другой"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/links/one/aliases", strings.NewReader(tt.requestBody))
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+			c.Set("userID", 1)
+
+			handler.AddAlias(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteAlias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WithArgs(nil, "one").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+	mock.ExpectExec("DELETE FROM link_aliases WHERE id = \\$1 AND link_id = \\$2").
+		WithArgs(4, 10).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/links/one/aliases/4", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}, {Key: "id", Value: "4"}}
+	c.Set("userID", 1)
+
+	handler.DeleteAlias(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAlias_Quarantine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	handler.CodeQuarantine = 24 * time.Hour
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WithArgs(nil, "one").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+	mock.ExpectExec("DELETE FROM link_aliases (.+) INSERT INTO quarantined_codes").
+		WithArgs(4, 10, float64(24*60*60)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/links/one/aliases/4", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}, {Key: "id", Value: "4"}}
+	c.Set("userID", 1)
+
+	handler.DeleteAlias(c)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedirect_Alias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id").
+		WithArgs(nil, "old-promo").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT link_id, short_code FROM link_aliases").
+		WithArgs(nil, "old-promo").
+		WillReturnRows(sqlmock.NewRows([]string{"link_id", "short_code"}).AddRow(10, "old-promo"))
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE id = \\$1").
+		WithArgs(10).
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/spring", ShortCode: "spring"}))
+	mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+	mock.ExpectQuery("UPDATE links SET click_count").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO click_analytics").
+		WithArgs(10, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "", nil, "link", "old-promo", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/old-promo", nil)
+	c.Request.RemoteAddr = "127.0.0.1:1234"
+	c.Params = gin.Params{{Key: "short_code", Value: "old-promo"}}
+
+	handler.Redirect(c)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://example.com/spring", w.Header().Get("Location"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedirect_AliasSharesVariant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expectAliasRedirect := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id").
+			WithArgs(nil, "old-promo").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT link_id, short_code FROM link_aliases").
+			WithArgs(nil, "old-promo").
+			WillReturnRows(sqlmock.NewRows([]string{"link_id", "short_code"}).AddRow(10, "old-promo"))
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE id = \\$1").
+			WithArgs(10).
+			WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/spring", ShortCode: "spring"}))
+		mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}).
+				AddRow(20, 10, "https://a.example.com", 99).
+				AddRow(21, 10, "https://b.example.com", 1))
+		mock.ExpectQuery("UPDATE links SET click_count").
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO click_analytics").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	}
+
+	t.Run("Variant assigned on the main code is kept", func(t *testing.T) {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+		expectAliasRedirect(mock)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/old-promo", nil)
+		c.Request.RemoteAddr = "127.0.0.1:1234"
+		c.Request.AddCookie(&http.Cookie{Name: "ab_10", Value: "21"})
+		c.Params = gin.Params{{Key: "short_code", Value: "old-promo"}}
+
+		handler.Redirect(c)
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://b.example.com", w.Header().Get("Location"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("New variant is remembered for every code of the link", func(t *testing.T) {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+		expectAliasRedirect(mock)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/old-promo", nil)
+		c.Request.RemoteAddr = "127.0.0.1:1234"
+		c.Params = gin.Params{{Key: "short_code", Value: "old-promo"}}
+
+		handler.Redirect(c)
+
+		cookies := w.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, "ab_10", cookies[0].Name)
+			assert.Equal(t, "/", cookies[0].Path)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -4,24 +4,32 @@ import (
 	"errors"
 	"net/http"
 	"time"
+	"url-short/internal/apierror"
 	"url-short/internal/config"
+	"url-short/internal/i18n"
 	"url-short/internal/models"
 	"url-short/internal/repositories"
+	"url-short/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// tokenTTL — срок действия токена и сессии входа.
+const tokenTTL = 24 * time.Hour
+
 type AuthHandler struct {
-	UserRepo *repositories.UserRepository
-	Config   *config.Config
+	UserRepo    *repositories.UserRepository
+	SessionRepo *repositories.SessionRepository
+	Config      *config.Config
 }
 
-func NewAuthHandler(userRepo *repositories.UserRepository, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(userRepo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		UserRepo: userRepo,
-		Config:   cfg,
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+		Config:      cfg,
 	}
 }
 
@@ -33,19 +41,19 @@ func NewAuthHandler(userRepo *repositories.UserRepository, cfg *config.Config) *
 // @Produce json
 // @Param   input body models.RegisterRequest true "Данные регистрации"
 // @Success 201 {object} models.RegisterResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные"})
+		apierror.RespondBinding(c, err, "Неверные данные")
 		return
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка хеширования"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка хеширования")
 		return
 	}
 
@@ -56,11 +64,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	if err := h.UserRepo.Create(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании пользователя"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка при создании пользователя")
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.RegisterResponse{Message: "Пользователь создан"})
+	c.JSON(http.StatusCreated, models.RegisterResponse{Message: i18n.T(i18n.FromContext(c), "Пользователь создан")})
 }
 
 // Login godoc
@@ -71,39 +79,56 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Produce json
 // @Param   input body models.LoginRequest true "Учетные данные"
 // @Success 200 {object} models.LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Router /api/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные данные"})
+		apierror.RespondBinding(c, err, "Неверные данные")
 		return
 	}
 
 	user, err := h.UserRepo.FindByEmail(req.Email)
 	if err != nil {
 		if errors.Is(err, repositories.ErrUserNotFound) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверные учетные данные"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Неверные учетные данные")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сервера"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сервера")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверные учетные данные"})
+		apierror.Respond(c, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Неверные учетные данные")
+		return
+	}
+
+	sessionID, err := utils.GenerateRandomCode(32)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации токена")
+		return
+	}
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+	if err := h.SessionRepo.Create(session, tokenTTL); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сервера")
 		return
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"sid":     session.ID,
+		"exp":     time.Now().Add(tokenTTL).Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(h.Config.JWTSecret))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации токена")
 		return
 	}
 
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 	"url-short/internal/config"
 	"url-short/internal/handlers"
 	"url-short/internal/repositories"
@@ -30,7 +31,7 @@ func setupAuthHandler(t *testing.T) (*handlers.AuthHandler, sqlmock.Sqlmock, *sq
 		JWTSecret: "test-secret-1234567890",
 	}
 
-	return handlers.NewAuthHandler(userRepo, cfg), mock, db
+	return handlers.NewAuthHandler(userRepo, repositories.NewSessionRepository(db), cfg), mock, db
 }
 
 func TestAuthHandler_Register(t *testing.T) {
@@ -58,7 +59,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			requestBody:  `{invalid-json}`,
 			mockClosure:  func(mock sqlmock.Sqlmock) {},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: `"error":"Неверные данные"`,
+			expectedBody: `"error":"Неверные данные","code":"invalid_json"`,
 		},
 		{
 			name:        "Duplicate Email",
@@ -75,7 +76,7 @@ func TestAuthHandler_Register(t *testing.T) {
 			requestBody:  `{"username": "testuser", "email": "test@example.com", "password": "1"}`,
 			mockClosure:  func(mock sqlmock.Sqlmock) {},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: `"error":"Неверные данные"`,
+			expectedBody: `"details":[{"field":"password","rule":"min","param":"6","message":"Минимальная длина — 6 символов"}]`,
 		},
 	}
 
@@ -126,6 +127,10 @@ func TestAuthHandler_Login(t *testing.T) {
 						sqlmock.NewRows([]string{"id", "username", "email", "password_hash"}).
 							AddRow(1, "testuser", "correct@example.com", hashedPassword),
 					)
+				mock.ExpectQuery("INSERT INTO user_sessions").
+					WithArgs(sqlmock.AnyArg(), 1, "", "192.0.2.1", float64(24*60*60)).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "last_seen_at", "expires_at"}).
+						AddRow(time.Now(), time.Now(), time.Now().Add(24*time.Hour)))
 			},
 			expectedCode: http.StatusOK,
 			checkToken:   true,
@@ -198,6 +203,7 @@ func TestAuthHandler_Login(t *testing.T) {
 				assert.True(t, token.Valid)
 				claims := token.Claims.(jwt.MapClaims)
 				assert.Equal(t, float64(1), claims["user_id"])
+				assert.Len(t, claims["sid"], 32)
 			}
 		})
 	}
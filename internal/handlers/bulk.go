@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// maxBulkRows — максимальное число строк в одном пакетном запросе.
+	maxBulkRows = 10000
+	// maxBulkBodySize — ограничение размера тела пакетного запроса.
+	maxBulkBodySize = 10 << 20
+)
+
+// bulkRow — строка пакетного запроса; parseError заполняется,
+// если строку не удалось разобрать еще до валидации.
+type bulkRow struct {
+	req        models.CreateLinkRequest
+	parseError *linkError
+}
+
+// BulkCreateLinks godoc
+// @Summary Пакетное создание ссылок
+// @Description Принимает JSON-массив или CSV (колонки url, custom_code, tags, expires_at).
+// @Description В режиме atomic ссылки создаются только если корректны все строки,
+// @Description в режиме partial сохраняются все корректные строки.
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Accept  text/csv
+// @Accept  multipart/form-data
+// @Produce json
+// @Param mode query string false "atomic или partial" default(partial)
+// @Param input body []models.CreateLinkRequest false "Ссылки (для JSON)"
+// @Param file formData file false "CSV-файл (для multipart)"
+// @Success 200 {object} models.BulkLinkResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.BulkLinkResponse
+// @Router /api/links/bulk [post]
+func (h *LinkHandler) BulkCreateLinks(c *gin.Context) {
+	mode := c.DefaultQuery("mode", models.BulkModePartial)
+	if mode != models.BulkModeAtomic && mode != models.BulkModePartial {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Неизвестный режим: ожидается atomic или partial")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBulkBodySize)
+
+	locale := i18n.FromContext(c)
+	rows, err := readBulkRows(c)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, i18n.Localize(locale, err))
+		return
+	}
+	if len(rows) == 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Нет строк для обработки")
+		return
+	}
+	if len(rows) > maxBulkRows {
+		apierror.Respondf(c, http.StatusBadRequest, apierror.CodeTooManyRows, "Слишком много строк: максимум %d", maxBulkRows)
+		return
+	}
+
+	userID := c.MustGet("userID").(int)
+	response := models.BulkLinkResponse{
+		Mode:    mode,
+		Results: make([]models.BulkLinkResult, len(rows)),
+	}
+	links := make([]*models.Link, len(rows))
+	taken := make(map[string]bool, len(rows))
+
+	for i := range rows {
+		result := &response.Results[i]
+		result.Row = i + 1
+		result.OriginalURL = rows[i].req.OriginalURL
+
+		link, linkErr := h.validateBulkRow(userID, &rows[i], taken, locale)
+		if linkErr != nil {
+			result.Status = models.BulkStatusFailed
+			result.Error = i18n.T(locale, linkErr.Message)
+			result.Code = linkErr.Code
+			result.Details = apierror.Translate(locale, linkErr.Details)
+			response.Failed++
+			continue
+		}
+		taken[h.takenKey(link.Domain, link.ShortCode)] = true
+		links[i] = link
+	}
+
+	if mode == models.BulkModeAtomic {
+		h.saveBulkAtomic(c, links, &response)
+		return
+	}
+
+	for i, link := range links {
+		if link == nil {
+			continue
+		}
+		result := &response.Results[i]
+		if err := h.LinkRepo.CreateLink(link); err != nil {
+			result.Status = models.BulkStatusFailed
+			result.Error = i18n.T(locale, "Ошибка сохранения ссылки")
+			result.Code = apierror.CodeInternal
+			response.Failed++
+			continue
+		}
+		if err := h.TagRepo.AttachToLink(userID, link.ID, link.Tags); err != nil {
+			log.Printf("[WARN] Ошибка сохранения тегов: %v | Код: %s", err, link.ShortCode)
+		}
+		result.Status = models.BulkStatusCreated
+		result.ShortCode = link.ShortCode
+		result.FullURL = fullURL(c, link)
+		response.Created++
+		h.emit(userID, models.EventLinkCreated, toLinkSummary(c, *link))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *LinkHandler) saveBulkAtomic(c *gin.Context, links []*models.Link, response *models.BulkLinkResponse) {
+	if response.Failed > 0 {
+		for i := range response.Results {
+			if links[i] != nil {
+				response.Results[i].Status = models.BulkStatusSkipped
+			}
+		}
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if err := h.LinkRepo.CreateLinksAtomic(links); err != nil {
+		log.Printf("[ERROR] Ошибка пакетного сохранения: %v", err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения ссылок")
+		return
+	}
+
+	for i, link := range links {
+		result := &response.Results[i]
+		result.Status = models.BulkStatusCreated
+		result.ShortCode = link.ShortCode
+		result.FullURL = fullURL(c, link)
+		h.emit(link.UserID, models.EventLinkCreated, toLinkSummary(c, *link))
+	}
+	response.Created = len(links)
+	c.JSON(http.StatusOK, response)
+}
+
+// validateBulkRow проверяет строку теми же правилами, что и одиночное создание.
+func (h *LinkHandler) validateBulkRow(userID int, row *bulkRow, taken map[string]bool, locale string) (*models.Link, *linkError) {
+	if row.parseError != nil {
+		return nil, row.parseError
+	}
+	if err := binding.Validator.ValidateStruct(&row.req); err != nil {
+		code, details := apierror.Describe(err, locale)
+		return nil, &linkError{Status: http.StatusBadRequest, Code: code, Message: "Неверные данные", Details: details}
+	}
+	if len(row.req.Destinations) > 0 {
+		return nil, invalidField("destinations", "not_allowed", "Варианты назначения не поддерживаются при пакетном создании")
+	}
+	return h.buildLink(userID, &row.req, taken)
+}
+
+// readBulkRows разбирает тело запроса в зависимости от Content-Type.
+func readBulkRows(c *gin.Context) ([]bulkRow, error) {
+	switch c.ContentType() {
+	case binding.MIMEMultipartPOSTForm:
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("Не передан файл")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, errors.New("Не удалось прочитать файл")
+		}
+		defer file.Close()
+		return parseBulkCSV(file)
+	case "text/csv":
+		return parseBulkCSV(c.Request.Body)
+	default:
+		var reqs []models.CreateLinkRequest
+		if err := json.NewDecoder(c.Request.Body).Decode(&reqs); err != nil {
+			return nil, errors.New("Ожидается JSON-массив ссылок")
+		}
+		rows := make([]bulkRow, len(reqs))
+		for i := range reqs {
+			rows[i].req = reqs[i]
+		}
+		return rows, nil
+	}
+}
+
+// parseBulkCSV читает CSV с заголовком. Обязательна колонка url (или original_url),
+// остальные — custom_code, tags (через «;» или «,») и expires_at — необязательны.
+func parseBulkCSV(r io.Reader) ([]bulkRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("Не удалось прочитать заголовок CSV")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel добавляет BOM в начало файла
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "original_url" {
+			name = "url"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("В CSV нет колонки url")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []bulkRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, i18n.Errorf("Ошибка разбора CSV: %v", err)
+		}
+		if len(rows) >= maxBulkRows {
+			return nil, i18n.Errorf("Слишком много строк: максимум %d", maxBulkRows)
+		}
+
+		row := bulkRow{req: models.CreateLinkRequest{
+			OriginalURL: field(record, "url"),
+			CustomCode:  field(record, "custom_code"),
+		}}
+		if tags := field(record, "tags"); tags != "" {
+			row.req.Tags = strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' })
+		}
+		if raw := field(record, "expires_at"); raw != "" {
+			expiresAt, err := parseExpiry(raw)
+			if err != nil {
+				row.parseError = invalidField("expires_at", "datetime", "Некорректная дата истечения")
+			} else {
+				row.req.ExpiresAt = &expiresAt
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseExpiry принимает дату в RFC 3339 или просто YYYY-MM-DD (начало дня по UTC).
+func parseExpiry(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// ExportLinks godoc
+// @Summary Экспорт ссылок пользователя
+// @Description Возвращает все ссылки пользователя с количеством кликов в CSV или JSON
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "csv или json" default(json)
+// @Success 200 {array} models.LinkSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/links/export [get]
+func (h *LinkHandler) ExportLinks(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Неизвестный формат: ожидается csv или json")
+		return
+	}
+
+	userID := c.MustGet("userID").(int)
+	links, err := h.LinkRepo.FindByUserID(userID, models.LinkFilter{})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения ссылок")
+		return
+	}
+
+	exports := make([]models.LinkSummary, 0, len(links))
+	for _, link := range links {
+		exports = append(exports, toLinkSummary(c, link))
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, exports)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="links.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"short_code", "full_url", "original_url", "click_count", "tags", "expires_at", "created_at"})
+	for _, e := range exports {
+		expiresAt := ""
+		if e.ExpiresAt != nil {
+			expiresAt = e.ExpiresAt.Format(time.RFC3339)
+		}
+		w.Write([]string{
+			e.ShortCode,
+			e.FullURL,
+			e.OriginalURL,
+			strconv.Itoa(e.ClickCount),
+			strings.Join(e.Tags, ";"),
+			expiresAt,
+			e.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("[ERROR] Ошибка записи CSV: %v", err)
+	}
+}
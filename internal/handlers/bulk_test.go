@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkCreateLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           func() (string, string)
+		mockClosure    func(mock sqlmock.Sqlmock)
+		expectedCode   int
+		expectedStatus []string
+	}{
+		{
+			name:        "Partial mode JSON",
+			contentType: "application/json",
+			body: func() (string, string) {
+				return `[
+					{"original_url": "https://example.com/1", "custom_code": "one"},
+					{"original_url": "not-a-url"},
+					{"original_url": "https://example.com/2", "custom_code": "one"}
+				]`, ""
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(nil, "one").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("INSERT INTO links").
+					WithArgs(1, "https://example.com/1", "one", false, nil, nil, "", "", "", "", "", nil, "https://example.com/1", "active", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedCode:   http.StatusOK,
+			expectedStatus: []string{models.BulkStatusCreated, models.BulkStatusFailed, models.BulkStatusFailed},
+		},
+		{
+			name:        "Atomic mode rejects whole batch",
+			query:       "?mode=atomic",
+			contentType: "application/json",
+			body: func() (string, string) {
+				return `[{"original_url": "https://example.com/1", "custom_code": "one"}, {"original_url": "bad"}]`, ""
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(nil, "one").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedStatus: []string{models.BulkStatusSkipped, models.BulkStatusFailed},
+		},
+		{
+			name:        "Atomic mode CSV upload",
+			query:       "?mode=atomic",
+			contentType: "multipart",
+			body: func() (string, string) {
+				var buf bytes.Buffer
+				mw := multipart.NewWriter(&buf)
+				fw, _ := mw.CreateFormFile("file", "links.csv")
+				fw.Write([]byte("\ufeffurl,custom_code,tags,expires_at\n" +
+					"https://example.com/1,one,\"sale;summer\",2099-01-01\n" +
+					"https://example.com/2,two,,\n"))
+				mw.Close()
+				return buf.String(), mw.FormDataContentType()
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(nil, "one").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(nil, "two").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WithArgs(1, "https://example.com/1", "one", false, sqlmock.AnyArg(), nil, "", "", "", "", "", nil, "https://example.com/1", "active", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "sale").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO link_tags").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "summer").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO link_tags").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO links").
+					WithArgs(1, "https://example.com/2", "two", false, nil, nil, "", "", "", "", "", nil, "https://example.com/2", "active", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectCommit()
+			},
+			expectedCode:   http.StatusOK,
+			expectedStatus: []string{models.BulkStatusCreated, models.BulkStatusCreated},
+		},
+		{
+			name:        "CSV with invalid date",
+			contentType: "text/csv",
+			body: func() (string, string) {
+				return "url,expires_at\nhttps://example.com/1,tomorrow\n", ""
+			},
+			mockClosure:    func(mock sqlmock.Sqlmock) {},
+			expectedCode:   http.StatusOK,
+			expectedStatus: []string{models.BulkStatusFailed},
+		},
+		{
+			name:        "CSV without url column",
+			contentType: "text/csv",
+			body: func() (string, string) {
+				return "link\nhttps://example.com/1\n", ""
+			},
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Unknown mode",
+			query:       "?mode=all",
+			contentType: "application/json",
+			body: func() (string, string) {
+				return `[]`, ""
+			},
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			body, contentType := tt.body()
+			if contentType == "" {
+				contentType = tt.contentType
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/links/bulk"+tt.query, strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", contentType)
+			c.Set("userID", 1)
+
+			handler.BulkCreateLinks(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code, w.Body.String())
+			if tt.expectedStatus != nil {
+				var response models.BulkLinkResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				statuses := make([]string, 0, len(response.Results))
+				for _, r := range response.Results {
+					statuses = append(statuses, r.Status)
+				}
+				assert.Equal(t, tt.expectedStatus, statuses)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExportLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, format := range []string{"json", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT (.+) FROM links WHERE links.user_id = \\$1").
+				WithArgs(1).
+				WillReturnRows(linkRowsWithTags(linkRow{
+					ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one",
+					ClickCount: 42, CreatedAt: createdAt, Tags: "{sale,summer}",
+				}))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/links/export?format="+format, nil)
+			c.Request.Host = "sho.rt"
+			c.Set("userID", 1)
+
+			handler.ExportLinks(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			if format == "json" {
+				assert.Contains(t, w.Body.String(), `"click_count":42`)
+				assert.Contains(t, w.Body.String(), `"tags":["sale","summer"]`)
+			} else {
+				assert.Equal(t,
+					"short_code,full_url,original_url,click_count,tags,expires_at,created_at\n"+
+						"one,sho.rt/one,https://example.com/1,42,sale;summer,,2025-01-02T03:04:05Z\n",
+					w.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultConsentCookie = "analytics_consent"
+
+// analyticsMode возвращает действующий режим аналитики ссылки.
+func (h *LinkHandler) analyticsMode(link *models.Link) string {
+	switch {
+	case link.AnalyticsMode != "":
+		return link.AnalyticsMode
+	case h.AnalyticsMode != "":
+		return h.AnalyticsMode
+	}
+	return models.AnalyticsModeFull
+}
+
+// trackingAllowed сообщает, можно ли сохранить клик целиком в режиме mode.
+// DNT: 1 и Sec-GPC: 1 запрещают это в любом режиме, кроме full; в режиме consent
+// нужна еще cookie согласия со значением true, 1 или granted.
+func (h *LinkHandler) trackingAllowed(c *gin.Context, mode string) bool {
+	if mode == models.AnalyticsModeFull {
+		return true
+	}
+	if c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1" {
+		return false
+	}
+	if mode != models.AnalyticsModeConsent {
+		return true
+	}
+
+	name := h.ConsentCookie
+	if name == "" {
+		name = defaultConsentCookie
+	}
+	value, err := c.Cookie(name)
+	if err != nil {
+		return false
+	}
+	granted, err := strconv.ParseBool(value)
+	return granted || (err != nil && value == "granted")
+}
+
+// SetAnalyticsMode godoc
+// @Summary Режим аналитики ссылки
+// @Description full — клики сохраняются целиком; respect — при DNT: 1 или Sec-GPC: 1 клик засчитывается анонимно,
+// @Description без IP-адреса, user agent и геолокации; consent — клик анонимный, пока посетитель не дал согласие
+// @Description в cookie. Пустой режим — режим сервера
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.AnalyticsModeRequest true "Режим аналитики"
+// @Success 200 {object} models.AnalyticsModeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/analytics-mode [put]
+func (h *LinkHandler) SetAnalyticsMode(c *gin.Context) {
+	var req models.AnalyticsModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Режим должен быть full, respect, consent или пустым")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if err := h.LinkRepo.SetAnalyticsMode(link.ID, req.Mode); err != nil {
+		if errors.Is(err, repositories.ErrLinkNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения режима аналитики")
+		return
+	}
+	link.AnalyticsMode = req.Mode
+
+	log.Printf("[INFO] Режим аналитики ссылки %s: %q | Пользователь: %d", link.ShortCode, req.Mode, link.UserID)
+	c.JSON(http.StatusOK, models.AnalyticsModeResponse{Mode: link.AnalyticsMode, Effective: h.analyticsMode(link)})
+}
@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectConsent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		serverMode string
+		linkMode   string
+		headers    map[string]string
+		cookie     *http.Cookie
+		anonymous  bool
+	}{
+		{name: "Respect without signals", serverMode: "respect", anonymous: false},
+		{name: "Respect with DNT", serverMode: "respect", headers: map[string]string{"DNT": "1"}, anonymous: true},
+		{name: "Respect with GPC", serverMode: "respect", headers: map[string]string{"Sec-GPC": "1"}, anonymous: true},
+		{name: "Link consent without cookie", serverMode: "full", linkMode: "consent", anonymous: true},
+		{
+			name: "Link consent with cookie", serverMode: "full", linkMode: "consent",
+			cookie: &http.Cookie{Name: "analytics_consent", Value: "granted"}, anonymous: false,
+		},
+		{
+			name: "GPC overrides consent cookie", linkMode: "consent",
+			headers: map[string]string{"Sec-GPC": "1"},
+			cookie:  &http.Cookie{Name: "analytics_consent", Value: "true"}, anonymous: true,
+		},
+		{name: "Link full ignores DNT", serverMode: "consent", linkMode: "full", headers: map[string]string{"DNT": "1"}, anonymous: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			handler.AnalyticsMode = tt.serverMode
+
+			mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+				WillReturnRows(linkRows(linkRow{
+					ID: 1, UserID: 1, OriginalURL: "https://example.com/?utm_source=sl", ShortCode: "valid",
+					TrackConversions: true, AnalyticsMode: tt.linkMode,
+				}))
+			mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+			mock.ExpectQuery("UPDATE links SET click_count").
+				WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+			if tt.anonymous {
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WithArgs(1, "", "", "", "", "", "", sqlmock.AnyArg(), nil, "", nil, "link", "valid", true).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			} else {
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WithArgs(1, "127.0.0.1", "test-agent", "localhost", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						sqlmock.AnyArg(), nil, "", sqlmock.AnyArg(), "link", "valid", false).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/valid", nil)
+			c.Request.RemoteAddr = "127.0.0.1:1234"
+			c.Request.Header.Set("User-Agent", "test-agent")
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+			if tt.cookie != nil {
+				c.Request.AddCookie(tt.cookie)
+			}
+			c.Params = gin.Params{{Key: "short_code", Value: "valid"}}
+
+			handler.Redirect(c)
+
+			assert.Equal(t, http.StatusMovedPermanently, w.Code)
+			// Анонимный клик не получает идентификатор для трекинга конверсий
+			assert.Equal(t, !tt.anonymous, strings.Contains(w.Header().Get("Location"), "clid="))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetAnalyticsMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Set consent",
+			requestBody: `{"mode": "consent"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc"}))
+				mock.ExpectExec("UPDATE links SET analytics_mode = \\$1 WHERE id = \\$2 AND deleted_at IS NULL").
+					WithArgs("consent", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"mode":"consent","effective":"consent"}`,
+		},
+		{
+			name:        "Reset to server mode",
+			requestBody: `{"mode": ""}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc", AnalyticsMode: "full"}))
+				mock.ExpectExec("UPDATE links SET analytics_mode").
+					WithArgs("", 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"mode":"","effective":"respect"}`,
+		},
+		{
+			name:         "Unknown mode",
+			requestBody:  `{"mode": "strict"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			handler.AnalyticsMode = "respect"
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PUT", "/api/links/abc/analytics-mode", strings.NewReader(tt.requestBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "short_code", Value: "abc"}}
+			c.Set("userID", 1)
+
+			handler.SetAnalyticsMode(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
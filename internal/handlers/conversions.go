@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clickIDParam — query-параметр, в котором адрес назначения получает идентификатор клика.
+const clickIDParam = "clid"
+
+// transparentGIF — прозрачный GIF 1x1 для пикселя конверсии.
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+type ConversionHandler struct {
+	ConversionRepo *repositories.ConversionRepository
+}
+
+// TrackConversion godoc
+// @Summary Зарегистрировать конверсию
+// @Description Вызывается сайтом назначения с идентификатором клика из параметра clid
+// @Tags conversions
+// @Accept  json
+// @Produce json
+// @Param input body models.ConversionRequest true "Данные конверсии"
+// @Success 201 {object} models.Conversion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/conversions [post]
+func (h *ConversionHandler) TrackConversion(c *gin.Context) {
+	var req models.ConversionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	conv := &models.Conversion{}
+	if req.Value != nil {
+		conv.Value = *req.Value
+	}
+
+	if err := h.ConversionRepo.Create(req.ClickID, conv); err != nil {
+		if errors.Is(err, repositories.ErrClickNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeClickNotFound, "Клик не найден")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения конверсии")
+		return
+	}
+
+	c.JSON(http.StatusCreated, conv)
+}
+
+// ConversionPixel godoc
+// @Summary Пиксель конверсии
+// @Description Регистрирует конверсию и всегда отдает прозрачный GIF 1x1
+// @Tags conversions
+// @Produce image/gif
+// @Param clid query string true "Идентификатор клика"
+// @Param value query number false "Ценность конверсии"
+// @Success 200 {file} binary
+// @Router /api/conversions/pixel.gif [get]
+func (h *ConversionHandler) ConversionPixel(c *gin.Context) {
+	// Пиксель не должен ломать страницу, поэтому ошибки только логируются
+	defer func() {
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "image/gif", transparentGIF)
+	}()
+
+	clickUID := c.Query(clickIDParam)
+	if clickUID == "" {
+		return
+	}
+
+	conv := &models.Conversion{}
+	if raw := c.Query("value"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 {
+			log.Printf("[WARN] Некорректная ценность конверсии: %q", raw)
+			return
+		}
+		conv.Value = value
+	}
+
+	if err := h.ConversionRepo.Create(clickUID, conv); err != nil {
+		log.Printf("[WARN] Ошибка сохранения конверсии: %v | Клик: %s", err, clickUID)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/handlers"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConversionHandler(t *testing.T) (*handlers.ConversionHandler, sqlmock.Sqlmock, *sql.DB) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+
+	return &handlers.ConversionHandler{
+		ConversionRepo: repositories.NewConversionRepository(db),
+	}, mock, db
+}
+
+func TestTrackConversion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"click_id": "uid123", "value": 19.99}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO conversions").
+					WithArgs("uid123", 19.99).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "click_id", "link_id", "created_at"}).
+						AddRow(1, 42, 7, time.Now()))
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `"value":19.99`,
+		},
+		{
+			name:        "Unknown click",
+			requestBody: `{"click_id": "missing"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO conversions").
+					WithArgs("missing", 0.0).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"error":"Клик не найден"`,
+		},
+		{
+			name:         "Negative value",
+			requestBody:  `{"click_id": "uid123", "value": -1}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Неверные данные"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupConversionHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/conversions", strings.NewReader(tt.requestBody))
+
+			handler.TrackConversion(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConversionPixel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupConversionHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO conversions").
+		WithArgs("uid123", 5.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "click_id", "link_id", "created_at"}).
+			AddRow(1, 42, 7, time.Now()))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/conversions/pixel.gif?clid=uid123&value=5", nil)
+
+	handler.ConversionPixel(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "GIF89a"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
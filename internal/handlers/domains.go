@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+	"url-short/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// verifyRecordPrefix — поддомен, в котором владелец публикует TXT-запись с токеном.
+	verifyRecordPrefix = "_urlshort-verify."
+	verifyValuePrefix  = "urlshort-verification="
+
+	verifyTimeout = 5 * time.Second
+)
+
+// TXTResolver — источник TXT-записей DNS. *net.Resolver подходит как есть,
+// в тестах подменяется заглушкой.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type DomainHandler struct {
+	DomainRepo *repositories.DomainRepository
+	Resolver   TXTResolver
+
+	// DefaultDomain — основной домен сервиса; его нельзя зарегистрировать как собственный
+	DefaultDomain string
+}
+
+// normalizeHost приводит имя хоста к виду, в котором оно хранится: без порта,
+// без завершающей точки и в нижнем регистре.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// fullURL собирает полный адрес короткой ссылки для ответа клиенту.
+// Ссылки без собственного домена живут на хосте, через который пришел запрос.
+func fullURL(c *gin.Context, link *models.Link) string {
+	return linkURL(link, c.Request.Host)
+}
+
+// linkURL собирает полный адрес ссылки; defaultHost — хост для ссылок без собственного домена.
+func linkURL(link *models.Link, defaultHost string) string {
+	host := link.Domain
+	if host == "" {
+		host = defaultHost
+	}
+	return host + "/" + link.ShortCode
+}
+
+func toDomainResponse(d models.Domain) models.DomainResponse {
+	return models.DomainResponse{
+		Domain:   d,
+		TXTName:  verifyRecordPrefix + d.Hostname,
+		TXTValue: verifyValuePrefix + d.VerificationToken,
+	}
+}
+
+// ListDomains godoc
+// @Summary Список собственных доменов
+// @Tags domains
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.DomainResponse
+// @Router /api/domains [get]
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	domains, err := h.DomainRepo.FindByUserID(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения доменов")
+		return
+	}
+
+	response := make([]models.DomainResponse, 0, len(domains))
+	for _, d := range domains {
+		response = append(response, toDomainResponse(d))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AddDomain godoc
+// @Summary Добавить собственный домен
+// @Description Домен начинает работать после подтверждения: нужно опубликовать TXT-запись txt_name со значением txt_value.
+// @Description Заявить можно и домен, который заявил другой пользователь, но не уже подтвержденный
+// @Tags domains
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param input body models.DomainRequest true "Имя домена"
+// @Success 201 {object} models.DomainResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/domains [post]
+func (h *DomainHandler) AddDomain(c *gin.Context) {
+	var req models.DomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Некорректное имя домена")
+		return
+	}
+
+	hostname := normalizeHost(req.Hostname)
+	if hostname == normalizeHost(h.DefaultDomain) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeDomainReserved, "Это основной домен сервиса")
+		return
+	}
+
+	token, err := utils.GenerateRandomCode(32)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации токена")
+		return
+	}
+
+	domain := &models.Domain{
+		UserID:            c.MustGet("userID").(int),
+		Hostname:          hostname,
+		VerificationToken: token,
+	}
+	if err := h.DomainRepo.Create(domain); err != nil {
+		if errors.Is(err, repositories.ErrDomainExists) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeDomainExists, "Домен уже зарегистрирован")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка добавления домена")
+		return
+	}
+	c.JSON(http.StatusCreated, toDomainResponse(*domain))
+}
+
+// VerifyDomain godoc
+// @Summary Подтвердить домен
+// @Description Проверяет TXT-запись с токеном подтверждения в DNS. Домен получает тот, кто подтвердит его первым;
+// @Description остальные заявки на это имя больше подтвердить нельзя
+// @Tags domains
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "ID домена"
+// @Success 200 {object} models.DomainResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /api/domains/{id}/verify [post]
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	domain, err := h.DomainRepo.FindByID(c.MustGet("userID").(int), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrDomainNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeDomainNotFound, "Домен не найден")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска домена")
+		return
+	}
+
+	if !domain.IsVerified() {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), verifyTimeout)
+		defer cancel()
+
+		records, err := h.Resolver.LookupTXT(ctx, verifyRecordPrefix+domain.Hostname)
+		if err != nil {
+			log.Printf("[INFO] TXT-запись не найдена: %v | Домен: %s", err, domain.Hostname)
+		}
+		if !containsToken(records, domain.VerificationToken) {
+			apierror.Respond(c, http.StatusUnprocessableEntity, apierror.CodeDomainVerificationFailed, "TXT-запись с токеном подтверждения не найдена")
+			return
+		}
+
+		if err := h.DomainRepo.MarkVerified(domain); err != nil {
+			if errors.Is(err, repositories.ErrDomainExists) {
+				apierror.Respond(c, http.StatusConflict, apierror.CodeDomainExists, "Домен уже подтвержден другим пользователем")
+				return
+			}
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка подтверждения домена")
+			return
+		}
+		log.Printf("[INFO] Домен подтвержден: %s", domain.Hostname)
+	}
+
+	c.JSON(http.StatusOK, toDomainResponse(*domain))
+}
+
+func containsToken(records []string, token string) bool {
+	for _, record := range records {
+		if strings.TrimSpace(record) == verifyValuePrefix+token {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteDomain godoc
+// @Summary Удалить домен
+// @Description Домен, на котором есть ссылки, удалить нельзя
+// @Tags domains
+// @Security ApiKeyAuth
+// @Param id path int true "ID домена"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/domains/{id} [delete]
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.DomainRepo.Delete(c.MustGet("userID").(int), id)
+	switch {
+	case errors.Is(err, repositories.ErrDomainNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeDomainNotFound, "Домен не найден")
+	case errors.Is(err, repositories.ErrDomainInUse):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeDomainInUse, "На домене есть ссылки")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления домена")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// resolveHost определяет домен, на который пришел запрос редиректа.
+// Основной домен дает nil. Для незнакомых и неподтвержденных хостов
+// отвечает сам: редиректом на UnknownHostRedirect или 404.
+func (h *LinkHandler) resolveHost(c *gin.Context) (*int, bool) {
+	host := normalizeHost(c.Request.Host)
+	if host == normalizeHost(h.DefaultDomain) || h.DomainRepo == nil {
+		return nil, true
+	}
+
+	domain, err := h.DomainRepo.FindByHostname(host)
+	switch {
+	case err == nil && domain.IsVerified():
+		return &domain.ID, true
+	case errors.Is(err, repositories.ErrDomainNotFound) && h.DefaultDomain == "":
+		// Основной домен не настроен: любой незарегистрированный хост считается основным
+		return nil, true
+	case err != nil && !errors.Is(err, repositories.ErrDomainNotFound):
+		log.Printf("[ERROR] Ошибка поиска домена: %v | Хост: %s", err, host)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска домена")
+		return nil, false
+	}
+
+	log.Printf("[INFO] Запрос на незнакомый хост: %s", host)
+	if h.UnknownHostRedirect != "" {
+		c.Redirect(http.StatusFound, h.UnknownHostRedirect)
+	} else {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeDomainNotFound, "Домен не обслуживается")
+	}
+	return nil, false
+}
+
+// domainFromQuery читает домен ссылки из query-параметра domain в API.
+// Пустой параметр — основной домен; чужой домен неотличим от несуществующего.
+func (h *LinkHandler) domainFromQuery(c *gin.Context) (*int, bool) {
+	hostname := c.Query("domain")
+	if hostname == "" {
+		return nil, true
+	}
+
+	domain, err := h.DomainRepo.FindOwnByHostname(c.MustGet("userID").(int), normalizeHost(hostname))
+	if err != nil {
+		if !errors.Is(err, repositories.ErrDomainNotFound) {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска домена")
+			return nil, false
+		}
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeDomainNotFound, "Домен не найден")
+		return nil, false
+	}
+	return &domain.ID, true
+}
+
+// linkDomain проверяет, что пользователь может создавать ссылки на домене.
+func (h *LinkHandler) linkDomain(userID int, hostname string) (*models.Domain, *linkError) {
+	if hostname == "" {
+		return nil, nil
+	}
+
+	domain, err := h.DomainRepo.FindOwnByHostname(userID, normalizeHost(hostname))
+	if err != nil && !errors.Is(err, repositories.ErrDomainNotFound) {
+		return nil, newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска домена")
+	}
+	if err != nil || !domain.IsVerified() {
+		return nil, newLinkError(http.StatusBadRequest, apierror.CodeDomainNotFound, "Домен не найден или не подтвержден")
+	}
+	return domain, nil
+}
@@ -0,0 +1,363 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/handlers"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var domainColumns = []string{"id", "user_id", "hostname", "verification_token", "verified_at", "created_at"}
+
+// fakeResolver отдает заранее заданные TXT-записи вместо обращения к DNS.
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return records, nil
+}
+
+func TestVerifyDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		resolver     fakeResolver
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			resolver: fakeResolver{
+				"_urlshort-verify.go.brand.com": {"v=spf1 -all", "urlshort-verification=secret"},
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(2, 1, "go.brand.com", "secret", nil, time.Now()))
+				mock.ExpectQuery("UPDATE domains SET verified_at = NOW\\(\\)").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"verified_at"}).AddRow(time.Now()))
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Verified by another user first",
+			resolver: fakeResolver{
+				"_urlshort-verify.go.brand.com": {"urlshort-verification=secret"},
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(2, 1, "go.brand.com", "secret", nil, time.Now()))
+				mock.ExpectQuery("UPDATE domains SET verified_at = NOW\\(\\)").
+					WithArgs(2).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "Wrong token",
+			resolver: fakeResolver{
+				"_urlshort-verify.go.brand.com": {"urlshort-verification=other"},
+			},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(2, 1, "go.brand.com", "secret", nil, time.Now()))
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "No record",
+			resolver: fakeResolver{},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(2, 1, "go.brand.com", "secret", nil, time.Now()))
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Foreign domain",
+			resolver: fakeResolver{},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			handler := &handlers.DomainHandler{
+				DomainRepo: repositories.NewDomainRepository(db),
+				Resolver:   tt.resolver,
+			}
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/domains/2/verify", nil)
+			c.Params = gin.Params{{Key: "id", Value: "2"}}
+			c.Set("userID", 1)
+
+			handler.VerifyDomain(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"hostname": "Go.Brand.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO domains").
+					WithArgs(1, "go.brand.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `"txt_name":"_urlshort-verify.go.brand.com"`,
+		},
+		{
+			name:        "Verified by another user",
+			requestBody: `{"hostname": "go.brand.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO domains (.+) WHERE NOT EXISTS \\(SELECT 1 FROM domains WHERE hostname = \\$2 AND verified_at IS NOT NULL\\)").
+					WithArgs(1, "go.brand.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"code":"domain_exists"`,
+		},
+		{
+			name:         "Invalid hostname",
+			requestBody:  `{"hostname": "not a domain"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Default domain",
+			requestBody:  `{"hostname": "sho.rt"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			handler := &handlers.DomainHandler{
+				DomainRepo:    repositories.NewDomainRepository(db),
+				DefaultDomain: "sho.rt",
+			}
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/domains", strings.NewReader(tt.requestBody))
+			c.Set("userID", 1)
+
+			handler.AddDomain(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedirect_CustomDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		host           string
+		fallback       string
+		mockClosure    func(mock sqlmock.Sqlmock)
+		expectedStatus int
+		expectedTarget string
+	}{
+		{
+			name: "Code is resolved within the domain",
+			host: "go.brand.com:443",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND verified_at IS NOT NULL").
+					WithArgs("go.brand.com").
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(5, 1, "go.brand.com", "secret", time.Now(), time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(5, "sale").
+					WillReturnRows(linkRows(linkRow{
+						ID: 1, UserID: 1, OriginalURL: "https://brand.com/sale", ShortCode: "sale",
+						DomainID: ptr(5), Domain: "go.brand.com",
+					}))
+				mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+				mock.ExpectQuery("UPDATE links SET click_count").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusMovedPermanently,
+			expectedTarget: "https://brand.com/sale",
+		},
+		{
+			name:     "Unverified domain falls back",
+			host:     "go.brand.com",
+			fallback: "https://sho.rt/",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1").
+					WithArgs("go.brand.com").
+					WillReturnRows(sqlmock.NewRows(domainColumns))
+			},
+			expectedStatus: http.StatusFound,
+			expectedTarget: "https://sho.rt/",
+		},
+		{
+			name: "Unknown host without fallback",
+			host: "evil.com",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1").
+					WithArgs("evil.com").
+					WillReturnRows(sqlmock.NewRows(domainColumns))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			handler.UnknownHostRedirect = tt.fallback
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/sale", nil)
+			c.Request.Host = tt.host
+			c.Request.RemoteAddr = "127.0.0.1:1234"
+			c.Params = gin.Params{{Key: "short_code", Value: "sale"}}
+
+			handler.Redirect(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedTarget != "" {
+				assert.Equal(t, tt.expectedTarget, w.Header().Get("Location"))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateShortLink_CustomDomain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Verified domain",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND user_id = \\$2").
+					WithArgs("go.brand.com", 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(5, 1, "go.brand.com", "secret", time.Now(), time.Now()))
+				mock.ExpectQuery("SELECT EXISTS\\(.*").
+					WithArgs(5, "sale").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WithArgs(1, "https://brand.com/sale", "sale", false, nil, nil, "", "", "", "", "", 5, "https://brand.com/sale", "active", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"full_url":"go.brand.com/sale"`,
+		},
+		{
+			name: "Unverified domain",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND user_id = \\$2").
+					WithArgs("go.brand.com", 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns).
+						AddRow(5, 1, "go.brand.com", "secret", nil, time.Now()))
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error"`,
+		},
+		{
+			name: "Foreign domain",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND user_id = \\$2").
+					WithArgs("go.brand.com", 1).
+					WillReturnRows(sqlmock.NewRows(domainColumns))
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body := `{"original_url": "https://brand.com/sale", "custom_code": "sale", "domain": "go.brand.com"}`
+			c.Request = httptest.NewRequest("POST", "/api/links", strings.NewReader(body))
+			c.Set("userID", 1)
+
+			handler.CreateShortLink(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/export"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultExportDays — за сколько последних дней выгружаются клики, если диапазон не задан.
+const defaultExportDays = 30
+
+type ExportHandler struct {
+	// Links ищет ссылку для выгрузки кликов одной ссылки
+	Links        *LinkHandler
+	AnalyticRepo *repositories.AnalyticRepository
+	ExportRepo   *repositories.ExportRepository
+
+	// Runner выполняет фоновые выгрузки; nil — выгрузки ждут следующей проверки очереди
+	Runner *export.Runner
+	// SyncLimit — выгрузки больше этого числа кликов ставятся в очередь
+	SyncLimit int64
+}
+
+// exportRequest — параметры выгрузки из query-строки.
+type exportRequest struct {
+	format   string
+	compress bool
+	async    bool
+	from, to time.Time
+}
+
+// parseExportRequest разбирает format, gzip, async, from и to. Даты задаются как
+// YYYY-MM-DD, to включается в диапазон целиком.
+func parseExportRequest(c *gin.Context) (exportRequest, bool) {
+	req := exportRequest{format: c.DefaultQuery("format", models.ExportFormatCSV)}
+	switch req.format {
+	case models.ExportFormatCSV, models.ExportFormatNDJSON, models.ExportFormatParquet:
+	default:
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Неизвестный формат: ожидается csv, ndjson или parquet")
+		return req, false
+	}
+
+	var err error
+	if req.compress, err = strconv.ParseBool(c.DefaultQuery("gzip", "false")); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Параметр gzip должен быть true или false")
+		return req, false
+	}
+	if req.compress && req.format == models.ExportFormatParquet {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Файл parquet уже сжат, параметр gzip для него не поддерживается")
+		return req, false
+	}
+	if req.async, err = strconv.ParseBool(c.DefaultQuery("async", "false")); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Параметр async должен быть true или false")
+		return req, false
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	req.to = today.AddDate(0, 0, 1)
+	if value := c.Query("to"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Дата to должна быть в формате ГГГГ-ММ-ДД")
+			return req, false
+		}
+		req.to = day.AddDate(0, 0, 1)
+	}
+	req.from = req.to.AddDate(0, 0, -defaultExportDays)
+	if value := c.Query("from"); value != "" {
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Дата from должна быть в формате ГГГГ-ММ-ДД")
+			return req, false
+		}
+		req.from = day
+	}
+	if !req.from.Before(req.to) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Дата from должна быть не позже to")
+		return req, false
+	}
+	return req, true
+}
+
+// ExportLinkClicks godoc
+// @Summary Выгрузить клики ссылки
+// @Description Клики за диапазон дат в CSV, NDJSON или Parquet (группы по 10 000 кликов, колонки сжаты zstd,
+// @Description gzip не поддерживается). Небольшие выгрузки отдаются сразу файлом;
+// @Description если кликов больше лимита или передан async=true, выгрузка ставится в очередь и возвращается 202
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.apache.parquet
+// @Produce application/gzip
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param format query string false "csv, ndjson или parquet" default(csv)
+// @Param gzip query bool false "Сжать файл gzip (кроме parquet)"
+// @Param from query string false "Первый день, ГГГГ-ММ-ДД; по умолчанию 30 дней назад"
+// @Param to query string false "Последний день включительно, ГГГГ-ММ-ДД; по умолчанию сегодня (UTC)"
+// @Param async query bool false "Всегда выполнять в фоне"
+// @Success 200 {file} file
+// @Success 202 {object} models.ExportJob
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/clicks/export [get]
+func (h *ExportHandler) ExportLinkClicks(c *gin.Context) {
+	req, ok := parseExportRequest(c)
+	if !ok {
+		return
+	}
+	link, ok := h.Links.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	filter := models.ClickExportFilter{UserID: link.UserID, LinkID: &link.ID, From: req.from, To: req.to}
+	h.exportClicks(c, req, filter, "clicks-"+link.ShortCode)
+}
+
+// ExportClicks godoc
+// @Summary Выгрузить клики всех ссылок
+// @Description То же, что выгрузка кликов ссылки, но по всем ссылкам пользователя
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.apache.parquet
+// @Produce application/gzip
+// @Param format query string false "csv, ndjson или parquet" default(csv)
+// @Param gzip query bool false "Сжать файл gzip (кроме parquet)"
+// @Param from query string false "Первый день, ГГГГ-ММ-ДД; по умолчанию 30 дней назад"
+// @Param to query string false "Последний день включительно, ГГГГ-ММ-ДД; по умолчанию сегодня (UTC)"
+// @Param async query bool false "Всегда выполнять в фоне"
+// @Success 200 {file} file
+// @Success 202 {object} models.ExportJob
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/clicks/export [get]
+func (h *ExportHandler) ExportClicks(c *gin.Context) {
+	req, ok := parseExportRequest(c)
+	if !ok {
+		return
+	}
+
+	filter := models.ClickExportFilter{UserID: c.MustGet("userID").(int), From: req.from, To: req.to}
+	h.exportClicks(c, req, filter, "clicks")
+}
+
+func (h *ExportHandler) exportClicks(c *gin.Context, req exportRequest, filter models.ClickExportFilter, name string) {
+	if !req.async {
+		count, err := h.AnalyticRepo.CountExportClicks(filter)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка выгрузки кликов")
+			return
+		}
+		req.async = count > h.SyncLimit
+	}
+	if req.async {
+		h.enqueueExport(c, req, filter)
+		return
+	}
+
+	name = fmt.Sprintf("%s-%s-%s", name, req.from.Format(time.DateOnly), req.to.AddDate(0, 0, -1).Format(time.DateOnly))
+	c.Header("Content-Type", export.ContentType(req.format, req.compress))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.FileName(name, req.format, req.compress)))
+	c.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому об ошибке посреди выгрузки остается только записать в лог
+	rows, err := export.WriteClicks(h.AnalyticRepo, filter, req.format, req.compress, c.Writer)
+	if err != nil {
+		log.Printf("[ERROR] Ошибка выгрузки кликов: %v | Пользователь: %d", err, filter.UserID)
+		return
+	}
+	log.Printf("[INFO] Выгружено кликов: %d | Формат: %s | Пользователь: %d", rows, req.format, filter.UserID)
+}
+
+func (h *ExportHandler) enqueueExport(c *gin.Context, req exportRequest, filter models.ClickExportFilter) {
+	job := &models.ExportJob{
+		UserID: filter.UserID,
+		LinkID: filter.LinkID,
+		Format: req.format,
+		Gzip:   req.compress,
+		From:   filter.From,
+		To:     filter.To,
+	}
+	if err := h.ExportRepo.Create(job); err != nil {
+		log.Printf("[ERROR] Ошибка создания выгрузки: %v", err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка создания выгрузки")
+		return
+	}
+	if h.Runner != nil {
+		h.Runner.Wake()
+	}
+
+	log.Printf("[INFO] Выгрузка %d поставлена в очередь | Пользователь: %d", job.ID, job.UserID)
+	c.Header("Location", fmt.Sprintf("/api/exports/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func withDownloadURL(job *models.ExportJob) {
+	if job.Status == models.ExportCompleted {
+		job.DownloadURL = fmt.Sprintf("/api/exports/%d/download", job.ID)
+	}
+}
+
+// ListExports godoc
+// @Summary Фоновые выгрузки кликов
+// @Description Выгрузки пользователя, новые первыми. Готовые файлы доступны до expires_at
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.ExportJob
+// @Router /api/exports [get]
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	jobs, err := h.ExportRepo.FindByUserID(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения выгрузок")
+		return
+	}
+	for i := range jobs {
+		withDownloadURL(&jobs[i])
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *ExportHandler) findOwnExport(c *gin.Context) (*models.ExportJob, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	job, err := h.ExportRepo.FindByID(c.MustGet("userID").(int), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrExportNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeExportNotFound, "Выгрузка не найдена")
+			return nil, false
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения выгрузки")
+		return nil, false
+	}
+	withDownloadURL(job)
+	return job, true
+}
+
+// GetExport godoc
+// @Summary Состояние фоновой выгрузки
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "ID выгрузки"
+// @Success 200 {object} models.ExportJob
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/exports/{id} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	job, ok := h.findOwnExport(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport godoc
+// @Summary Скачать файл выгрузки
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce application/octet-stream
+// @Param id path int true "ID выгрузки"
+// @Success 200 {file} file
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 410 {object} models.ErrorResponse
+// @Router /api/exports/{id}/download [get]
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	job, ok := h.findOwnExport(c)
+	if !ok {
+		return
+	}
+	if job.Status != models.ExportCompleted {
+		apierror.Respond(c, http.StatusConflict, apierror.CodeExportNotReady, "Выгрузка еще не готова")
+		return
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		apierror.Respond(c, http.StatusGone, apierror.CodeExportExpired, "Срок хранения выгрузки истек")
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(job.Format, job.Gzip))
+	c.FileAttachment(job.FilePath, filepath.Base(job.FilePath))
+}
@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/handlers"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var exportJobColumns = []string{
+	"id", "user_id", "link_id", "format", "gzip", "range_from", "range_to", "status", "rows_count", "size_bytes",
+	"file_path", "error", "created_at", "started_at", "completed_at", "expires_at",
+}
+
+func TestExportClicks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		query        string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+		expectedType string
+	}{
+		{
+			name:  "Small export is streamed",
+			query: "?from=2026-03-01&to=2026-03-31",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM click_analytics ca").
+					WithArgs(1, from, to).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery("FROM click_analytics ca").
+					WithArgs(1, from, to).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "link_code", "clicked_code", "domain", "destination", "link_version", "clicked_at",
+						"source", "location", "device_type", "os", "browser", "referrer", "user_agent",
+					}).AddRow(7, "abc", "abc", "", "https://example.com", 1, from, "link", "RU", "desktop", "Linux", "Firefox", "", "curl"))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: "7,abc,abc,,https://example.com,1,2026-03-01T00:00:00Z,link,RU,desktop,Linux,Firefox,,curl",
+			expectedType: "text/csv; charset=utf-8",
+		},
+		{
+			name:  "Large export becomes a job",
+			query: "?from=2026-03-01&to=2026-03-31&format=ndjson&gzip=true",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM click_analytics ca").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1000))
+				mock.ExpectQuery("INSERT INTO export_jobs").
+					WithArgs(1, nil, "ndjson", true, from, to, "pending").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(12, time.Now()))
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: `"status":"pending"`,
+		},
+		{
+			name:         "Unknown format",
+			query:        "?format=xlsx",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Parquet is not gzipped",
+			query:        "?format=parquet&gzip=true",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "parquet",
+		},
+		{
+			name:         "Reversed range",
+			query:        "?from=2026-03-31&to=2026-03-01",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			handler := &handlers.ExportHandler{
+				AnalyticRepo: repositories.NewAnalyticRepository(db),
+				ExportRepo:   repositories.NewExportRepository(db),
+				SyncLimit:    100,
+			}
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/clicks/export"+tt.query, nil)
+			c.Set("userID", 1)
+
+			handler.ExportClicks(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "clicks-2026-03-01-2026-03-31.csv")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDownloadExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	path := dir + "/clicks-12.csv"
+	assert.NoError(t, os.WriteFile(path, []byte("id\n1\n"), 0o600))
+
+	tests := []struct {
+		name         string
+		status       string
+		path         string
+		expectedCode int
+	}{
+		{name: "Completed", status: "completed", path: path, expectedCode: http.StatusOK},
+		{name: "Still running", status: "running", expectedCode: http.StatusConflict},
+		{name: "File removed", status: "completed", path: dir + "/missing.csv", expectedCode: http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			handler := &handlers.ExportHandler{ExportRepo: repositories.NewExportRepository(db)}
+
+			now := time.Now()
+			mock.ExpectQuery("SELECT (.+) FROM export_jobs WHERE id = \\$1 AND user_id = \\$2").
+				WithArgs(12, 1).
+				WillReturnRows(sqlmock.NewRows(exportJobColumns).
+					AddRow(12, 1, nil, "csv", false, now, now, tt.status, 1, 6, tt.path, "", now, now, nil, nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/exports/12/download", nil)
+			c.Params = gin.Params{{Key: "id", Value: "12"}}
+			c.Set("userID", 1)
+
+			handler.DownloadExport(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.True(t, strings.HasPrefix(w.Body.String(), "id\n"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "clicks-12.csv")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
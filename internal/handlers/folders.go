@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FolderHandler struct {
+	FolderRepo *repositories.FolderRepository
+}
+
+// ListFolders godoc
+// @Summary Список папок
+// @Tags folders
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Folder
+// @Router /api/folders [get]
+func (h *FolderHandler) ListFolders(c *gin.Context) {
+	folders, err := h.FolderRepo.FindByUserID(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения папок")
+		return
+	}
+	c.JSON(http.StatusOK, folders)
+}
+
+// CreateFolder godoc
+// @Summary Создать папку
+// @Tags folders
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param input body models.FolderRequest true "Имя папки"
+// @Success 201 {object} models.Folder
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/folders [post]
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	var req models.FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apierror.RespondDetails(c, http.StatusBadRequest, apierror.CodeValidationFailed, "Неверные данные",
+			[]models.FieldError{apierror.Field("name", "required", "Обязательное поле")})
+		return
+	}
+
+	folder := &models.Folder{Name: strings.TrimSpace(req.Name)}
+	if err := h.FolderRepo.Create(c.MustGet("userID").(int), folder); err != nil {
+		if errors.Is(err, repositories.ErrFolderExists) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeFolderExists, "Папка с таким именем уже существует")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка создания папки")
+		return
+	}
+	c.JSON(http.StatusCreated, folder)
+}
+
+// RenameFolder godoc
+// @Summary Переименовать папку
+// @Tags folders
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param id path int true "ID папки"
+// @Param input body models.FolderRequest true "Новое имя"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/folders/{id} [patch]
+func (h *FolderHandler) RenameFolder(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apierror.RespondDetails(c, http.StatusBadRequest, apierror.CodeValidationFailed, "Неверные данные",
+			[]models.FieldError{apierror.Field("name", "required", "Обязательное поле")})
+		return
+	}
+
+	err := h.FolderRepo.Rename(c.MustGet("userID").(int), id, strings.TrimSpace(req.Name))
+	switch {
+	case errors.Is(err, repositories.ErrFolderNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeFolderNotFound, "Папка не найдена")
+	case errors.Is(err, repositories.ErrFolderExists):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeFolderExists, "Папка с таким именем уже существует")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка переименования папки")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// DeleteFolder godoc
+// @Summary Удалить папку
+// @Description Ссылки из папки остаются без папки и не удаляются
+// @Tags folders
+// @Security ApiKeyAuth
+// @Param id path int true "ID папки"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/folders/{id} [delete]
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.FolderRepo.Delete(c.MustGet("userID").(int), id)
+	switch {
+	case errors.Is(err, repositories.ErrFolderNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeFolderNotFound, "Папка не найдена")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления папки")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// linkColumns — колонки links в том порядке, в котором их читает LinkRepository.
+var linkColumns = []string{
+	"id", "user_id", "original_url", "short_code", "click_count", "created_at",
+	"track_conversions", "expires_at", "folder_id",
+	"title", "description", "notes", "image_url", "site_name",
+	"domain_id", "domain",
+	"status", "starts_at", "analytics_mode",
+}
+
+// linkRow — строка таблицы links для моков; незаданные поля получают значения по умолчанию.
+type linkRow struct {
+	ID               int
+	UserID           int
+	OriginalURL      string
+	ShortCode        string
+	ClickCount       int
+	CreatedAt        time.Time
+	TrackConversions bool
+	ExpiresAt        *time.Time
+	FolderID         *int
+	Title            string
+	Description      string
+	ImageURL         string
+	SiteName         string
+	DomainID         *int
+	Domain           string
+	Status           string // пусто — active
+	StartsAt         *time.Time
+	AnalyticsMode    string
+
+	// Tags в формате массива PostgreSQL, например "{sale,summer}"; используется в linkRowsWithTags
+	Tags string
+}
+
+func (r linkRow) values() []driver.Value {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	if r.Status == "" {
+		r.Status = "active"
+	}
+	var expiresAt, folderID, domainID, startsAt driver.Value
+	if r.ExpiresAt != nil {
+		expiresAt = *r.ExpiresAt
+	}
+	if r.FolderID != nil {
+		folderID = int64(*r.FolderID)
+	}
+	if r.DomainID != nil {
+		domainID = int64(*r.DomainID)
+	}
+	if r.StartsAt != nil {
+		startsAt = *r.StartsAt
+	}
+	return []driver.Value{
+		r.ID, r.UserID, r.OriginalURL, r.ShortCode, r.ClickCount, r.CreatedAt,
+		r.TrackConversions, expiresAt, folderID,
+		r.Title, r.Description, "", r.ImageURL, r.SiteName,
+		domainID, r.Domain,
+		r.Status, startsAt, r.AnalyticsMode,
+	}
+}
+
+// linkRows — результат выборки ссылок по коду.
+func linkRows(rows ...linkRow) *sqlmock.Rows {
+	result := sqlmock.NewRows(linkColumns)
+	for _, r := range rows {
+		result.AddRow(r.values()...)
+	}
+	return result
+}
+
+// linkRowsWithTags — результат выборки списка ссылок, где последней колонкой идут теги.
+func linkRowsWithTags(rows ...linkRow) *sqlmock.Rows {
+	result := sqlmock.NewRows(append(append([]string{}, linkColumns...), "tags"))
+	for _, r := range rows {
+		tags := r.Tags
+		if tags == "" {
+			tags = "{}"
+		}
+		result.AddRow(append(r.values(), tags)...)
+	}
+	return result
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
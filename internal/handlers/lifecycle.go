@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveUnavailable отвечает вместо редиректа, если ссылка сейчас не работает,
+// и сообщает, что ответ уже отправлен. Адрес назначения в ответ не попадает.
+func (h *LinkHandler) serveUnavailable(c *gin.Context, link *models.Link) bool {
+	switch link.State(time.Now()) {
+	case models.LinkStatusActive:
+		return false
+	case models.LinkStatusExpired:
+		log.Printf("[INFO] Ссылка истекла: %s", link.ShortCode)
+		apierror.Respond(c, http.StatusGone, apierror.CodeLinkExpired, "Срок действия ссылки истек")
+	case models.LinkStatusArchived:
+		log.Printf("[INFO] Ссылка в архиве: %s", link.ShortCode)
+		apierror.Respond(c, http.StatusGone, apierror.CodeLinkArchived, "Ссылка больше не действует")
+	case models.LinkStatusPaused:
+		log.Printf("[INFO] Ссылка приостановлена: %s", link.ShortCode)
+		h.unavailablePage(c, link, h.PausedPageURL, http.StatusServiceUnavailable, "link_paused.html")
+	default:
+		// Черновик и запланированная ссылка выглядят одинаково, чтобы не выдавать дату запуска
+		log.Printf("[INFO] Ссылка еще не активна: %s", link.ShortCode)
+		h.unavailablePage(c, link, h.InactivePageURL, http.StatusNotFound, "link_inactive.html")
+	}
+	return true
+}
+
+// unavailablePage отправляет на настроенную страницу или показывает встроенный шаблон.
+// Ответ не кешируется: после активации ссылка должна заработать сразу.
+func (h *LinkHandler) unavailablePage(c *gin.Context, link *models.Link, pageURL string, status int, template string) {
+	c.Header("Cache-Control", "no-store")
+	if pageURL != "" {
+		c.Redirect(http.StatusFound, pageURL)
+		return
+	}
+	c.HTML(status, template, gin.H{"ShortURL": fullURL(c, link), "Lang": i18n.FromContext(c)})
+}
+
+// ChangeLinkStatus godoc
+// @Summary Изменить состояние ссылки
+// @Description Переводит ссылку в draft, scheduled (с будущим starts_at), active, paused или archived. Переход записывается в журнал
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.LinkStatusRequest true "Новое состояние"
+// @Success 200 {object} models.LinkStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/status [post]
+func (h *LinkHandler) ChangeLinkStatus(c *gin.Context) {
+	var req models.LinkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Состояние должно быть draft, scheduled, active, paused или archived")
+		return
+	}
+
+	now := time.Now()
+	if req.Status == models.LinkStatusScheduled {
+		if req.StartsAt == nil || !req.StartsAt.After(now) {
+			invalidField("starts_at", "future", "Для запланированной ссылки нужна дата активации в будущем").respond(c)
+			return
+		}
+	} else if req.StartsAt != nil {
+		invalidField("starts_at", "not_allowed", "Дата активации задается только для состояния scheduled").respond(c)
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if req.Status == models.LinkStatusScheduled && link.ExpiresAt != nil && !link.ExpiresAt.After(*req.StartsAt) {
+		invalidField("starts_at", "before", "Дата истечения должна быть позже даты активации").respond(c)
+		return
+	}
+
+	from := link.State(now)
+	if !models.CanTransition(from, req.Status) {
+		apierror.Respondf(c, http.StatusConflict, apierror.CodeInvalidTransition, "Недопустимый переход: %s → %s", from, req.Status)
+		return
+	}
+
+	userID := c.MustGet("userID").(int)
+	event := &models.LinkStatusEvent{
+		UserID:     &userID,
+		FromStatus: from,
+		ToStatus:   req.Status,
+		StartsAt:   req.StartsAt,
+	}
+	if err := h.LinkRepo.ChangeStatus(link, event); err != nil {
+		if errors.Is(err, repositories.ErrStatusConflict) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeStatusConflict, "Состояние ссылки изменилось, повторите запрос")
+			return
+		}
+		log.Printf("[ERROR] Ошибка смены состояния: %v | Код: %s", err, link.ShortCode)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка смены состояния ссылки")
+		return
+	}
+
+	log.Printf("[INFO] Состояние ссылки изменено: %s | %s → %s | Пользователь: %d", link.ShortCode, from, req.Status, userID)
+	h.emit(link.UserID, models.EventLinkUpdated, toLinkSummary(c, *link))
+	c.JSON(http.StatusOK, models.LinkStatusResponse{Status: link.State(now), StartsAt: link.StartsAt})
+}
+
+// ListStatusEvents godoc
+// @Summary Журнал состояний ссылки
+// @Description Кто и когда менял состояние ссылки, старые записи первыми
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {array} models.LinkStatusEvent
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/status/history [get]
+func (h *LinkHandler) ListStatusEvents(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.LinkRepo.FindStatusEvents(link.ID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения журнала")
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
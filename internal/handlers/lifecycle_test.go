@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/i18n"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirect_Lifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		row          linkRow
+		pausedPage   string
+		locale       string
+		expectedCode int
+		expectedBody string
+		location     string
+	}{
+		{
+			name:         "Scheduled before start",
+			row:          linkRow{Status: "scheduled", StartsAt: &future},
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Ссылка еще не активна",
+		},
+		{
+			name:         "Draft",
+			row:          linkRow{Status: "draft"},
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Ссылка еще не активна",
+		},
+		{
+			name:         "Paused with configured page",
+			row:          linkRow{Status: "paused"},
+			pausedPage:   "https://status.example.com/paused",
+			expectedCode: http.StatusFound,
+			location:     "https://status.example.com/paused",
+		},
+		{
+			name:         "Paused",
+			row:          linkRow{Status: "paused"},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: "Ссылка приостановлена",
+		},
+		{
+			name:         "Paused in English",
+			row:          linkRow{Status: "paused"},
+			locale:       i18n.English,
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: "This link is paused",
+		},
+		{
+			name:         "Archived",
+			row:          linkRow{Status: "archived"},
+			expectedCode: http.StatusGone,
+			expectedBody: `"error":"Ссылка больше не действует"`,
+		},
+		{
+			name:         "Archived in English",
+			row:          linkRow{Status: "archived"},
+			locale:       i18n.English,
+			expectedCode: http.StatusGone,
+			expectedBody: `"error":"This link is no longer active","code":"link_archived"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			handler.PausedPageURL = tt.pausedPage
+
+			tt.row.ID, tt.row.UserID, tt.row.ShortCode = 1, 1, "launch"
+			tt.row.OriginalURL = "https://example.com/secret-launch"
+			mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+				WithArgs(nil, "launch").
+				WillReturnRows(linkRows(tt.row))
+
+			w := httptest.NewRecorder()
+			c, engine := gin.CreateTestContext(w)
+			engine.SetFuncMap(i18n.FuncMap())
+			engine.LoadHTMLGlob("../../web/templates/link_*.html")
+			c.Request = httptest.NewRequest("GET", "/launch", nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "launch"}}
+			if tt.locale != "" {
+				c.Set(i18n.ContextKey, tt.locale)
+			}
+
+			handler.Redirect(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+			assert.NotContains(t, w.Body.String(), "secret-launch")
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("Scheduled after start", func(t *testing.T) {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+			WillReturnRows(linkRows(linkRow{
+				ID: 1, UserID: 1, OriginalURL: "https://example.com/launch", ShortCode: "launch",
+				Status: "scheduled", StartsAt: &past,
+			}))
+		mock.ExpectQuery("SELECT (.+) FROM link_destinations").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("UPDATE links SET click_count").
+			WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO click_analytics").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/launch", nil)
+		c.Params = gin.Params{{Key: "short_code", Value: "launch"}}
+
+		handler.Redirect(c)
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://example.com/launch", w.Header().Get("Location"))
+	})
+}
+
+func TestChangeLinkStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	startsAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Schedule draft",
+			requestBody: `{"status": "scheduled", "starts_at": "` + startsAt.Format(time.RFC3339) + `"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "launch").
+					WillReturnRows(linkRows(linkRow{ID: 7, UserID: 1, OriginalURL: "https://example.com", ShortCode: "launch", Status: "draft"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE links SET status = \\$1, starts_at = \\$2 WHERE id = \\$3 AND status = \\$4").
+					WithArgs("scheduled", startsAt, 7, "draft").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO link_status_events").
+					WithArgs(7, 1, "draft", "scheduled", startsAt).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"status":"scheduled"`,
+		},
+		{
+			name:        "Invalid transition",
+			requestBody: `{"status": "draft"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 7, UserID: 1, OriginalURL: "https://example.com", ShortCode: "launch"}))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"error":"Недопустимый переход: active → draft"`,
+		},
+		{
+			name:        "Concurrent change",
+			requestBody: `{"status": "paused"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 7, UserID: 1, OriginalURL: "https://example.com", ShortCode: "launch"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE links SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"error":"Состояние ссылки изменилось, повторите запрос"`,
+		},
+		{
+			name:         "Scheduled without start",
+			requestBody:  `{"status": "scheduled"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Для запланированной ссылки нужна дата активации в будущем"`,
+		},
+		{
+			name:         "Unknown status",
+			requestBody:  `{"status": "expired"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/links/launch/status", strings.NewReader(tt.requestBody))
+			c.Params = gin.Params{{Key: "short_code", Value: "launch"}}
+			c.Set("userID", 1)
+
+			handler.ChangeLinkStatus(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLinkFilter читает фильтр ссылок из query-параметров tag и folder_id.
+func parseLinkFilter(c *gin.Context) (models.LinkFilter, bool) {
+	filter := models.LinkFilter{Tag: c.Query("tag")}
+	if raw := c.Query("folder_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Некорректный идентификатор папки")
+			return filter, false
+		}
+		filter.FolderID = &id
+	}
+	return filter, true
+}
+
+func toLinkSummary(c *gin.Context, link models.Link) models.LinkSummary {
+	return linkSummary(link, c.Request.Host)
+}
+
+func linkSummary(link models.Link, defaultHost string) models.LinkSummary {
+	tags := link.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return models.LinkSummary{
+		ShortCode:   link.ShortCode,
+		Domain:      link.Domain,
+		FullURL:     linkURL(&link, defaultHost),
+		OriginalURL: link.OriginalURL,
+		ClickCount:  link.ClickCount,
+		Tags:        tags,
+		FolderID:    link.FolderID,
+		ExpiresAt:   link.ExpiresAt,
+		CreatedAt:   link.CreatedAt,
+		Title:       link.Title,
+		Description: link.Description,
+		Notes:       link.Notes,
+		ImageURL:    link.ImageURL,
+		SiteName:    link.SiteName,
+		Status:      link.State(time.Now()),
+		StartsAt:    link.StartsAt,
+	}
+}
+
+// findOwnLink ищет ссылку по коду из пути (и домену из query-параметра domain)
+// и проверяет, что она принадлежит пользователю. Чужие ссылки неотличимы от несуществующих.
+func (h *LinkHandler) findOwnLink(c *gin.Context) (*models.Link, bool) {
+	domainID, ok := h.domainFromQuery(c)
+	if !ok {
+		return nil, false
+	}
+
+	link, err := h.LinkRepo.FindByShortCode(domainID, c.Param("short_code"))
+	if err != nil || link.UserID != c.MustGet("userID").(int) {
+		if err != nil && !errors.Is(err, repositories.ErrLinkNotFound) {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска ссылки")
+			return nil, false
+		}
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+		return nil, false
+	}
+	return link, true
+}
+
+// ListLinks godoc
+// @Summary Список ссылок пользователя
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param tag query string false "Только ссылки с этим тегом"
+// @Param folder_id query int false "Только ссылки из этой папки"
+// @Success 200 {array} models.LinkSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/links [get]
+func (h *LinkHandler) ListLinks(c *gin.Context) {
+	filter, ok := parseLinkFilter(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.LinkRepo.FindByUserID(c.MustGet("userID").(int), filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения ссылок")
+		return
+	}
+
+	result := make([]models.LinkSummary, 0, len(links))
+	for _, link := range links {
+		result = append(result, toLinkSummary(c, link))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// SetLinkTags godoc
+// @Summary Заменить теги ссылки
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.LinkTagsRequest true "Новый набор тегов"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/tags [put]
+func (h *LinkHandler) SetLinkTags(c *gin.Context) {
+	var req models.LinkTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if err := h.TagRepo.ReplaceForLink(link.UserID, link.ID, normalizeTags(req.Tags)); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения тегов")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetLinkFolder godoc
+// @Summary Переместить ссылку в папку
+// @Description folder_id: null убирает ссылку из папки
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.LinkFolderRequest true "Папка"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/folder [put]
+func (h *LinkHandler) SetLinkFolder(c *gin.Context) {
+	var req models.LinkFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if linkErr := h.checkFolder(link.UserID, req.FolderID); linkErr != nil {
+		linkErr.respond(c)
+		return
+	}
+
+	if err := h.LinkRepo.SetFolder(link.ID, req.FolderID); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка перемещения ссылки")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// checkFolder проверяет, что папка (если задана) принадлежит пользователю.
+func (h *LinkHandler) checkFolder(userID int, folderID *int) *linkError {
+	if folderID == nil {
+		return nil
+	}
+	exists, err := h.FolderRepo.Exists(userID, *folderID)
+	if err != nil {
+		return newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка проверки папки")
+	}
+	if !exists {
+		return newLinkError(http.StatusBadRequest, apierror.CodeFolderNotFound, "Папка не найдена")
+	}
+	return nil
+}
+
+// GetAggregatedStats godoc
+// @Summary Суммарная статистика по ссылкам
+// @Description Статистика по всем ссылкам пользователя либо по тегу или папке
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce json
+// @Param tag query string false "Только ссылки с этим тегом"
+// @Param folder_id query int false "Только ссылки из этой папки"
+// @Success 200 {object} models.AggregatedAnalytics
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/analytics [get]
+func (h *LinkHandler) GetAggregatedStats(c *gin.Context) {
+	filter, ok := parseLinkFilter(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.AnalyticRepo.GetAggregated(c.MustGet("userID").(int), filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListLinks_Filter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("FROM links WHERE links.user_id = \\$1 AND links.deleted_at IS NULL AND links.folder_id = \\$2 AND EXISTS").
+		WithArgs(1, 4, "sale").
+		WillReturnRows(linkRowsWithTags(linkRow{
+			ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one",
+			ClickCount: 5, FolderID: ptr(4), Tags: "{sale}",
+		}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/links?tag=sale&folder_id=4", nil)
+	c.Set("userID", 1)
+
+	handler.ListLinks(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"short_code":"one"`)
+	assert.Contains(t, w.Body.String(), `"folder_id":4`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetLinkFolder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"folder_id": 4}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "one").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM folders").
+					WithArgs(4, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("UPDATE links SET folder_id = \\$1 WHERE id = \\$2").
+					WithArgs(4, 10).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "Foreign folder",
+			requestBody: `{"folder_id": 5}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM folders").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Foreign link",
+			requestBody: `{"folder_id": null}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 2, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PUT", "/api/links/one/folder", strings.NewReader(tt.requestBody))
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+			c.Set("userID", 1)
+
+			handler.SetLinkFolder(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetAggregatedStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT links.short_code, links.click_count FROM links WHERE links.user_id = \\$1 AND links.deleted_at IS NULL AND EXISTS").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"short_code", "click_count"}).
+			AddRow("one", 60).
+			AddRow("two", 40))
+	mock.ExpectQuery("FROM click_rollups_daily r (.+) r.dimension = 'device'").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"device_type", "count"}).AddRow("mobile", 60).AddRow("desktop", 40))
+	mock.ExpectQuery("r.dimension = 'browser'").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"browser", "count"}).AddRow("Chrome", 100))
+	mock.ExpectQuery("r.dimension = 'location'").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"location", "count"}).AddRow("Moscow, Russia", 100))
+	mock.ExpectQuery("r.dimension = 'tracking'").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"tracking", "count"}).AddRow("full", 90).AddRow("anonymous", 10))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/analytics?tag=sale", nil)
+	c.Set("userID", 1)
+
+	handler.GetAggregatedStats(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"link_count":2`)
+	assert.Contains(t, w.Body.String(), `"total_clicks":100`)
+	assert.Contains(t, w.Body.String(), `"devices":{"desktop":40,"mobile":60}`)
+	assert.Contains(t, w.Body.String(), `"tracking":{"anonymous":10,"full":90}`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
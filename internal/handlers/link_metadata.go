@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// previewSuffix — суффикс короткого кода, открывающий страницу предпросмотра вместо редиректа.
+const previewSuffix = "+"
+
+// fetchMetadata загружает страницу назначения и дополняет метаданные ссылки.
+// Заданные пользователем title и description не перезаписываются, если overwrite == false.
+// Ошибки загрузки не мешают сохранению ссылки и только логируются.
+func (h *LinkHandler) fetchMetadata(ctx context.Context, link *models.Link, overwrite bool) {
+	if h.MetadataFetcher == nil {
+		return
+	}
+
+	meta, err := h.MetadataFetcher.Fetch(ctx, link.OriginalURL)
+	if err != nil {
+		log.Printf("[WARN] Не удалось получить метаданные: %v | URL: %s", err, link.OriginalURL)
+		return
+	}
+
+	if overwrite || link.Title == "" {
+		link.Title = meta.Title
+	}
+	if overwrite || link.Description == "" {
+		link.Description = meta.Description
+	}
+	link.ImageURL = meta.ImageURL
+	link.SiteName = meta.SiteName
+}
+
+// GetLink godoc
+// @Summary Получить ссылку
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {object} models.LinkSummary
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code} [get]
+func (h *LinkHandler) GetLink(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, toLinkSummary(c, *link))
+}
+
+// UpdateLink godoc
+// @Summary Изменить ссылку
+// @Description Меняет заголовок, описание и заметки; refresh_metadata заново загружает Open Graph.
+// @Description Изменение адреса назначения, вариантов, срока действия или трекинга конверсий сохраняется в истории версий
+// @Tags links
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param input body models.UpdateLinkRequest true "Изменяемые поля"
+// @Success 200 {object} models.LinkSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code} [patch]
+func (h *LinkHandler) UpdateLink(c *gin.Context) {
+	var req models.UpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if settingsRequested(&req) {
+		current, err := h.linkSettings(link)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения ссылки")
+			return
+		}
+		next := applySettings(current, &req)
+		if _, linkErr := h.saveSettings(link, current, next, c.MustGet("userID").(int)); linkErr != nil {
+			linkErr.respond(c)
+			return
+		}
+	}
+
+	if req.RefreshMetadata {
+		h.fetchMetadata(c.Request.Context(), link, true)
+	}
+	if req.Title != nil {
+		link.Title = *req.Title
+	}
+	if req.Description != nil {
+		link.Description = *req.Description
+	}
+	if req.Notes != nil {
+		link.Notes = *req.Notes
+	}
+
+	if err := h.LinkRepo.UpdateMetadata(link); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения ссылки")
+		return
+	}
+	summary := toLinkSummary(c, *link)
+	h.emit(link.UserID, models.EventLinkUpdated, summary)
+	c.JSON(http.StatusOK, summary)
+}
+
+// showPreview отображает страницу, где видно, куда ведет ссылка, до перехода по ней.
+// Просмотр не засчитывается как клик.
+func (h *LinkHandler) showPreview(c *gin.Context, domainID *int, shortCode string) {
+	link, _, err := h.findPublicLink(domainID, shortCode)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+		return
+	}
+	// Истекшая ссылка показывается с пометкой, остальные неактивные — как при переходе
+	if state := link.State(time.Now()); state != models.LinkStatusActive && state != models.LinkStatusExpired {
+		h.serveUnavailable(c, link)
+		return
+	}
+
+	c.HTML(http.StatusOK, "preview.html", gin.H{
+		"ShortURL":    fullURL(c, link),
+		"Path":        "/" + link.ShortCode,
+		"Destination": link.OriginalURL,
+		"Title":       link.Title,
+		"Description": link.Description,
+		"ImageURL":    link.ImageURL,
+		"SiteName":    link.SiteName,
+		"Expired":     link.IsExpired(time.Now()),
+		"Lang":        i18n.FromContext(c),
+	})
+}
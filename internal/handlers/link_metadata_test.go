@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-short/internal/i18n"
+	"url-short/internal/metadata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateShortLink_FetchMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head>
+			<title>Fallback</title>
+			<meta property="og:title" content="Летняя распродажа">
+			<meta property="og:description" content="Скидки до 50%">
+			<meta property="og:image" content="https://cdn.example.com/sale.png">
+			<meta property="og:site_name" content="Shop">
+		</head></html>`))
+	}))
+	defer site.Close()
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	handler.MetadataFetcher = metadata.NewFetcher()
+	handler.MetadataFetcher.AllowPrivateNetworks = true
+
+	mock.ExpectQuery("SELECT EXISTS\\(.*").
+		WithArgs(nil, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO links").
+		WithArgs(1, site.URL, "sale", false, nil, nil,
+			"Своя подпись", "Скидки до 50%", "", "https://cdn.example.com/sale.png", "Shop", nil, site.URL+"/", "active", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"original_url": "` + site.URL + `", "custom_code": "sale", "title": "Своя подпись", "fetch_metadata": true}`
+	c.Request = httptest.NewRequest("POST", "/api/links", strings.NewReader(body))
+	c.Set("userID", 1)
+
+	handler.CreateShortLink(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"title": "Новый заголовок", "notes": "для рассылки"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "one").
+					WillReturnRows(linkRows(linkRow{
+						ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one",
+						Description: "Старое описание",
+					}))
+				mock.ExpectExec("UPDATE links SET title = \\$1").
+					WithArgs("Новый заголовок", "Старое описание", "для рассылки", "", "", 10).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"title":"Новый заголовок"`,
+		},
+		{
+			name:         "Title too long",
+			requestBody:  `{"title": "` + strings.Repeat("x", 256) + `"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error"`,
+		},
+		{
+			name:        "Foreign link",
+			requestBody: `{"title": "Чужая"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "one").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 2, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"error"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PATCH", "/api/links/one", strings.NewReader(tt.requestBody))
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+			c.Set("userID", 1)
+
+			handler.UpdateLink(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedirect_Preview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WithArgs(nil, "promo").
+		WillReturnRows(linkRows(linkRow{
+			ID: 1, UserID: 1, OriginalURL: "https://example.com/sale", ShortCode: "promo",
+			Title: "Распродажа", SiteName: "Shop",
+		}))
+
+	w := httptest.NewRecorder()
+	c, engine := gin.CreateTestContext(w)
+	engine.SetFuncMap(i18n.FuncMap())
+	engine.LoadHTMLGlob("../../web/templates/preview.html")
+	c.Request = httptest.NewRequest("GET", "/promo+", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "promo+"}}
+
+	handler.Redirect(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/sale")
+	assert.Contains(t, w.Body.String(), "Распродажа")
+	// Предпросмотр не считается кликом: ни счетчик, ни аналитика не трогаются
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
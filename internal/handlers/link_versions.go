@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+	"url-short/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// linkSettings собирает текущие версионируемые настройки ссылки.
+func (h *LinkHandler) linkSettings(link *models.Link) (models.LinkSettings, error) {
+	destinations, err := h.DestinationRepo.FindByLinkID(link.ID)
+	if err != nil {
+		return models.LinkSettings{}, err
+	}
+
+	settings := models.LinkSettings{
+		OriginalURL:      link.OriginalURL,
+		Destinations:     make([]models.DestinationRequest, 0, len(destinations)),
+		ExpiresAt:        link.ExpiresAt,
+		TrackConversions: link.TrackConversions,
+	}
+	for _, d := range destinations {
+		settings.Destinations = append(settings.Destinations, models.DestinationRequest{URL: d.URL, Weight: d.Weight})
+	}
+	return settings, nil
+}
+
+// settingsRequested сообщает, меняет ли запрос версионируемые настройки ссылки.
+func settingsRequested(req *models.UpdateLinkRequest) bool {
+	return req.OriginalURL != nil || req.Destinations != nil || req.ExpiresAt != nil ||
+		req.ClearExpiresAt || req.TrackConversions != nil
+}
+
+// applySettings применяет к настройкам изменения из запроса.
+func applySettings(settings models.LinkSettings, req *models.UpdateLinkRequest) models.LinkSettings {
+	if req.OriginalURL != nil {
+		settings.OriginalURL = *req.OriginalURL
+	}
+	if req.Destinations != nil {
+		settings.Destinations = append([]models.DestinationRequest{}, *req.Destinations...)
+	}
+	if req.ExpiresAt != nil {
+		settings.ExpiresAt = req.ExpiresAt
+	}
+	if req.ClearExpiresAt {
+		settings.ExpiresAt = nil
+	}
+	if req.TrackConversions != nil {
+		settings.TrackConversions = *req.TrackConversions
+	}
+	return settings
+}
+
+// saveSettings проверяет новые настройки и сохраняет их новой версией.
+// Если ничего не изменилось, версия не создается и возвращается nil.
+func (h *LinkHandler) saveSettings(link *models.Link, prev, next models.LinkSettings, userID int) (*models.LinkVersion, *linkError) {
+	changes := prev.Diff(next)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	for _, change := range changes {
+		if change.Field != "expires_at" || next.ExpiresAt == nil {
+			continue
+		}
+		if !next.ExpiresAt.After(time.Now()) {
+			return nil, invalidField("expires_at", "future", "Дата истечения должна быть в будущем")
+		}
+		if link.StartsAt != nil && !next.ExpiresAt.After(*link.StartsAt) {
+			return nil, invalidField("expires_at", "after", "Дата истечения должна быть позже даты активации")
+		}
+	}
+
+	normalizedURL, err := utils.NormalizeURL(next.OriginalURL)
+	if err != nil {
+		return nil, invalidField("original_url", "url", "Некорректный URL")
+	}
+	link.NormalizedURL = normalizedURL
+
+	version, err := h.LinkRepo.SaveVersion(link, prev, next, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrLinkNotFound) {
+			return nil, newLinkError(http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+		}
+		log.Printf("[ERROR] Ошибка сохранения версии: %v | Код: %s", err, link.ShortCode)
+		return nil, newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения ссылки")
+	}
+	version.Changes = changes
+
+	log.Printf("[INFO] Сохранена версия %d ссылки %s | Пользователь: %d", version.Version, link.ShortCode, userID)
+	return version, nil
+}
+
+// GetLinkHistory godoc
+// @Summary История версий ссылки
+// @Description Версии настроек перехода (адрес, варианты, срок действия, трекинг конверсий): кто, когда и что изменил. Пока ссылку не правили, в истории одна версия — текущая
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {array} models.LinkVersion
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/history [get]
+func (h *LinkHandler) GetLinkHistory(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.LinkRepo.FindVersions(link.ID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения истории")
+		return
+	}
+	if len(versions) == 0 {
+		settings, err := h.linkSettings(link)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения истории")
+			return
+		}
+		versions = append(versions, models.LinkVersion{
+			Version:   1,
+			UserID:    &link.UserID,
+			Settings:  settings,
+			CreatedAt: link.CreatedAt,
+		})
+	}
+
+	for i := range versions {
+		versions[i].Changes = []models.SettingChange{}
+		if i > 0 {
+			versions[i].Changes = versions[i-1].Settings.Diff(versions[i].Settings)
+		}
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// RollbackLink godoc
+// @Summary Откатить ссылку к версии
+// @Description Восстанавливает настройки перехода из выбранной версии. Откат сохраняется в истории новой версией
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param version path int true "Номер версии"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {object} models.LinkVersion
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/history/{version}/rollback [post]
+func (h *LinkHandler) RollbackLink(c *gin.Context) {
+	number, ok := paramID(c, "version")
+	if !ok {
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	target, err := h.LinkRepo.FindVersion(link.ID, number)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeVersionNotFound, "Версия не найдена")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения истории")
+		return
+	}
+
+	current, err := h.linkSettings(link)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения ссылки")
+		return
+	}
+
+	// Истекший срок действия восстановить нельзя: ссылка сразу перестала бы работать
+	next := target.Settings
+	if next.ExpiresAt != nil && !next.ExpiresAt.After(time.Now()) {
+		apierror.Respond(c, http.StatusConflict, apierror.CodeVersionExpired, "Срок действия в этой версии уже истек")
+		return
+	}
+
+	version, linkErr := h.saveSettings(link, current, next, c.MustGet("userID").(int))
+	if linkErr != nil {
+		linkErr.respond(c)
+		return
+	}
+	if version == nil {
+		apierror.Respond(c, http.StatusConflict, apierror.CodeVersionUnchanged, "Настройки ссылки уже совпадают с этой версией")
+		return
+	}
+	h.emit(link.UserID, models.EventLinkUpdated, toLinkSummary(c, *link))
+	c.JSON(http.StatusOK, version)
+}
@@ -0,0 +1,176 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var versionColumns = []string{"version", "user_id", "original_url", "destinations", "expires_at", "track_conversions", "created_at"}
+
+func TestUpdateLink_Repoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	created := time.Now().Add(-48 * time.Hour)
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WithArgs(nil, "one").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/old", ShortCode: "one", CreatedAt: created}))
+	mock.ExpectQuery("SELECT (.+) FROM link_destinations").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM links WHERE id = \\$1 FOR UPDATE").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	// Первая правка сохраняет исходное состояние как версию 1
+	mock.ExpectExec("INSERT INTO link_versions (.+) ON CONFLICT").
+		WithArgs(10, 1, "https://example.com/old", []byte("[]"), nil, false, created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE links SET original_url = \\$1").
+		WithArgs("https://example.com/new", "https://example.com/new", nil, false, 2, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO link_versions (.+) RETURNING created_at").
+		WithArgs(10, 2, 1, "https://example.com/new", []byte("[]"), nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE links SET title = \\$1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PATCH", "/api/links/one", strings.NewReader(`{"original_url": "https://example.com/new"}`))
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+	c.Set("userID", 1)
+
+	handler.UpdateLink(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"original_url":"https://example.com/new"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLinkHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/new", ShortCode: "one"}))
+	mock.ExpectQuery("SELECT (.+) FROM link_versions WHERE link_id = \\$1 ORDER BY version").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows(versionColumns).
+			AddRow(1, 1, "https://example.com/old", []byte("[]"), nil, false, time.Now().Add(-time.Hour)).
+			AddRow(2, 3, "https://example.com/new", []byte("[]"), nil, false, time.Now()))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/links/one/history", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+	c.Set("userID", 1)
+
+	handler.GetLinkHistory(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"changes":[]`)
+	assert.Contains(t, w.Body.String(), `"user_id":3`)
+	assert.Contains(t, w.Body.String(), `{"field":"original_url","old":"https://example.com/old","new":"https://example.com/new"}`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollbackLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		version      string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:    "Success",
+			version: "1",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/new", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT (.+) FROM link_versions WHERE link_id = \\$1 AND version = \\$2").
+					WithArgs(10, 1).
+					WillReturnRows(sqlmock.NewRows(versionColumns).
+						AddRow(1, 1, "https://example.com/old", []byte("[]"), nil, false, time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM link_destinations").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT version FROM links").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+				mock.ExpectExec("UPDATE links SET original_url = \\$1").
+					WithArgs("https://example.com/old", "https://example.com/old", nil, false, 3, 10).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO link_versions").
+					WithArgs(10, 3, 1, "https://example.com/old", []byte("[]"), nil, false).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"version":3`,
+		},
+		{
+			name:    "Unknown version",
+			version: "7",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/new", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT (.+) FROM link_versions").
+					WithArgs(10, 7).
+					WillReturnRows(sqlmock.NewRows(versionColumns))
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"error":"Версия не найдена"`,
+		},
+		{
+			name:    "Same settings",
+			version: "2",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/new", ShortCode: "one"}))
+				mock.ExpectQuery("SELECT (.+) FROM link_versions").
+					WillReturnRows(sqlmock.NewRows(versionColumns).
+						AddRow(2, 1, "https://example.com/new", []byte("[]"), nil, false, time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM link_destinations").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"error":"Настройки ссылки уже совпадают с этой версией"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/links/one/history/"+tt.version+"/rollback", nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}, {Key: "version", Value: tt.version}}
+			c.Set("userID", 1)
+
+			handler.RollbackLink(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
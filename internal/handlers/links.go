@@ -2,15 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/blocklist"
+	"url-short/internal/codegen"
+	"url-short/internal/metadata"
 	"url-short/internal/models"
+	"url-short/internal/privacy"
 	"url-short/internal/repositories"
+	"url-short/internal/stream"
 	"url-short/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +29,87 @@ import (
 )
 
 type LinkHandler struct {
-	LinkRepo     *repositories.LinkRepository
-	AnalyticRepo *repositories.AnalyticRepository
+	LinkRepo        *repositories.LinkRepository
+	AnalyticRepo    *repositories.AnalyticRepository
+	DestinationRepo *repositories.DestinationRepository
+	ConversionRepo  *repositories.ConversionRepository
+	TagRepo         *repositories.TagRepository
+	FolderRepo      *repositories.FolderRepository
+
+	// MetadataFetcher загружает заголовок и Open Graph страницы назначения; nil отключает загрузку
+	MetadataFetcher *metadata.Fetcher
+
+	// Codes подбирает свободные короткие коды
+	Codes *codegen.Allocator
+	// InactivePageURL и PausedPageURL — куда отправлять посетителей черновиков и
+	// запланированных ссылок и приостановленных ссылок; пусто — встроенные страницы
+	InactivePageURL string
+	PausedPageURL   string
+
+	// AliasRepo — дополнительные коды ссылок; nil отключает их поддержку
+	AliasRepo *repositories.AliasRepository
+	// CodeQuarantine — сколько код удаленного алиаса нельзя занять снова
+	CodeQuarantine time.Duration
+
+	// Blocklist запрещает зарезервированные и нежелательные пользовательские коды; nil — без ограничений.
+	// Сгенерированные коды фильтрует Codes.Allowed
+	Blocklist *blocklist.Blocklist
+
+	// QRLogo — логотип для центра QR-кодов; nil, если не настроен
+	QRLogo image.Image
+
+	// DomainRepo — собственные домены; nil отключает их поддержку
+	DomainRepo *repositories.DomainRepository
+	// DefaultDomain — основной домен сервиса; пусто — основным считается любой незарегистрированный хост
+	DefaultDomain string
+	// UnknownHostRedirect — куда отправлять запросы на незнакомые домены; пусто — 404
+	UnknownHostRedirect string
+
+	// Events получает события ссылок и кликов для вебхуков; nil — события не отправляются
+	Events EventEmitter
+	// ClickStream раздает клики в потоки реального времени; nil отключает потоки
+	ClickStream *stream.Hub
+
+	// AnalyticsMode — режим аналитики для ссылок без собственного режима; пусто — full
+	AnalyticsMode string
+	// ConsentCookie — cookie, в которой сайт сохраняет согласие посетителя на аналитику;
+	// пусто — analytics_consent
+	ConsentCookie string
+
+	// IPAnonymizer обезличивает IP-адреса кликов перед сохранением; nil — адреса хранятся как есть
+	IPAnonymizer *privacy.IPAnonymizer
+	// ExposeClickIPs включает IP-адреса кликов в статистику ссылки
+	ExposeClickIPs bool
+}
+
+// variantCookieMaxAge — сколько посетитель «помнит» выданный ему вариант A/B-теста.
+const variantCookieMaxAge = 30 * 24 * 60 * 60
+
+// variantCookieName называет cookie варианта по ссылке, а не по коду: основной код
+// и алиасы ссылки делят один выданный вариант.
+func variantCookieName(linkID int) string {
+	return "ab_" + strconv.Itoa(linkID)
+}
+
+// chooseDestination возвращает вариант, уже выданный посетителю (по cookie),
+// либо выбирает новый по весам и запоминает его.
+func chooseDestination(c *gin.Context, linkID int, destinations []models.LinkDestination) *models.LinkDestination {
+	if value, err := c.Cookie(variantCookieName(linkID)); err == nil {
+		if id, err := strconv.Atoi(value); err == nil {
+			for i := range destinations {
+				if destinations[i].ID == id {
+					return &destinations[i]
+				}
+			}
+		}
+	}
+
+	dest := utils.PickDestination(destinations)
+	if dest != nil {
+		// Путь "/", чтобы cookie приходила и на адреса алиасов
+		c.SetCookie(variantCookieName(linkID), strconv.Itoa(dest.ID), variantCookieMaxAge, "/", "", false, true)
+	}
+	return dest
 }
 
 type IPGeoResponse struct {
@@ -79,6 +169,18 @@ func getLocation(ip string) (string, error) {
 	return geoData.City + ", " + geoData.Country, nil
 }
 
+// appendQueryParam добавляет параметр к адресу назначения, сохраняя остальные.
+func appendQueryParam(rawURL, key, value string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 func isValidCustomCode(code string) bool {
 	if len(code) < 2 || len(code) > 20 {
 		return false
@@ -86,150 +188,485 @@ func isValidCustomCode(code string) bool {
 	return regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString(code)
 }
 
+// linkError — ошибка подготовки ссылки с HTTP-статусом и кодом для ответа.
+type linkError struct {
+	Status  int
+	Code    string
+	Message string
+	Details []models.FieldError
+}
+
+func newLinkError(status int, code, message string) *linkError {
+	return &linkError{Status: status, Code: code, Message: message}
+}
+
+// invalidField — ошибка проверки одного поля запроса.
+func invalidField(field, rule, message string) *linkError {
+	return &linkError{
+		Status:  http.StatusBadRequest,
+		Code:    apierror.CodeValidationFailed,
+		Message: message,
+		Details: []models.FieldError{apierror.Field(field, rule, message)},
+	}
+}
+
+func (e *linkError) respond(c *gin.Context) {
+	apierror.RespondDetails(c, e.Status, e.Code, e.Message, e.Details)
+}
+
+// takenKey — ключ кода в наборе занятых: коды уникальны в пределах домена
+// и сравниваются по правилам режима регистра.
+func (h *LinkHandler) takenKey(domain, code string) string {
+	return domain + "/" + h.LinkRepo.CaseMode.Fold(code)
+}
+
+// checkCustomCode проверяет, что пользовательский код (основной или алиас) можно занять на домене.
+func (h *LinkHandler) checkCustomCode(domainID *int, hostname, code string, taken map[string]bool) *linkError {
+	if !isValidCustomCode(code) {
+		return newLinkError(http.StatusBadRequest, apierror.CodeInvalidCode, "Код должен содержать от 2 до 20 символов: латинские буквы, цифры, _ и -")
+	}
+	switch err := h.Blocklist.Check(code); {
+	case errors.Is(err, blocklist.ErrReserved):
+		return newLinkError(http.StatusBadRequest, apierror.CodeCodeReserved, "Код зарезервирован сервисом, выберите другой")
+	case errors.Is(err, blocklist.ErrBlocked):
+		return newLinkError(http.StatusBadRequest, apierror.CodeCodeBlocked, "Код содержит недопустимое слово")
+	}
+	if taken[h.takenKey(hostname, code)] {
+		return newLinkError(http.StatusConflict, apierror.CodeCodeTaken, "Код уже занят")
+	}
+
+	exists, err := h.LinkRepo.IsShortCodeExist(domainID, code)
+	if err != nil {
+		return newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка проверки кода")
+	}
+	if exists {
+		return newLinkError(http.StatusConflict, apierror.CodeCodeTaken, "Код уже занят")
+	}
+	return nil
+}
+
+// buildLink проверяет запрос и подбирает короткий код, но ничего не сохраняет.
+// taken содержит коды, уже занятые в рамках текущей пачки (может быть nil), ключи — takenKey.
+func (h *LinkHandler) buildLink(userID int, req *models.CreateLinkRequest, taken map[string]bool) (*models.Link, *linkError) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, invalidField("expires_at", "future", "Дата истечения должна быть в будущем")
+	}
+	status := models.LinkStatusActive
+	if req.Status == models.LinkStatusDraft {
+		status = models.LinkStatusDraft
+	}
+	if req.StartsAt != nil {
+		if status == models.LinkStatusDraft {
+			return nil, invalidField("starts_at", "not_allowed", "Для черновика дата активации задается при планировании")
+		}
+		if !req.StartsAt.After(time.Now()) {
+			return nil, invalidField("starts_at", "future", "Дата активации должна быть в будущем")
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+			return nil, invalidField("expires_at", "after", "Дата истечения должна быть позже даты активации")
+		}
+		status = models.LinkStatusScheduled
+	}
+	if linkErr := h.checkFolder(userID, req.FolderID); linkErr != nil {
+		return nil, linkErr
+	}
+
+	var domainID *int
+	var hostname string
+	domain, linkErr := h.linkDomain(userID, req.Domain)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+	if domain != nil {
+		domainID, hostname = &domain.ID, domain.Hostname
+	}
+
+	normalizedURL, err := utils.NormalizeURL(req.OriginalURL)
+	if err != nil {
+		return nil, invalidField("original_url", "url", "Некорректный URL")
+	}
+
+	var shortCode string
+	if req.CustomCode != "" {
+		if linkErr := h.checkCustomCode(domainID, hostname, req.CustomCode, taken); linkErr != nil {
+			return nil, linkErr
+		}
+		shortCode = req.CustomCode
+	} else {
+		code, err := h.Codes.Allocate(func(code string) (bool, error) {
+			if taken[h.takenKey(hostname, code)] {
+				return true, nil
+			}
+			return h.LinkRepo.IsShortCodeExist(domainID, code)
+		})
+		if err != nil {
+			log.Printf("[ERROR] Ошибка генерации кода: %v", err)
+			return nil, newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации кода")
+		}
+		shortCode = code
+	}
+
+	return &models.Link{
+		UserID:           userID,
+		OriginalURL:      req.OriginalURL,
+		ShortCode:        shortCode,
+		TrackConversions: req.TrackConversions,
+		ExpiresAt:        req.ExpiresAt,
+		Tags:             normalizeTags(req.Tags),
+		FolderID:         req.FolderID,
+		Title:            strings.TrimSpace(req.Title),
+		Description:      strings.TrimSpace(req.Description),
+		Notes:            req.Notes,
+		DomainID:         domainID,
+		Domain:           hostname,
+		NormalizedURL:    normalizedURL,
+		Status:           status,
+		StartsAt:         req.StartsAt,
+	}, nil
+}
+
+// findReusable ищет существующую ссылку пользователя на тот же адрес для reuse_existing.
+// Запросы с собственным кодом или вариантами A/B-теста всегда создают новую ссылку.
+func (h *LinkHandler) findReusable(userID int, req *models.CreateLinkRequest) (*models.Link, *linkError) {
+	if !req.ReuseExisting || req.CustomCode != "" || len(req.Destinations) > 0 {
+		return nil, nil
+	}
+
+	domain, linkErr := h.linkDomain(userID, req.Domain)
+	if linkErr != nil {
+		return nil, linkErr
+	}
+	var domainID *int
+	if domain != nil {
+		domainID = &domain.ID
+	}
+
+	normalizedURL, err := utils.NormalizeURL(req.OriginalURL)
+	if err != nil {
+		return nil, invalidField("original_url", "url", "Некорректный URL")
+	}
+
+	link, err := h.LinkRepo.FindReusable(userID, domainID, normalizedURL)
+	if errors.Is(err, repositories.ErrLinkNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newLinkError(http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска ссылки")
+	}
+	return link, nil
+}
+
+// normalizeTags обрезает пробелы, отбрасывает пустые теги и дубликаты.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
 // CreateShortLink godoc
 // @Summary Создать короткую ссылку
+// @Description С reuse_existing возвращает существующую ссылку на тот же адрес (reused: true) вместо создания новой
 // @Tags links
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce json
 // @Param input body models.CreateLinkRequest true "Данные ссылки"
 // @Success 200 {object} models.LinkResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Router /api/links [post]
 func (h *LinkHandler) CreateShortLink(c *gin.Context) {
 	var req models.CreateLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный URL"})
+		apierror.RespondBinding(c, err, "Неверные данные")
 		return
 	}
 
 	userID := c.MustGet("userID").(int)
 
-	var shortCode string
-	var err error
-
-	if req.CustomCode != "" {
-		if !isValidCustomCode(req.CustomCode) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый формат кода"})
-			return
-		}
-
-		exists, err := h.LinkRepo.IsShortCodeExist(req.CustomCode)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки кода"})
-			return
-		}
-		if exists {
-			c.JSON(http.StatusConflict, gin.H{"error": "Код уже занят"})
-			return
-		}
-		shortCode = req.CustomCode
-	} else {
-		shortCode, err = utils.GenerateUniqueShortCode(h.LinkRepo)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации кода"})
-			return
-		}
+	existing, linkErr := h.findReusable(userID, &req)
+	if linkErr != nil {
+		linkErr.respond(c)
+		return
+	}
+	if existing != nil {
+		log.Printf("[INFO] Переиспользована ссылка: %s → %s", existing.ShortCode, existing.OriginalURL)
+		c.JSON(http.StatusOK, models.LinkResponse{
+			ShortCode: existing.ShortCode,
+			FullURL:   fullURL(c, existing),
+			Reused:    true,
+		})
+		return
 	}
 
-	link := &models.Link{
-		UserID:      userID,
-		OriginalURL: req.OriginalURL,
-		ShortCode:   shortCode,
+	link, linkErr := h.buildLink(userID, &req, nil)
+	if linkErr != nil {
+		linkErr.respond(c)
+		return
+	}
+	if req.FetchMetadata {
+		h.fetchMetadata(c.Request.Context(), link, false)
 	}
 
-	if err := h.LinkRepo.CreateLink(link); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения ссылки"})
+	destinations := make([]models.LinkDestination, 0, len(req.Destinations))
+	for _, d := range req.Destinations {
+		destinations = append(destinations, models.LinkDestination{URL: d.URL, Weight: d.Weight})
+	}
+	if err := h.LinkRepo.CreateLinkAtomic(link, destinations); err != nil {
+		log.Printf("[ERROR] Ошибка сохранения ссылки %s: %v", link.ShortCode, err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка сохранения ссылки")
 		return
 	}
+	h.emit(userID, models.EventLinkCreated, toLinkSummary(c, *link))
 
 	c.JSON(http.StatusOK, models.LinkResponse{
-		ShortCode: shortCode,
-		FullURL:   fmt.Sprintf("%s/%s", c.Request.Host, shortCode),
+		ShortCode: link.ShortCode,
+		FullURL:   fullURL(c, link),
 	})
 }
 
+// findPublicLink ищет ссылку для публичных адресов сначала по основному коду,
+// затем по алиасам. Возвращает код в том виде, в котором он сохранен.
+func (h *LinkHandler) findPublicLink(domainID *int, code string) (*models.Link, string, error) {
+	link, err := h.LinkRepo.FindByShortCode(domainID, code)
+	if errors.Is(err, repositories.ErrLinkNotFound) && h.AliasRepo != nil {
+		return h.LinkRepo.FindByAlias(domainID, code)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return link, link.ShortCode, nil
+}
+
 func (h *LinkHandler) Redirect(c *gin.Context) {
+	domainID, ok := h.resolveHost(c)
+	if !ok {
+		return
+	}
+
 	shortCode := c.Param("short_code")
-	log.Printf("[DEBUG] Запрос редиректа: %s", shortCode)
+	if code, ok := strings.CutSuffix(shortCode, previewSuffix); ok {
+		h.showPreview(c, domainID, code)
+		return
+	}
+	if code, format, ok := splitQRSuffix(shortCode); ok {
+		h.showQR(c, domainID, code, format)
+		return
+	}
+	log.Printf("[DEBUG] Запрос редиректа: %s%s", c.Request.Host, c.Request.URL.Path)
 
-	link, err := h.LinkRepo.FindByShortCode(shortCode)
+	link, clickedCode, err := h.findPublicLink(domainID, shortCode)
 	if err != nil {
 		log.Printf("[ERROR] Ошибка поиска: %v | Код: %s", err, shortCode)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ссылка не найдена"})
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
 		return
 	}
-	log.Printf("[INFO] Редирект: %s → %s", shortCode, link.OriginalURL)
 
-	if err := h.LinkRepo.IncrementClickCount(shortCode); err != nil {
-		log.Printf("[WARN] Ошибка инкремента: %v", err)
+	if h.serveUnavailable(c, link) {
+		return
 	}
 
-	location, err := getLocationWithCache(c.ClientIP())
+	target := link.OriginalURL
+	status := http.StatusMovedPermanently
+	var destinationID *int
+
+	destinations, err := h.DestinationRepo.FindByLinkID(link.ID)
 	if err != nil {
-		log.Printf("[WARN] Ошибка геолокации: %v", err)
-		location = "unknown"
+		log.Printf("[WARN] Ошибка загрузки вариантов: %v", err)
+	}
+	if dest := chooseDestination(c, link.ID, destinations); dest != nil {
+		target = dest.URL
+		destinationID = &dest.ID
+		// Постоянный редирект закешировался бы браузером и сломал ротацию
+		status = http.StatusFound
+	}
+
+	// Без согласия посетителя клик засчитывается анонимно и не получает идентификатор для конверсий
+	tracked := h.trackingAllowed(c, h.analyticsMode(link))
+
+	var clickUID string
+	if link.TrackConversions && tracked {
+		clickUID, err = utils.GenerateRandomCode(16)
+		if err != nil {
+			log.Printf("[WARN] Ошибка генерации идентификатора клика: %v", err)
+		} else if tracked, err := appendQueryParam(target, clickIDParam, clickUID); err != nil {
+			log.Printf("[WARN] Не удалось добавить идентификатор клика: %v", err)
+			clickUID = ""
+		} else {
+			target = tracked
+		}
+	}
+
+	log.Printf("[INFO] Редирект: %s → %s", shortCode, target)
+
+	// Порог кликов проверяется по счетчику, который вернула база: у параллельных
+	// переходов значения разные, и каждый порог срабатывает ровно один раз.
+	// При ошибке счетчик неизвестен, и порог не проверяется
+	clickCount, err := h.LinkRepo.IncrementClickCount(link.ID)
+	if err != nil {
+		log.Printf("[WARN] Ошибка инкремента: %v", err)
 	}
 
-	ua := useragent.Parse(c.GetHeader("User-Agent"))
 	clickData := &models.ClickAnalytic{
-		LinkID:     link.ID,
-		IPAddress:  c.ClientIP(),
-		UserAgent:  c.GetHeader("User-Agent"),
-		Location:   location,
-		DeviceType: ua.Device,
-		OS:         ua.OS,
-		Browser:    ua.Name,
+		LinkID:        link.ID,
+		DestinationID: destinationID,
+		Referrer:      c.Request.Referer(),
+		ClickUID:      clickUID,
+		Source:        clickSource(c),
+		ShortCode:     clickedCode,
+		Anonymous:     !tracked,
+	}
+	if tracked {
+		location, err := getLocationWithCache(c.ClientIP())
+		if err != nil {
+			log.Printf("[WARN] Ошибка геолокации: %v", err)
+			location = "unknown"
+		}
+
+		ip := c.ClientIP()
+		if h.IPAnonymizer != nil {
+			ip = h.IPAnonymizer.Anonymize(ip)
+		}
+
+		ua := useragent.Parse(c.GetHeader("User-Agent"))
+		clickData.IPAddress = ip
+		clickData.UserAgent = c.GetHeader("User-Agent")
+		clickData.Location = location
+		clickData.DeviceType = ua.Device
+		clickData.OS = ua.OS
+		clickData.Browser = ua.Name
 	}
 
 	if err := h.AnalyticRepo.SaveClick(clickData); err != nil {
 		log.Printf("[ERROR] Ошибка сохранения клика: %v", err)
 	}
+	click := models.ClickEvent{
+		ShortCode:   link.ShortCode,
+		ClickedCode: clickedCode,
+		Domain:      link.Domain,
+		Destination: target,
+		ClickCount:  clickCount,
+		Source:      clickData.Source,
+		DeviceType:  clickData.DeviceType,
+		OS:          clickData.OS,
+		Browser:     clickData.Browser,
+		Location:    clickData.Location,
+		Referrer:    clickData.Referrer,
+		ClickedAt:   time.Now(),
+	}
+	h.emit(link.UserID, models.EventClick, click)
+	if h.ClickStream != nil {
+		h.ClickStream.Publish(link.UserID, link.ID, click)
+	}
 
-	c.Redirect(http.StatusMovedPermanently, link.OriginalURL)
+	// Без этого браузеры и прокси кешируют 301 навсегда: следующие клики не учитываются,
+	// а смена адреса, пауза или истечение ссылки до посетителя не доходят
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(status, target)
 }
 
+// clickListLimit — сколько последних кликов показывает статистика ссылки.
+// Сводки по всем кликам читаются из счетчиков.
+const clickListLimit = 100
+
 // GetLinkStats godoc
 // @Summary Получить статистику кликов
-// @Description Возвращает аналитику кликов по короткой ссылке
+// @Description Возвращает аналитику кликов по короткой ссылке. Сводки считаются по счетчикам кликов за все время,
+// @Description список содержит не больше 100 последних сырых кликов за срок хранения CLICK_RETENTION_DAYS;
+// @Description IP-адреса в нем есть, только если включен EXPOSE_CLICK_IPS
 // @Tags analytics
 // @Security ApiKeyAuth
 // @Produce json
 // @Param short_code path string true "Короткий код ссылки" example(test123)
+// @Param domain query string false "Собственный домен ссылки"
 // @Success 200 {object} models.AnalyticsResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /api/links/{short_code}/stats [get]
 func (h *LinkHandler) GetLinkStats(c *gin.Context) {
-	shortCode := c.Param("short_code")
-
-	link, err := h.LinkRepo.FindByShortCode(shortCode)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ссылка не найдена"})
+	link, ok := h.findOwnLink(c)
+	if !ok {
 		return
 	}
 
-	dbStats, err := h.AnalyticRepo.GetAnalytics(link.ID)
+	dbStats, err := h.AnalyticRepo.GetAnalytics(link.ID, clickListLimit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения статистики"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
 		return
 	}
 
 	response := models.AnalyticsResponse{
-		TotalClicks: len(dbStats),
-		Clicks:      make([]models.ClickStatistic, 0),
+		TotalClicks: link.ClickCount,
+		Clicks:      make([]models.ClickStatistic, 0, len(dbStats)),
 	}
 
 	for _, s := range dbStats {
-		response.Clicks = append(response.Clicks, models.ClickStatistic{
-			IPAddress:  s.IPAddress,
+		click := models.ClickStatistic{
 			Location:   s.Location,
 			DeviceType: s.DeviceType,
 			OS:         s.OS,
 			Browser:    s.Browser,
+			Referrer:   s.Referrer,
+			Source:     s.Source,
+			ShortCode:  s.ShortCode,
 			ClickedAt:  s.ClickedAt,
-		})
+			Anonymous:  s.Anonymous,
+		}
+		if h.ExposeClickIPs {
+			click.IPAddress = s.IPAddress
+		}
+		response.Clicks = append(response.Clicks, click)
+	}
+
+	if response.Sources, err = h.AnalyticRepo.CountDimension(link.ID, models.DimensionSource); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+	if response.Codes, err = h.AnalyticRepo.CountDimension(link.ID, models.DimensionCode); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+
+	variants, err := h.AnalyticRepo.GetVariantClicks(link.ID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+	response.Variants = variants
+
+	response.Versions, err = h.AnalyticRepo.GetVersionClicks(link.ID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+	for i := range response.Versions {
+		// Клики до первой правки: история еще не записана, адрес — текущий
+		if response.Versions[i].OriginalURL == "" {
+			response.Versions[i].OriginalURL = link.OriginalURL
+		}
+	}
+
+	response.AnalyticsMode = h.analyticsMode(link)
+	response.Tracking, err = h.AnalyticRepo.CountDimension(link.ID, models.DimensionTracking)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+
+	if link.TrackConversions {
+		response.Conversions, err = h.ConversionRepo.GetReport(link.ID)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"url-short/internal/blocklist"
+	"url-short/internal/codegen"
 	"url-short/internal/handlers"
 	"url-short/internal/repositories"
 
@@ -23,10 +25,26 @@ func setupLinkHandler(t *testing.T) (*handlers.LinkHandler, sqlmock.Sqlmock, *sq
 
 	linkRepo := repositories.NewLinkRepository(db)
 	analyticRepo := repositories.NewAnalyticRepository(db)
+	destinationRepo := repositories.NewDestinationRepository(db)
+	conversionRepo := repositories.NewConversionRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	folderRepo := repositories.NewFolderRepository(db)
+	domainRepo := repositories.NewDomainRepository(db)
+	aliasRepo := repositories.NewAliasRepository(db)
 
 	return &handlers.LinkHandler{
-		LinkRepo:     linkRepo,
-		AnalyticRepo: analyticRepo,
+		LinkRepo:        linkRepo,
+		AnalyticRepo:    analyticRepo,
+		DestinationRepo: destinationRepo,
+		ConversionRepo:  conversionRepo,
+		TagRepo:         tagRepo,
+		FolderRepo:      folderRepo,
+		DomainRepo:      domainRepo,
+		AliasRepo:       aliasRepo,
+		Codes:           codegen.NewAllocator(&codegen.RandomGenerator{Alphabet: codegen.Base62}),
+		Blocklist:       blocklist.New([]string{"api", "static"}, nil),
+		// httptest.NewRequest по умолчанию использует хост example.com
+		DefaultDomain: "example.com",
 	}, mock, db
 }
 
@@ -45,8 +63,10 @@ func TestCreateShortLink(t *testing.T) {
 			mockClosure: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("SELECT EXISTS\\(.*").
 					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
 				mock.ExpectQuery("INSERT INTO links").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
 			},
 			expectedCode: http.StatusOK,
 			expectedBody: `"short_code"`,
@@ -56,31 +76,122 @@ func TestCreateShortLink(t *testing.T) {
 			requestBody: `{"original_url": "https://example.com", "custom_code": "mycode"}`,
 			mockClosure: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("SELECT EXISTS\\(.*").
-					WithArgs("mycode").
+					WithArgs(nil, "mycode").
 					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
 				mock.ExpectQuery("INSERT INTO links").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
 			},
 			expectedCode: http.StatusOK,
 			expectedBody: `"short_code":"mycode"`,
 		},
+		{
+			name: "Success with destinations",
+			requestBody: `{"original_url": "https://example.com", "custom_code": "abtest",
+				"destinations": [{"url": "https://a.example.com", "weight": 70}, {"url": "https://b.example.com", "weight": 30}]}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS\\(.*").
+					WithArgs(nil, "abtest").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("UPDATE link_destinations SET retired_at").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO link_destinations").
+					WithArgs(1, "https://a.example.com", 70).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO link_destinations").
+					WithArgs(1, "https://b.example.com", 30).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"short_code":"abtest"`,
+		},
+		{
+			name:         "Invalid destination weight",
+			requestBody:  `{"original_url": "https://example.com", "destinations": [{"url": "https://a.example.com", "weight": 0}]}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error"`,
+		},
+		{
+			name:        "Success with tags",
+			requestBody: `{"original_url": "https://example.com", "custom_code": "tagged", "tags": [" sale ", "sale", "summer"]}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS\\(.*").
+					WithArgs(nil, "tagged").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "sale").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO link_tags").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "summer").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO link_tags").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"short_code":"tagged"`,
+		},
+		{
+			name:        "Scheduled link",
+			requestBody: `{"original_url": "https://example.com", "custom_code": "launch", "starts_at": "2999-01-01T09:00:00Z"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS\\(.*").
+					WithArgs(nil, "launch").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WithArgs(1, "https://example.com", "launch", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						"scheduled", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"short_code":"launch"`,
+		},
+		{
+			name:         "Draft with activation date",
+			requestBody:  `{"original_url": "https://example.com", "status": "draft", "starts_at": "2999-01-01T09:00:00Z"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Для черновика дата активации задается при планировании"`,
+		},
+		{
+			name:         "Expiry in the past",
+			requestBody:  `{"original_url": "https://example.com", "expires_at": "2001-01-01T00:00:00Z"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"details":[{"field":"expires_at","rule":"future","message":"Дата истечения должна быть в будущем"}]`,
+		},
 		{
 			name:         "Invalid URL",
 			requestBody:  `{"original_url": "invalid-url"}`,
 			mockClosure:  func(mock sqlmock.Sqlmock) {},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: `"error":"Некорректный URL"`,
+			expectedBody: `"code":"validation_failed","status":400,"details":[{"field":"original_url","rule":"url","message":"Некорректный URL"}]`,
 		},
 		{
 			name:        "Custom code exists",
 			requestBody: `{"original_url": "https://example.com", "custom_code": "taken"}`,
 			mockClosure: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("SELECT EXISTS\\(.*").
-					WithArgs("taken").
+					WithArgs(nil, "taken").
 					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 			},
 			expectedCode: http.StatusConflict,
-			expectedBody: `"error":"Код уже занят"`,
+			expectedBody: `"error":"Код уже занят","code":"code_taken"`,
 		},
 		{
 			name:         "Invalid custom code",
@@ -89,6 +200,20 @@ func TestCreateShortLink(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `Код должен содержать`,
 		},
+		{
+			name:         "Reserved custom code",
+			requestBody:  `{"original_url": "https://example.com", "custom_code": "API"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Код зарезервирован сервисом, выберите другой"`,
+		},
+		{
+			name:         "Blocked custom code",
+			requestBody:  `{"original_url": "https://example.com", "custom_code": "sh1t-sale"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"error":"Код содержит недопустимое слово"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -122,25 +247,29 @@ func TestRedirect(t *testing.T) {
 	tests := []struct {
 		name           string
 		shortCode      string
+		query          string
+		cookie         *http.Cookie
 		mockClosure    func(mock sqlmock.Sqlmock)
 		expectedStatus int
+		expectedTarget string
 	}{
 		{
 			name:      "Success",
 			shortCode: "valid",
 			mockClosure: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, user_id, original_url, short_code, created_at FROM links WHERE short_code = \\$1").
-					WithArgs("valid").
-					WillReturnRows(
-						sqlmock.NewRows([]string{"id", "user_id", "original_url", "short_code", "created_at"}).
-							AddRow(1, 1, "https://example.com", "valid", time.Now()),
-					)
-
-				mock.ExpectExec("UPDATE links SET click_count = click_count \\+ 1 WHERE short_code = \\$1").
-					WithArgs("valid").
-					WillReturnResult(sqlmock.NewResult(1, 1))
-
-				mock.ExpectExec("INSERT INTO click_analytics").
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "valid").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "valid"}))
+
+				mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+
+				mock.ExpectQuery("UPDATE links SET click_count = click_count \\+ 1 WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+
+				mock.ExpectQuery("INSERT INTO click_analytics").
 					WithArgs(
 						1,
 						sqlmock.AnyArg(), // IP
@@ -150,17 +279,141 @@ func TestRedirect(t *testing.T) {
 						sqlmock.AnyArg(), // OS
 						sqlmock.AnyArg(), // Browser
 						sqlmock.AnyArg(), // Timestamp
+						nil,              // Destination
+						"",               // Referrer
+						nil,              // Click UID
+						"link",           // Source
+						"valid",          // Clicked code
+						false,            // Anonymous
 					).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 			},
 			expectedStatus: http.StatusMovedPermanently,
+			expectedTarget: "https://example.com",
+		},
+		{
+			name:      "QR scan is marked as source",
+			shortCode: "valid",
+			query:     "?src=qr",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "valid").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "valid"}))
+
+				mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+
+				mock.ExpectQuery("UPDATE links SET click_count").
+					WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WithArgs(
+						1,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						nil,
+						"",
+						nil,
+						"qr",
+						"valid",
+						false,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusMovedPermanently,
+			expectedTarget: "https://example.com",
+		},
+		{
+			name:      "Sticky variant from cookie",
+			shortCode: "abtest",
+			cookie:    &http.Cookie{Name: "ab_2", Value: "11"},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "abtest").
+					WillReturnRows(linkRows(linkRow{ID: 2, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abtest"}))
+
+				mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}).
+						AddRow(10, 2, "https://a.example.com", 99).
+						AddRow(11, 2, "https://b.example.com", 1))
+
+				mock.ExpectQuery("UPDATE links SET click_count").
+					WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WithArgs(
+						2,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						11,
+						sqlmock.AnyArg(),
+						nil,
+						"link",
+						"abtest",
+						false,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusFound,
+			expectedTarget: "https://b.example.com",
+		},
+		{
+			name:      "Conversion tracking appends click id",
+			shortCode: "tracked",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "tracked").
+					WillReturnRows(linkRows(linkRow{
+						ID: 3, UserID: 1, OriginalURL: "https://shop.example.com/?utm_source=sl", ShortCode: "tracked",
+						TrackConversions: true,
+					}))
+
+				mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+
+				mock.ExpectQuery("UPDATE links SET click_count").
+					WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+
+				mock.ExpectQuery("INSERT INTO click_analytics").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusMovedPermanently,
+		},
+		{
+			name:      "Expired link",
+			shortCode: "old",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "old").
+					WillReturnRows(linkRows(linkRow{
+						ID: 4, UserID: 1, OriginalURL: "https://example.com", ShortCode: "old",
+						ExpiresAt: ptr(time.Now().Add(-time.Hour)),
+					}))
+			},
+			expectedStatus: http.StatusGone,
 		},
 		{
 			name:      "Link not found",
 			shortCode: "invalid",
 			mockClosure: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, user_id, original_url, short_code, created_at FROM links WHERE short_code = \\$1").
-					WithArgs("invalid").
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "invalid").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT link_id, short_code FROM link_aliases").
+					WithArgs(nil, "invalid").
 					WillReturnError(sql.ErrNoRows)
 			},
 			expectedStatus: http.StatusNotFound,
@@ -176,16 +429,83 @@ func TestRedirect(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
-			c.Request = httptest.NewRequest("GET", "/"+tt.shortCode, nil)
+			c.Request = httptest.NewRequest("GET", "/"+tt.shortCode+tt.query, nil)
+			c.Request.RemoteAddr = "127.0.0.1:1234"
+			if tt.cookie != nil {
+				c.Request.AddCookie(tt.cookie)
+			}
 			c.Params = gin.Params{{Key: "short_code", Value: tt.shortCode}}
 
 			handler.Redirect(c)
 
 			t.Logf("Response body: %s", w.Body.String())
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedTarget != "" {
+				assert.Equal(t, tt.expectedTarget, w.Header().Get("Location"))
+			}
+			if w.Code == http.StatusMovedPermanently || w.Code == http.StatusFound {
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			}
+			if tt.shortCode == "tracked" {
+				assert.Regexp(t, `^https://shop\.example\.com/\?clid=[\w-]{16}&utm_source=sl$`, w.Header().Get("Location"))
+			}
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("Unfulfilled expectations: %s", err)
 			}
 		})
 	}
 }
+
+func TestCreateShortLink_ReuseExisting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Returns the existing link", func(t *testing.T) {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE user_id = \\$1 AND domain_id IS NOT DISTINCT FROM \\$2 AND normalized_url = \\$3").
+			WithArgs(1, nil, "https://example.com/page?a=1&b=2").
+			WillReturnRows(linkRows(linkRow{ID: 7, UserID: 1, OriginalURL: "https://Example.com:443/page/?b=2&a=1", ShortCode: "exist"}))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"original_url": "https://EXAMPLE.com/page?b=2&a=1", "reuse_existing": true}`
+		c.Request = httptest.NewRequest("POST", "/api/links", strings.NewReader(body))
+		c.Set("userID", 1)
+
+		handler.CreateShortLink(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"short_code":"exist"`)
+		assert.Contains(t, w.Body.String(), `"reused":true`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Creates a new link when there is no match", func(t *testing.T) {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE user_id = \\$1").
+			WithArgs(1, nil, "https://example.com/new").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT EXISTS").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO links").
+			WithArgs(1, "https://example.com/new/", sqlmock.AnyArg(), false, nil, nil, "", "", "", "", "", nil, "https://example.com/new", "active", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+		mock.ExpectCommit()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"original_url": "https://example.com/new/", "reuse_existing": true}`
+		c.Request = httptest.NewRequest("POST", "/api/links", strings.NewReader(body))
+		c.Set("userID", 1)
+
+		handler.CreateShortLink(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"reused":false`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-short/internal/privacy"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectAnonymizesIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	handler.IPAnonymizer, _ = privacy.NewIPAnonymizer(privacy.IPModeTruncate, "")
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "valid"}))
+	mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}))
+	mock.ExpectQuery("UPDATE links SET click_count").
+		WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO click_analytics").
+		WithArgs(1, "127.0.0.0", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), nil, "", nil, "link", "valid", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/valid", nil)
+	c.Request.RemoteAddr = "127.0.0.1:1234"
+	c.Params = gin.Params{{Key: "short_code", Value: "valid"}}
+
+	handler.Redirect(c)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLinkStatsHidesIPs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		expose  bool
+		visible bool
+	}{
+		{name: "Hidden by default", expose: false, visible: false},
+		{name: "Exposed when enabled", expose: true, visible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			handler.ExposeClickIPs = tt.expose
+
+			mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+				WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc", ClickCount: 42}))
+			mock.ExpectQuery("FROM click_analytics WHERE link_id = \\$1").
+				WithArgs(1, 100).
+				WillReturnRows(sqlmock.NewRows([]string{"ip_address", "location", "device_type", "os", "browser", "referrer", "source", "short_code", "clicked_at", "anonymous"}).
+					AddRow("203.0.113.0", "Moscow, Russia", "mobile", "Android", "Chrome", "", "link", "abc", time.Now(), false))
+			mock.ExpectQuery("FROM click_rollups_daily WHERE link_id = \\$1 AND dimension = \\$2").
+				WithArgs(1, "source").
+				WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).AddRow("link", 40).AddRow("qr", 2))
+			mock.ExpectQuery("FROM click_rollups_daily WHERE link_id = \\$1 AND dimension = \\$2").
+				WithArgs(1, "short_code").
+				WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).AddRow("abc", 42))
+			mock.ExpectQuery("FROM link_destinations d LEFT JOIN click_rollups_daily r").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "url", "weight", "retired_at", "count"}))
+			mock.ExpectQuery("FROM click_rollups_daily r LEFT JOIN link_versions").
+				WillReturnRows(sqlmock.NewRows([]string{"version", "original_url", "count"}).AddRow(1, "", 1))
+			mock.ExpectQuery("FROM click_rollups_daily WHERE link_id = \\$1 AND dimension = \\$2").
+				WithArgs(1, "tracking").
+				WillReturnRows(sqlmock.NewRows([]string{"value", "clicks"}).AddRow("full", 1))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/links/abc/stats", nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "abc"}}
+			c.Set("userID", 1)
+
+			handler.GetLinkStats(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			// Сырые клики могли удалить по сроку хранения, итог берется из счетчика ссылки
+			assert.Contains(t, w.Body.String(), `"total_clicks":42`)
+			// Сводки считаются по счетчикам, а не по списку последних кликов
+			assert.Contains(t, w.Body.String(), `"sources":{"link":40,"qr":2}`)
+			assert.Contains(t, w.Body.String(), `"codes":{"abc":42}`)
+			if tt.visible {
+				assert.Contains(t, w.Body.String(), `"ip_address":"203.0.113.0"`)
+			} else {
+				assert.NotContains(t, w.Body.String(), "ip_address")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetLinkStatsOtherUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	handler.ExposeClickIPs = true
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+		WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc"}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/links/abc/stats", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "abc"}}
+	c.Set("userID", 2)
+
+	handler.GetLinkStats(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"link_not_found"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+	"url-short/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// emailTokenTTL — сколько действует ссылка подтверждения нового email.
+const emailTokenTTL = 24 * time.Hour
+
+// hashToken возвращает SHA-256 токена: в базе хранятся только хеши токенов из писем.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findCurrentUser загружает пользователя из токена. Пользователь мог удалить
+// аккаунт, пока токен еще действует.
+func (h *AccountHandler) findCurrentUser(c *gin.Context) (*models.User, bool) {
+	user, err := h.UserRepo.FindByID(c.MustGet("userID").(int))
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeUserNotFound, "Пользователь не найден")
+			return nil, false
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения профиля")
+		return nil, false
+	}
+	return user, true
+}
+
+// GetProfile godoc
+// @Summary Профиль текущего пользователя
+// @Tags account
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.UserProfile
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me [get]
+func (h *AccountHandler) GetProfile(c *gin.Context) {
+	user, ok := h.findCurrentUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, user.Profile())
+}
+
+// UpdateProfile godoc
+// @Summary Изменить профиль
+// @Description Меняет имя пользователя, email и язык. Имя и язык меняются сразу; новый email требует
+// @Description текущего пароля и начинает действовать после перехода по ссылке из письма, отправленного на него
+// @Tags account
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body models.UpdateProfileRequest true "Новые имя, email и язык"
+// @Success 200 {object} models.UserProfile
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/me [patch]
+func (h *AccountHandler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if req.Username == nil && req.Email == nil && req.Locale == nil {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Укажите имя пользователя, email или язык")
+		return
+	}
+	if req.Locale != nil && *req.Locale != "" && !i18n.Supported(*req.Locale) {
+		invalidField("locale", "oneof", "Язык не поддерживается").respond(c)
+		return
+	}
+
+	user, ok := h.findCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var newEmail string
+	if req.Email != nil && strings.TrimSpace(*req.Email) != user.Email {
+		newEmail = strings.TrimSpace(*req.Email)
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+			apierror.Respond(c, http.StatusForbidden, apierror.CodePasswordRequired, "Для смены email нужен текущий пароль")
+			return
+		}
+		if _, err := h.UserRepo.FindByEmail(newEmail); err == nil {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeEmailTaken, "Email уже используется")
+			return
+		} else if !errors.Is(err, repositories.ErrUserNotFound) {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка обновления профиля")
+			return
+		}
+	}
+
+	if req.Username != nil && *req.Username != user.Username {
+		if err := h.UserRepo.UpdateUsername(user.ID, *req.Username); err != nil {
+			if errors.Is(err, repositories.ErrUsernameTaken) {
+				apierror.Respond(c, http.StatusConflict, apierror.CodeUsernameTaken, "Имя пользователя уже занято")
+				return
+			}
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка обновления профиля")
+			return
+		}
+		user.Username = *req.Username
+	}
+
+	if req.Locale != nil && *req.Locale != user.Locale {
+		if err := h.UserRepo.UpdateLocale(user.ID, *req.Locale); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка обновления профиля")
+			return
+		}
+		user.Locale = *req.Locale
+	}
+
+	if newEmail != "" {
+		if !h.requestEmailChange(c, user, newEmail) {
+			return
+		}
+		user.PendingEmail = newEmail
+	}
+	c.JSON(http.StatusOK, user.Profile())
+}
+
+// userLocale — язык писем пользователю: выбранный в профиле или язык текущего запроса.
+func userLocale(c *gin.Context, user *models.User) string {
+	if i18n.Supported(user.Locale) {
+		return user.Locale
+	}
+	return i18n.FromContext(c)
+}
+
+// requestEmailChange сохраняет новый email и отправляет на него ссылку подтверждения,
+// а на текущий адрес — уведомление о запросе смены.
+func (h *AccountHandler) requestEmailChange(c *gin.Context, user *models.User, email string) bool {
+	token, err := utils.GenerateRandomCode(43)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации токена")
+		return false
+	}
+	if err := h.UserRepo.RequestEmailChange(user.ID, email, hashToken(token), emailTokenTTL); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка обновления профиля")
+		return false
+	}
+
+	locale := userLocale(c, user)
+	link := strings.TrimRight(h.AppURL, "/") + "/api/email/confirm?token=" + url.QueryEscape(token)
+	body := i18n.Tf(locale, "Здравствуйте, %s!\n\nЧтобы подтвердить новый email, перейдите по ссылке:\n%s\n\n"+
+		"Ссылка действует %d ч. Если вы не меняли email, проигнорируйте это письмо.\n",
+		user.Username, link, int(emailTokenTTL.Hours()))
+	if err := h.Mailer.Send(email, i18n.T(locale, "Подтверждение email"), body); err != nil {
+		log.Printf("[ERROR] Ошибка отправки письма подтверждения: %v | Пользователь: %d", err, user.ID)
+		apierror.Respond(c, http.StatusBadGateway, apierror.CodeMailFailed, "Не удалось отправить письмо подтверждения")
+		return false
+	}
+
+	notice := i18n.Tf(locale, "Здравствуйте, %s!\n\nДля вашего аккаунта запрошена смена email на %s. "+
+		"Адрес изменится после подтверждения по ссылке из письма.\n\n"+
+		"Если это были не вы, смените пароль.\n", user.Username, email)
+	if err := h.Mailer.Send(user.Email, i18n.T(locale, "Запрос на смену email"), notice); err != nil {
+		log.Printf("[WARN] Не удалось отправить уведомление о смене email: %v | Пользователь: %d", err, user.ID)
+	}
+	log.Printf("[INFO] Запрошена смена email | Пользователь: %d", user.ID)
+	return true
+}
+
+// ConfirmEmail godoc
+// @Summary Подтвердить новый email
+// @Description Применяет новый email по токену из письма
+// @Tags account
+// @Produce json
+// @Param token query string true "Токен из письма"
+// @Success 200 {object} models.UserProfile
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/email/confirm [get]
+func (h *AccountHandler) ConfirmEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Не указан токен")
+		return
+	}
+
+	user, err := h.UserRepo.ConfirmEmail(hashToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInvalidEmailToken):
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeEmailTokenInvalid, "Ссылка подтверждения недействительна или устарела")
+		case errors.Is(err, repositories.ErrEmailTaken):
+			apierror.Respond(c, http.StatusConflict, apierror.CodeEmailTaken, "Email уже используется")
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка подтверждения email")
+		}
+		return
+	}
+	log.Printf("[INFO] Email подтвержден | Пользователь: %d", user.ID)
+	c.JSON(http.StatusOK, user.Profile())
+}
+
+// ChangePassword godoc
+// @Summary Сменить пароль
+// @Description Меняет пароль после проверки текущего и завершает все сессии, кроме текущей
+// @Tags account
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/me/password [post]
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+
+	user, ok := h.findCurrentUser(c)
+	if !ok {
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)); err != nil {
+		apierror.Respond(c, http.StatusForbidden, apierror.CodeInvalidPassword, "Неверный пароль")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка хеширования")
+		return
+	}
+	if err := h.UserRepo.ChangePassword(user.ID, string(hash), c.GetString("sessionID")); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка смены пароля")
+		return
+	}
+	log.Printf("[INFO] Пароль изменен, остальные сессии завершены | Пользователь: %d", user.ID)
+	c.Status(http.StatusNoContent)
+}
+
+// ListSessions godoc
+// @Summary Активные сессии
+// @Description Устройства, с которых выполнен вход и сессия которых не истекла и не завершена
+// @Tags account
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Session
+// @Router /api/me/sessions [get]
+func (h *AccountHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.SessionRepo.FindActive(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения сессий")
+		return
+	}
+	current := c.GetString("sessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Завершить сессию
+// @Description Токен этой сессии перестает действовать. Завершение текущей сессии — выход из аккаунта
+// @Tags account
+// @Security ApiKeyAuth
+// @Param id path string true "ID сессии"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/me/sessions/{id} [delete]
+func (h *AccountHandler) RevokeSession(c *gin.Context) {
+	err := h.SessionRepo.Revoke(c.MustGet("userID").(int), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeSessionNotFound, "Сессия не найдена")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка завершения сессии")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
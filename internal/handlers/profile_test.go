@@ -0,0 +1,455 @@
+package handlers_test
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/i18n"
+	"url-short/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("FROM users WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(userRows(1, "test@example.com", "secret"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/me", nil)
+	c.Set("userID", 1)
+
+	handler.GetProfile(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"username":"user","email":"test@example.com","locale":"","created_at":"2024-01-01T00:00:00Z"}`, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+		expectedMail int
+	}{
+		{
+			name:        "Username",
+			requestBody: `{"username": "renamed"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectExec("UPDATE users SET username = \\$2 WHERE id = \\$1").
+					WithArgs(1, "renamed").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"username":"renamed"`,
+		},
+		{
+			name:        "Email requires confirmation",
+			requestBody: `{"email": "new@example.com", "password": "secret"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectQuery("FROM users WHERE email = ?").
+					WithArgs("new@example.com").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("UPDATE users SET pending_email = \\$2").
+					WithArgs(1, "new@example.com", sqlmock.AnyArg(), float64(24*60*60)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"email":"test@example.com","pending_email":"new@example.com"`,
+			expectedMail: 2,
+		},
+		{
+			name:        "Email without password",
+			requestBody: `{"email": "new@example.com"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: "текущий пароль",
+		},
+		{
+			name:        "Email taken",
+			requestBody: `{"email": "taken@example.com", "password": "secret"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectQuery("FROM users WHERE email = ?").
+					WithArgs("taken@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password_hash"}).
+						AddRow(2, "other", "taken@example.com", "hash"))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: "Email уже используется",
+		},
+		{
+			name:        "Locale",
+			requestBody: `{"locale": "en"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectExec("UPDATE users SET locale = \\$2 WHERE id = \\$1").
+					WithArgs(1, "en").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"locale":"en"`,
+		},
+		{
+			name:         "Unsupported locale",
+			requestBody:  `{"locale": "de"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"field":"locale","rule":"oneof"`,
+		},
+		{
+			name:         "Nothing to change",
+			requestBody:  `{}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid email",
+			requestBody:  `{"email": "not-an-email", "password": "secret"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PATCH", "/api/me", strings.NewReader(tt.requestBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("userID", 1)
+
+			handler.UpdateProfile(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Len(t, handler.Mailer.(*fakeMailer).sent, tt.expectedMail)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateProfileSendsConfirmationLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	var storedHash string
+	mock.ExpectQuery("FROM users WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(userRows(1, "test@example.com", "secret"))
+	mock.ExpectQuery("FROM users WHERE email = ?").
+		WithArgs("new@example.com").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("UPDATE users SET pending_email").
+		WithArgs(1, "new@example.com", hashCapture{&storedHash}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PATCH", "/api/me", strings.NewReader(`{"email": "new@example.com", "password": "secret"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", 1)
+
+	handler.UpdateProfile(c)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, http.StatusOK, w.Code)
+
+	sent := handler.Mailer.(*fakeMailer).sent
+	require.Len(t, sent, 2)
+	assert.Equal(t, "new@example.com", sent[0].to)
+	assert.Equal(t, "test@example.com", sent[1].to)
+
+	// В письме токен, в базе — только его хеш
+	link := regexp.MustCompile(`https://sho\.rt/api/email/confirm\?token=\S+`).FindString(sent[0].body)
+	require.NotEmpty(t, link)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(u.Query().Get("token")))
+	assert.Equal(t, hex.EncodeToString(sum[:]), storedHash)
+	assert.NotContains(t, sent[0].body, storedHash)
+}
+
+func TestUpdateProfileMailLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("FROM users WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(userRows(1, "test@example.com", "secret"))
+	mock.ExpectQuery("FROM users WHERE email = ?").
+		WithArgs("new@example.com").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("UPDATE users SET locale").
+		WithArgs(1, "en").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET pending_email").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PATCH", "/api/me", strings.NewReader(`{"email": "new@example.com", "password": "secret", "locale": "en"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", 1)
+	// Язык запроса русский, но письма уходят на языке, выбранном в профиле
+	c.Set(i18n.ContextKey, i18n.Russian)
+
+	handler.UpdateProfile(c)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, http.StatusOK, w.Code)
+
+	sent := handler.Mailer.(*fakeMailer).sent
+	require.Len(t, sent, 2)
+	assert.Equal(t, "Confirm your email", sent[0].subject)
+	assert.Contains(t, sent[0].body, "To confirm your new email, follow this link:")
+	assert.Equal(t, "Email change requested", sent[1].subject)
+	assert.Contains(t, sent[1].body, "change of your account email to new@example.com")
+}
+
+// hashCapture сохраняет аргумент запроса, чтобы сверить его с токеном из письма.
+type hashCapture struct {
+	value *string
+}
+
+func (h hashCapture) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*h.value = s
+	return ok
+}
+
+func TestConfirmEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		token        string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "Success",
+			token: "token",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				sum := sha256.Sum256([]byte("token"))
+				mock.ExpectQuery("UPDATE users SET email = pending_email").
+					WithArgs(hex.EncodeToString(sum[:])).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "created_at", "locale"}).
+						AddRow(1, "user", "new@example.com", time.Now(), ""))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"email":"new@example.com"`,
+		},
+		{
+			name:  "Expired",
+			token: "stale",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("UPDATE users SET email = pending_email").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "недействительна или устарела",
+		},
+		{
+			name:         "Missing token",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/email/confirm?token="+tt.token, nil)
+
+			handler.ConfirmEmail(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"old_password": "secret", "new_password": "new-secret"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET password_hash = \\$2 WHERE id = \\$1").
+					WithArgs(1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE user_sessions SET revoked_at = NOW\\(\\) WHERE user_id = \\$1 AND id <> \\$2").
+					WithArgs(1, "current").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "Wrong old password",
+			requestBody: `{"old_password": "wrong", "new_password": "new-secret"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM users WHERE id = ?").
+					WithArgs(1).
+					WillReturnRows(userRows(1, "test@example.com", "secret"))
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Weak new password",
+			requestBody:  `{"old_password": "secret", "new_password": "1"}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/me/password", strings.NewReader(tt.requestBody))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("userID", 1)
+			c.Set("sessionID", "current")
+
+			handler.ChangePassword(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupAccountHandler(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM user_sessions WHERE user_id = \\$1 AND revoked_at IS NULL AND expires_at > NOW\\(\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_agent", "ip_address", "created_at", "last_seen_at", "expires_at"}).
+			AddRow("current", "Firefox", "203.0.113.7", now, now, now.Add(time.Hour)).
+			AddRow("phone", "Safari", "198.51.100.2", now, now, now.Add(time.Hour)))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/me/sessions", nil)
+	c.Set("userID", 1)
+	c.Set("sessionID", "current")
+
+	handler.ListSessions(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var sessions []models.Session
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+	assert.Equal(t, "Safari", sessions[1].UserAgent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		affected     int64
+		expectedCode int
+	}{
+		{name: "Success", affected: 1, expectedCode: http.StatusNoContent},
+		{name: "Unknown session", affected: 0, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupAccountHandler(t)
+			defer db.Close()
+
+			mock.ExpectExec("UPDATE user_sessions SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2").
+				WithArgs("phone", 1).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("DELETE", "/api/me/sessions/phone", nil)
+			c.Params = gin.Params{{Key: "id", Value: "phone"}}
+			c.Set("userID", 1)
+
+			handler.RevokeSession(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
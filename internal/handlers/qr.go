@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/qr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sourceParam — параметр, которым QR-код помечает переходы, чтобы отличать их в аналитике.
+const sourceParam = "src"
+
+// qrCacheMaxAge — сколько клиенты и CDN могут хранить картинку; изменения параметров меняют ETag.
+const qrCacheMaxAge = 24 * 60 * 60
+
+// clickSource определяет источник перехода по параметру sourceParam.
+func clickSource(c *gin.Context) string {
+	if c.Query(sourceParam) == models.ClickSourceQR {
+		return models.ClickSourceQR
+	}
+	return models.ClickSourceLink
+}
+
+// qrContent — адрес, который кодируется в QR-код. Схема обязательна:
+// без нее сканеры показывают адрес как обычный текст.
+func qrContent(c *gin.Context, link *models.Link) string {
+	scheme := "http://"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https://"
+	}
+	return scheme + fullURL(c, link) + "?" + sourceParam + "=" + models.ClickSourceQR
+}
+
+// splitQRSuffix отделяет расширение картинки от кода: "abc.png" → "abc", "png".
+func splitQRSuffix(shortCode string) (string, string, bool) {
+	for _, format := range []string{qr.FormatPNG, qr.FormatSVG} {
+		if code, ok := strings.CutSuffix(shortCode, "."+format); ok {
+			return code, format, true
+		}
+	}
+	return "", "", false
+}
+
+// parseQROptions читает параметры отрисовки из query-строки.
+func (h *LinkHandler) parseQROptions(c *gin.Context) (qr.Options, string) {
+	opts := qr.DefaultOptions()
+
+	if v := c.Query("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, qr.ErrInvalidSize.Error()
+		}
+		opts.Size = size
+	}
+	if v := c.Query("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, qr.ErrInvalidMargin.Error()
+		}
+		opts.Margin = margin
+	}
+	if v := c.Query("level"); v != "" {
+		opts.Level = strings.ToUpper(v)
+	}
+	var err error
+	if v := c.Query("fg"); v != "" {
+		if opts.Foreground, err = qr.ParseColor(v); err != nil {
+			return opts, err.Error()
+		}
+	}
+	if v := c.Query("bg"); v != "" {
+		if opts.Background, err = qr.ParseColor(v); err != nil {
+			return opts, err.Error()
+		}
+	}
+	if v := c.Query("logo"); v != "" {
+		withLogo, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, "Параметр logo должен быть true или false"
+		}
+		if withLogo {
+			if h.QRLogo == nil {
+				return opts, "Логотип для QR-кодов не настроен"
+			}
+			opts.Logo = h.QRLogo
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err.Error()
+	}
+	return opts, ""
+}
+
+// GetLinkQR godoc
+// @Summary QR-код ссылки
+// @Description Рисует QR-код полного адреса ссылки. Переходы по нему помечаются в аналитике источником qr
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce png
+// @Produce image/svg+xml
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param format query string false "png или svg" default(png)
+// @Param size query int false "Сторона в пикселях (64–2048)" default(256)
+// @Param level query string false "Уровень коррекции ошибок: L, M, Q, H" default(M)
+// @Param margin query int false "Отступ в модулях (0–16)" default(4)
+// @Param fg query string false "Цвет модулей, RRGGBB" default(000000)
+// @Param bg query string false "Цвет фона, RRGGBB" default(ffffff)
+// @Param logo query bool false "Логотип в центре кода"
+// @Success 200 {file} file
+// @Success 304 "Не изменился (If-None-Match)"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/qr [get]
+func (h *LinkHandler) GetLinkQR(c *gin.Context) {
+	format := c.DefaultQuery("format", qr.FormatPNG)
+	if format != qr.FormatPNG && format != qr.FormatSVG {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Формат должен быть png или svg")
+		return
+	}
+
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+	h.renderQR(c, link, format, "private")
+}
+
+// showQR отдает QR-код по публичному адресу /<code>.png или /<code>.svg.
+func (h *LinkHandler) showQR(c *gin.Context, domainID *int, shortCode, format string) {
+	link, _, err := h.findPublicLink(domainID, shortCode)
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+		return
+	}
+	h.renderQR(c, link, format, "public")
+}
+
+// renderQR отдает картинку с поддержкой условных запросов;
+// cacheScope — public для публичного адреса, private для API.
+func (h *LinkHandler) renderQR(c *gin.Context, link *models.Link, format, cacheScope string) {
+	opts, msg := h.parseQROptions(c)
+	if msg != "" {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, msg)
+		return
+	}
+
+	content := qrContent(c, link)
+	etag := qr.ETag(content, format, opts)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheScope+", max-age="+strconv.Itoa(qrCacheMaxAge))
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	write := qr.WritePNG
+	if format == qr.FormatSVG {
+		contentType = "image/svg+xml"
+		write = qr.WriteSVG
+	}
+	if err := write(&buf, content, opts); err != nil {
+		if errors.Is(err, qr.ErrTooSmall) {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+			return
+		}
+		log.Printf("[ERROR] Ошибка генерации QR-кода: %v | Код: %s", err, link.ShortCode)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка генерации QR-кода")
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLinkQR(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ownLink := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+			WithArgs(nil, "one").
+			WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedType string
+	}{
+		{
+			name:         "PNG by default",
+			mockClosure:  ownLink,
+			expectedCode: http.StatusOK,
+			expectedType: "image/png",
+		},
+		{
+			name:         "SVG with colors",
+			query:        "?format=svg&fg=123456&bg=fff&level=h&margin=2&size=512",
+			mockClosure:  ownLink,
+			expectedCode: http.StatusOK,
+			expectedType: "image/svg+xml",
+		},
+		{
+			name:         "Unknown format",
+			query:        "?format=gif",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid color",
+			query:        "?fg=red",
+			mockClosure:  ownLink,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Logo is not configured",
+			query:        "?logo=true",
+			mockClosure:  ownLink,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Foreign link",
+			query: "",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WithArgs(nil, "one").
+					WillReturnRows(linkRows(linkRow{ID: 10, UserID: 2, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/links/one/qr"+tt.query, nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+			c.Set("userID", 1)
+
+			handler.GetLinkQR(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedType != "" {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+				assert.True(t, strings.HasPrefix(w.Header().Get("Cache-Control"), "private"))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedirect_PublicQR(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(t *testing.T, etag string) *httptest.ResponseRecorder {
+		handler, mock, db := setupLinkHandler(t)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+			WithArgs(nil, "promo").
+			WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "promo"}))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/promo.svg", nil)
+		if etag != "" {
+			c.Request.Header.Set("If-None-Match", etag)
+		}
+		c.Params = gin.Params{{Key: "short_code", Value: "promo.svg"}}
+
+		handler.Redirect(c)
+		c.Writer.WriteHeaderNow()
+
+		// Картинка не засчитывается как клик
+		assert.NoError(t, mock.ExpectationsWereMet())
+		return w
+	}
+
+	first := request(t, "")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "image/svg+xml", first.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(first.Header().Get("Cache-Control"), "public"))
+
+	etag := first.Header().Get("ETag")
+	second := request(t, etag)
+	assert.Equal(t, http.StatusNotModified, second.Code)
+	assert.Empty(t, second.Body.String())
+}
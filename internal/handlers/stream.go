@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/stream"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamHeartbeat — как часто в тихий поток пишется комментарий, чтобы прокси не закрыли соединение
+	streamHeartbeat = 15 * time.Second
+	// streamRetry — через сколько миллисекунд браузер переподключается к оборвавшемуся потоку
+	streamRetry = 3000
+)
+
+// StreamLinkClicks godoc
+// @Summary Клики по ссылке в реальном времени
+// @Description Server-Sent Events: событие click приходит на каждый переход по ссылке. После переподключения
+// @Description с заголовком Last-Event-ID (или параметром last_event_id) поток продолжается с недавних событий,
+// @Description пропущенных за время разрыва. Каждые 15 секунд тишины отправляется комментарий-пинг
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce text/event-stream
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param Last-Event-ID header string false "Номер последнего полученного события"
+// @Success 200 {object} models.ClickEvent
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/stream [get]
+func (h *LinkHandler) StreamLinkClicks(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+	h.streamClicks(c, link.UserID, link.ID)
+}
+
+// StreamClicks godoc
+// @Summary Клики по всем ссылкам в реальном времени
+// @Description То же, что поток одной ссылки, но по всем ссылкам пользователя
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "Номер последнего полученного события"
+// @Success 200 {object} models.ClickEvent
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/links/stream [get]
+func (h *LinkHandler) StreamClicks(c *gin.Context) {
+	h.streamClicks(c, c.MustGet("userID").(int), 0)
+}
+
+func (h *LinkHandler) streamClicks(c *gin.Context, userID, linkID int) {
+	if h.ClickStream == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Поток кликов недоступен")
+		return
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	afterID, _ := strconv.ParseUint(lastID, 10, 64)
+
+	sub, backlog := h.ClickStream.Subscribe(userID, linkID, afterID)
+	defer sub.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// nginx иначе копит ответ в буфере и события приходят пачками
+	c.Header("X-Accel-Buffering", "no")
+	c.Render(http.StatusOK, sse.Event{Event: "ready", Retry: streamRetry, Data: gin.H{"resumed": len(backlog)}})
+	for _, e := range backlog {
+		renderClick(c, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// Клиент не успевал читать; он переподключится и продолжит с Last-Event-ID
+				return
+			}
+			renderClick(c, e)
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func renderClick(c *gin.Context, e stream.Event) {
+	c.Render(-1, sse.Event{Id: strconv.FormatUint(e.ID, 10), Event: "click", Data: e.Click})
+}
@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"url-short/internal/handlers"
+	"url-short/internal/models"
+	"url-short/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent читает из потока одно событие SSE и возвращает его поля.
+func readEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && key != "" {
+			fields[key] = value
+		}
+	}
+}
+
+func TestStreamClicks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hub := stream.NewHub(10)
+	handler := &handlers.LinkHandler{ClickStream: hub}
+
+	r := gin.New()
+	r.GET("/api/links/stream", func(c *gin.Context) {
+		c.Set("userID", 1)
+		handler.StreamClicks(c)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	missed := hub.Publish(1, 5, models.ClickEvent{ShortCode: "missed"})
+	hub.Publish(1, 5, models.ClickEvent{ShortCode: "resumed"})
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/links/stream", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(missed.ID, 10))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	body := bufio.NewReader(resp.Body)
+	ready := readEvent(t, body)
+	assert.Equal(t, "ready", ready["event"])
+	assert.Equal(t, `{"resumed":1}`, ready["data"])
+
+	resumed := readEvent(t, body)
+	assert.Equal(t, "click", resumed["event"])
+	assert.Contains(t, resumed["data"], `"short_code":"resumed"`)
+
+	hub.Publish(2, 9, models.ClickEvent{ShortCode: "foreign"})
+	live := hub.Publish(1, 6, models.ClickEvent{ShortCode: "live", ClickCount: 3})
+
+	event := readEvent(t, body)
+	assert.Equal(t, strconv.FormatUint(live.ID, 10), event["id"])
+	assert.Contains(t, event["data"], `"short_code":"live"`)
+	assert.Contains(t, event["data"], `"click_count":3`)
+}
+
+func TestStreamClicks_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &handlers.LinkHandler{}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/links/stream", nil)
+	c.Set("userID", 1)
+
+	handler.StreamClicks(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	TagRepo *repositories.TagRepository
+}
+
+// paramID разбирает числовой параметр пути и отвечает 400, если он некорректен.
+func paramID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Некорректный идентификатор")
+		return 0, false
+	}
+	return id, true
+}
+
+// ListTags godoc
+// @Summary Список тегов
+// @Tags tags
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Router /api/tags [get]
+func (h *TagHandler) ListTags(c *gin.Context) {
+	tags, err := h.TagRepo.FindByUserID(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения тегов")
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag godoc
+// @Summary Создать тег
+// @Tags tags
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param input body models.TagRequest true "Имя тега"
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/tags [post]
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apierror.RespondDetails(c, http.StatusBadRequest, apierror.CodeValidationFailed, "Неверные данные",
+			[]models.FieldError{apierror.Field("name", "required", "Обязательное поле")})
+		return
+	}
+
+	tag := &models.Tag{Name: strings.TrimSpace(req.Name)}
+	if err := h.TagRepo.Create(c.MustGet("userID").(int), tag); err != nil {
+		if errors.Is(err, repositories.ErrTagExists) {
+			apierror.Respond(c, http.StatusConflict, apierror.CodeTagExists, "Тег с таким именем уже существует")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка создания тега")
+		return
+	}
+	c.JSON(http.StatusCreated, tag)
+}
+
+// RenameTag godoc
+// @Summary Переименовать тег
+// @Tags tags
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param id path int true "ID тега"
+// @Param input body models.TagRequest true "Новое имя"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /api/tags/{id} [patch]
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		apierror.RespondDetails(c, http.StatusBadRequest, apierror.CodeValidationFailed, "Неверные данные",
+			[]models.FieldError{apierror.Field("name", "required", "Обязательное поле")})
+		return
+	}
+
+	err := h.TagRepo.Rename(c.MustGet("userID").(int), id, strings.TrimSpace(req.Name))
+	switch {
+	case errors.Is(err, repositories.ErrTagNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeTagNotFound, "Тег не найден")
+	case errors.Is(err, repositories.ErrTagExists):
+		apierror.Respond(c, http.StatusConflict, apierror.CodeTagExists, "Тег с таким именем уже существует")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка переименования тега")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// DeleteTag godoc
+// @Summary Удалить тег
+// @Description Тег снимается со всех ссылок, сами ссылки не удаляются
+// @Tags tags
+// @Security ApiKeyAuth
+// @Param id path int true "ID тега"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	err := h.TagRepo.Delete(c.MustGet("userID").(int), id)
+	switch {
+	case errors.Is(err, repositories.ErrTagNotFound):
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeTagNotFound, "Тег не найден")
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления тега")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
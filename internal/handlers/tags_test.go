@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-short/internal/handlers"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		call         func(h *handlers.TagHandler, c *gin.Context)
+		params       gin.Params
+		requestBody  string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Create",
+			call:        (*handlers.TagHandler).CreateTag,
+			requestBody: `{"name": " sale "}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "sale").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `"name":"sale"`,
+		},
+		{
+			name:        "Create duplicate",
+			call:        (*handlers.TagHandler).CreateTag,
+			requestBody: `{"name": "sale"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO tags").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `"code":"tag_exists"`,
+		},
+		{
+			name:         "Create blank",
+			call:         (*handlers.TagHandler).CreateTag,
+			requestBody:  `{"name": "  "}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `"details":[{"field":"name","rule":"required","message":"Обязательное поле"}]`,
+		},
+		{
+			name:        "Rename missing tag",
+			call:        (*handlers.TagHandler).RenameTag,
+			params:      gin.Params{{Key: "id", Value: "9"}},
+			requestBody: `{"name": "new"}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE tags").
+					WithArgs("new", 9, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"code":"tag_not_found"`,
+		},
+		{
+			name:         "Delete with bad id",
+			call:         (*handlers.TagHandler).DeleteTag,
+			params:       gin.Params{{Key: "id", Value: "abc"}},
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Delete",
+			call:   (*handlers.TagHandler).DeleteTag,
+			params: gin.Params{{Key: "id", Value: "3"}},
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM tags").
+					WithArgs(3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			handler := &handlers.TagHandler{TagRepo: repositories.NewTagRepository(db)}
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/tags", strings.NewReader(tt.requestBody))
+			c.Params = tt.params
+			c.Set("userID", 1)
+
+			tt.call(handler, c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
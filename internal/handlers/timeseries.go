@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTimeseriesPoints ограничивает число интервалов в одном ряду.
+const maxTimeseriesPoints = 5000
+
+// timeseriesSteps — длительность интервала каждого шага и диапазон по умолчанию.
+// Неделя и месяц выравниваются по суткам: их ряды строятся из посуточных счетчиков.
+var timeseriesSteps = map[string]struct {
+	align, approx, span time.Duration
+}{
+	models.GranularityMinute: {time.Minute, time.Minute, time.Hour},
+	models.GranularityHour:   {time.Hour, time.Hour, 48 * time.Hour},
+	models.GranularityDay:    {24 * time.Hour, 24 * time.Hour, 30 * 24 * time.Hour},
+	models.GranularityWeek:   {24 * time.Hour, 7 * 24 * time.Hour, 12 * 7 * 24 * time.Hour},
+	models.GranularityMonth:  {24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour},
+}
+
+// parseSeriesTime разбирает время в формате RFC 3339 или дату ГГГГ-ММ-ДД.
+func parseSeriesTime(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseTimeseriesQuery разбирает granularity, dimension, from и to. Границы
+// расширяются до целых интервалов, чтобы крайние интервалы попадали в ряд целиком.
+func parseTimeseriesQuery(c *gin.Context) (models.TimeseriesQuery, bool) {
+	q := models.TimeseriesQuery{
+		Granularity: c.DefaultQuery("granularity", models.GranularityDay),
+		Dimension:   c.DefaultQuery("dimension", models.DimensionTotal),
+	}
+	step, ok := timeseriesSteps[q.Granularity]
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Неизвестный шаг: ожидается minute, hour, day, week или month")
+		return q, false
+	}
+	if !models.IsRollupDimension(q.Dimension) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Неизвестное измерение: ожидается total, country, location, browser, os, device, referrer, tracking, source, short_code, destination или link_version")
+		return q, false
+	}
+
+	q.To = time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		if q.To, ok = parseSeriesTime(value); !ok {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Время to должно быть в формате RFC 3339 или ГГГГ-ММ-ДД")
+			return q, false
+		}
+	}
+	q.From = q.To.Add(-step.span)
+	if value := c.Query("from"); value != "" {
+		if q.From, ok = parseSeriesTime(value); !ok {
+			apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Время from должно быть в формате RFC 3339 или ГГГГ-ММ-ДД")
+			return q, false
+		}
+	}
+
+	q.From = q.From.Truncate(step.align)
+	if end := q.To.Truncate(step.align); end.Before(q.To) {
+		q.To = end.Add(step.align)
+	}
+	if !q.From.Before(q.To) {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Время from должно быть раньше to")
+		return q, false
+	}
+	if q.To.Sub(q.From)/step.approx > maxTimeseriesPoints {
+		apierror.Respond(c, http.StatusBadRequest, apierror.CodeInvalidParameter, "Слишком много интервалов: увеличьте шаг или сократите диапазон")
+		return q, false
+	}
+	return q, true
+}
+
+// GetLinkTimeseries godoc
+// @Summary Клики ссылки по интервалам
+// @Description Число кликов за каждый час, сутки, неделю или месяц, всего или в разрезе страны, геолокации,
+// @Description браузера, ОС, устройства, источника перехода, анонимности клика (tracking), источника клика (source)
+// @Description, кода (short_code), варианта назначения (destination) или версии настроек (link_version). Такие ряды строятся по предагрегированным счетчикам, которые отстают от кликов
+// @Description на несколько секунд; поминутный ряд считается по сырым кликам и ограничен 5000 интервалами
+// @Tags analytics
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Param granularity query string false "minute, hour, day, week или month" default(day)
+// @Param dimension query string false "total, country, location, browser, os, device, referrer, tracking, source, short_code, destination или link_version" default(total)
+// @Param from query string false "Начало диапазона, RFC 3339 или ГГГГ-ММ-ДД (UTC)"
+// @Param to query string false "Конец диапазона, не включается; по умолчанию — сейчас"
+// @Success 200 {object} models.Timeseries
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/stats/timeseries [get]
+func (h *LinkHandler) GetLinkTimeseries(c *gin.Context) {
+	q, ok := parseTimeseriesQuery(c)
+	if !ok {
+		return
+	}
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.AnalyticRepo.Timeseries(link.ID, q)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения статистики")
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
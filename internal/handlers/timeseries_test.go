@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLinkTimeseries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		query        string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "Hourly series is read from rollups with widened bounds",
+			query: "?granularity=hour&from=2026-03-01T10:15:00Z&to=2026-03-01T12:30:00Z",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc"}))
+				mock.ExpectQuery("FROM click_rollups_hourly").
+					WithArgs(1, "total", from, to, "hour").
+					WillReturnRows(sqlmock.NewRows([]string{"bucket", "value", "clicks"}).
+						AddRow(from, "", 5).
+						AddRow(from.Add(time.Hour), "", 3))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"source":"rollup","points":[{"bucket":"2026-03-01T10:00:00Z","clicks":5},{"bucket":"2026-03-01T11:00:00Z","clicks":3}]`,
+		},
+		{
+			name:  "Minute series is read from raw clicks",
+			query: "?granularity=minute&dimension=referrer&from=2026-03-01T10:00:00Z&to=2026-03-01T10:05:00Z",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 1, OriginalURL: "https://example.com", ShortCode: "abc"}))
+				mock.ExpectQuery("FROM click_analytics ca CROSS JOIN LATERAL").
+					WithArgs(1, "referrer", from, from.Add(5*time.Minute), "minute").
+					WillReturnRows(sqlmock.NewRows([]string{"bucket", "value", "clicks"}).AddRow(from, "t.me", 2))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"source":"raw","points":[{"bucket":"2026-03-01T10:00:00Z","value":"t.me","clicks":2}]`,
+		},
+		{
+			name:         "Unknown granularity",
+			query:        "?granularity=second",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown dimension",
+			query:        "?dimension=ip_address",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Too many minute buckets",
+			query:        "?granularity=minute&from=2026-01-01&to=2026-03-01",
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Foreign link",
+			query: "",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM links WHERE").
+					WillReturnRows(linkRows(linkRow{ID: 1, UserID: 2, OriginalURL: "https://example.com", ShortCode: "abc"}))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/links/abc/stats/timeseries"+tt.query, nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "abc"}}
+			c.Set("userID", 1)
+
+			handler.GetLinkTimeseries(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteLink godoc
+// @Summary Удалить ссылку
+// @Description Ссылка перестает открываться, но ее статистика хранится до окончательной очистки, и ссылку можно восстановить. Код остается занятым на время карантина
+// @Tags links
+// @Security ApiKeyAuth
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code} [delete]
+func (h *LinkHandler) DeleteLink(c *gin.Context) {
+	link, ok := h.findOwnLink(c)
+	if !ok {
+		return
+	}
+
+	if err := h.LinkRepo.SoftDelete(link.ID); err != nil {
+		if errors.Is(err, repositories.ErrLinkNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+			return
+		}
+		log.Printf("[ERROR] Ошибка удаления ссылки: %v | Код: %s", err, link.ShortCode)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления ссылки")
+		return
+	}
+
+	log.Printf("[INFO] Ссылка удалена: %s | Пользователь: %d", link.ShortCode, link.UserID)
+	h.emit(link.UserID, models.EventLinkDeleted, toLinkSummary(c, *link))
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeletedLinks godoc
+// @Summary Удаленные ссылки
+// @Description Ссылки, которые еще можно восстановить, недавно удаленные первыми
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.LinkSummary
+// @Router /api/links/trash [get]
+func (h *LinkHandler) ListDeletedLinks(c *gin.Context) {
+	links, err := h.LinkRepo.FindDeleted(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения ссылок")
+		return
+	}
+
+	response := make([]models.LinkSummary, 0, len(links))
+	for _, link := range links {
+		summary := toLinkSummary(c, link)
+		summary.DeletedAt = link.DeletedAt
+		response = append(response, summary)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreLink godoc
+// @Summary Восстановить удаленную ссылку
+// @Tags links
+// @Security ApiKeyAuth
+// @Produce json
+// @Param short_code path string true "Короткий код ссылки"
+// @Param domain query string false "Собственный домен ссылки"
+// @Success 200 {object} models.LinkSummary
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/links/{short_code}/restore [post]
+func (h *LinkHandler) RestoreLink(c *gin.Context) {
+	domainID, ok := h.domainFromQuery(c)
+	if !ok {
+		return
+	}
+
+	link, err := h.LinkRepo.FindDeletedByShortCode(domainID, c.Param("short_code"))
+	if err != nil || link.UserID != c.MustGet("userID").(int) {
+		if err != nil && !errors.Is(err, repositories.ErrLinkNotFound) {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка поиска ссылки")
+			return
+		}
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Удаленная ссылка не найдена")
+		return
+	}
+
+	if err := h.LinkRepo.Restore(link.ID); err != nil {
+		if errors.Is(err, repositories.ErrLinkNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Удаленная ссылка не найдена")
+			return
+		}
+		log.Printf("[ERROR] Ошибка восстановления ссылки: %v | Код: %s", err, link.ShortCode)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка восстановления ссылки")
+		return
+	}
+
+	log.Printf("[INFO] Ссылка восстановлена: %s | Пользователь: %d", link.ShortCode, link.UserID)
+	link.DeletedAt = nil
+	summary := toLinkSummary(c, *link)
+	h.emit(link.UserID, models.EventLinkUpdated, summary)
+	c.JSON(http.StatusOK, summary)
+}
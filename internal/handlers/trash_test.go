@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id (.+) AND deleted_at IS NULL").
+		WithArgs(nil, "one").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+	mock.ExpectExec("UPDATE links SET deleted_at = NOW\\(\\)").
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/links/one", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+	c.Set("userID", 1)
+
+	handler.DeleteLink(c)
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deletedRow := func(userID int) *sqlmock.Rows {
+		values := linkRow{ID: 10, UserID: userID, OriginalURL: "https://example.com/1", ShortCode: "one"}.values()
+		return sqlmock.NewRows(append(append([]string{}, linkColumns...), "deleted_at")).
+			AddRow(append(values, time.Now().Add(-time.Hour))...)
+	}
+
+	tests := []struct {
+		name         string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "Success",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+), deleted_at FROM links WHERE (.+) AND deleted_at IS NOT NULL").
+					WithArgs(nil, "one").
+					WillReturnRows(deletedRow(1))
+				mock.ExpectExec("UPDATE links SET deleted_at = NULL WHERE id = \\$1 AND deleted_at IS NOT NULL").
+					WithArgs(10).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"short_code":"one"`,
+		},
+		{
+			name: "Link of another user",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+), deleted_at FROM links").
+					WillReturnRows(deletedRow(2))
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"error":"Удаленная ссылка не найдена"`,
+		},
+		{
+			name: "Already purged",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+), deleted_at FROM links").
+					WillReturnRows(sqlmock.NewRows(linkColumns))
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `"error":"Удаленная ссылка не найдена"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, db := setupLinkHandler(t)
+			defer db.Close()
+
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/links/one/restore", nil)
+			c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+			c.Set("userID", 1)
+
+			handler.RestoreLink(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"url-short/internal/apierror"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+	"url-short/internal/utils"
+	"url-short/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deliveryLogLimit — сколько последних доставок показывает журнал вебхука.
+const deliveryLogLimit = 100
+
+// EventEmitter принимает события для вебхуков. Emit не должен блокировать запрос.
+type EventEmitter interface {
+	Emit(userID int, event string, data any)
+}
+
+func (h *LinkHandler) emit(userID int, event string, data any) {
+	if h.Events != nil {
+		h.Events.Emit(userID, event, data)
+	}
+}
+
+// NotifyExpired отправляет link.expired для ссылок, срок действия которых истек,
+// пачками по batchSize. Возвращает число ссылок, о которых отправлены события.
+func (h *LinkHandler) NotifyExpired(batchSize int) (int, error) {
+	total := 0
+	for {
+		links, err := h.LinkRepo.ClaimExpired(batchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, link := range links {
+			h.emit(link.UserID, models.EventLinkExpired, linkSummary(link, h.DefaultDomain))
+		}
+		total += len(links)
+		if len(links) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+type WebhookHandler struct {
+	WebhookRepo *repositories.WebhookRepository
+
+	// Dispatcher отправляет повторные доставки сразу; nil — при следующей проверке очереди
+	Dispatcher *webhooks.Dispatcher
+}
+
+// findOwnWebhook ищет вебхук из пути среди вебхуков пользователя.
+func (h *WebhookHandler) findOwnWebhook(c *gin.Context) (*models.Webhook, bool) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	webhook, err := h.WebhookRepo.FindByID(c.MustGet("userID").(int), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWebhookNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeWebhookNotFound, "Вебхук не найден")
+			return nil, false
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения вебхука")
+		return nil, false
+	}
+	return webhook, true
+}
+
+// ListWebhooks godoc
+// @Summary Список вебхуков
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Router /api/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	hooks, err := h.WebhookRepo.FindByUserID(c.MustGet("userID").(int))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения вебхуков")
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateWebhook godoc
+// @Summary Создать вебхук
+// @Description События отправляются POST-запросом с JSON-телом models.WebhookEvent. Заголовок X-Webhook-Signature
+// @Description содержит "sha256=" и HMAC-SHA256 секрета от строки "<X-Webhook-Timestamp>.<тело>".
+// @Description Неудачные доставки (не 2xx) повторяются с растущей задержкой. Секрет показывается только в этом ответе
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param input body models.WebhookRequest true "Адрес и события"
+// @Success 201 {object} models.WebhookCreatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err, "Неверные данные")
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalidField("url", "url", "Адрес вебхука должен начинаться с http:// или https://").respond(c)
+		return
+	}
+
+	secret, err := utils.GenerateRandomCode(32)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка создания вебхука")
+		return
+	}
+
+	webhook := &models.Webhook{
+		UserID:     c.MustGet("userID").(int),
+		URL:        req.URL,
+		Secret:     secret,
+		Events:     req.Events,
+		Milestones: req.Milestones,
+	}
+	if err := h.WebhookRepo.Create(webhook); err != nil {
+		log.Printf("[ERROR] Ошибка создания вебхука: %v", err)
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка создания вебхука")
+		return
+	}
+
+	log.Printf("[INFO] Создан вебхук %d | Пользователь: %d", webhook.ID, webhook.UserID)
+	c.JSON(http.StatusCreated, models.WebhookCreatedResponse{Webhook: *webhook, Secret: secret})
+}
+
+// DeleteWebhook godoc
+// @Summary Удалить вебхук
+// @Description Удаляет вебхук вместе с журналом доставок; неотправленные события отменяются
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Param id path int true "ID вебхука"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.WebhookRepo.Delete(c.MustGet("userID").(int), id); err != nil {
+		if errors.Is(err, repositories.ErrWebhookNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeWebhookNotFound, "Вебхук не найден")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка удаления вебхука")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries godoc
+// @Summary Журнал доставок вебхука
+// @Description Последние 100 доставок, новые первыми: статус, число попыток, код ответа и ошибка
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "ID вебхука"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	webhook, ok := h.findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.WebhookRepo.FindDeliveries(webhook.ID, deliveryLogLimit)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка получения журнала доставок")
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhook godoc
+// @Summary Повторить доставку
+// @Description Ставит в очередь новую доставку с тем же событием и телом. Исходная запись журнала не меняется
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "ID вебхука"
+// @Param delivery_id path int true "ID доставки"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	deliveryID, ok := paramID(c, "delivery_id")
+	if !ok {
+		return
+	}
+	webhook, ok := h.findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.WebhookRepo.Redeliver(webhook.ID, deliveryID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrDeliveryNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.CodeDeliveryNotFound, "Доставка не найдена")
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка повторной доставки")
+		return
+	}
+	if h.Dispatcher != nil {
+		h.Dispatcher.Wake()
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/handlers"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookColumns = []string{"id", "user_id", "url", "secret", "events", "milestones", "created_at"}
+
+// recordedEvent — событие, переданное в EventEmitter.
+type recordedEvent struct {
+	UserID int
+	Event  string
+	Data   any
+}
+
+type eventRecorder struct {
+	events []recordedEvent
+}
+
+func (r *eventRecorder) Emit(userID int, event string, data any) {
+	r.events = append(r.events, recordedEvent{userID, event, data})
+}
+
+func TestCreateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		body         string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			body: `{"url":"https://crm.example.com/hook","events":["click.milestone"],"milestones":[100]}`,
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO webhooks").
+					WithArgs(1, "https://crm.example.com/hook", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()))
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Unknown event",
+			body:         `{"url":"https://crm.example.com/hook","events":["link.visited"]}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Not an HTTP address",
+			body:         `{"url":"ftp://crm.example.com/hook","events":["click"]}`,
+			mockClosure:  func(mock sqlmock.Sqlmock) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			handler := &handlers.WebhookHandler{WebhookRepo: repositories.NewWebhookRepository(db)}
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/webhooks", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("userID", 1)
+
+			handler.CreateWebhook(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Len(t, response["secret"], 32)
+				assert.Equal(t, []any{float64(100)}, response["milestones"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRedeliverWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		mockClosure  func(mock sqlmock.Sqlmock)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM webhooks WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(webhookColumns).
+						AddRow(3, 1, "https://crm.example.com/hook", "s3cret", "{click}", "{}", time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE id = \\$1 AND webhook_id = \\$2").
+					WithArgs(42, 3).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "webhook_id", "event", "payload", "status", "attempts", "response_code", "error",
+						"next_attempt_at", "created_at", "delivered_at",
+					}).AddRow(42, 3, "click", []byte(`{}`), "failed", 8, 500, "ответ 500", nil, time.Now(), nil))
+				mock.ExpectQuery("INSERT INTO webhook_deliveries").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at"}).AddRow(43, time.Now(), time.Now()))
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name: "Webhook of another user",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM webhooks WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(webhookColumns))
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			handler := &handlers.WebhookHandler{WebhookRepo: repositories.NewWebhookRepository(db)}
+			tt.mockClosure(mock)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/api/webhooks/3/deliveries/42/redeliver", nil)
+			c.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "delivery_id", Value: "42"}}
+			c.Set("userID", 1)
+
+			handler.RedeliverWebhook(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteLink_EmitsEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	recorder := &eventRecorder{}
+	handler.Events = recorder
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id (.+) AND deleted_at IS NULL").
+		WithArgs(nil, "one").
+		WillReturnRows(linkRows(linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"}))
+	mock.ExpectExec("UPDATE links SET deleted_at = NOW\\(\\)").
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/links/one", nil)
+	c.Params = gin.Params{{Key: "short_code", Value: "one"}}
+	c.Set("userID", 1)
+
+	handler.DeleteLink(c)
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, 1, recorder.events[0].UserID)
+	assert.Equal(t, models.EventLinkDeleted, recorder.events[0].Event)
+	assert.Equal(t, "one", recorder.events[0].Data.(models.LinkSummary).ShortCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotifyExpired(t *testing.T) {
+	handler, mock, db := setupLinkHandler(t)
+	defer db.Close()
+	handler.DefaultDomain = "sho.rt"
+	recorder := &eventRecorder{}
+	handler.Events = recorder
+
+	expired := time.Now().Add(-time.Minute)
+	mock.ExpectQuery("UPDATE links SET expiry_notified_at = NOW\\(\\) WHERE id IN \\( SELECT id FROM links " +
+		"WHERE expires_at <= NOW\\(\\) AND expiry_notified_at IS NULL AND deleted_at IS NULL").
+		WithArgs(2).
+		WillReturnRows(linkRows(
+			linkRow{ID: 10, UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one", ExpiresAt: &expired},
+			linkRow{ID: 11, UserID: 2, OriginalURL: "https://example.com/2", ShortCode: "two", ExpiresAt: &expired, Domain: "go.brand.com"},
+		))
+	mock.ExpectQuery("UPDATE links SET expiry_notified_at").
+		WithArgs(2).
+		WillReturnRows(linkRows())
+
+	n, err := handler.NotifyExpired(2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, recorder.events, 2)
+	assert.Equal(t, models.EventLinkExpired, recorder.events[0].Event)
+	assert.Equal(t, "sho.rt/one", recorder.events[0].Data.(models.LinkSummary).FullURL)
+	assert.Equal(t, 2, recorder.events[1].UserID)
+	assert.Equal(t, "go.brand.com/two", recorder.events[1].Data.(models.LinkSummary).FullURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
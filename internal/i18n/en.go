@@ -0,0 +1,234 @@
+package i18n
+
+// english — английский каталог. Ключи совпадают с русскими текстами в коде;
+// форматы должны сохранять глаголы подстановки в том же порядке.
+var english = map[string]string{
+	// Общие ошибки запросов и проверки полей
+	"Неверные данные":                    "Invalid request data",
+	"Обязательное поле":                  "This field is required",
+	"Некорректный email":                 "Invalid email address",
+	"Некорректный URL":                   "Invalid URL",
+	"Некорректное имя домена":            "Invalid domain name",
+	"Некорректное значение":              "Invalid value",
+	"Допустимые значения: %s":            "Allowed values: %s",
+	"Минимальная длина — %s символов":    "Must be at least %s characters long",
+	"Максимальная длина — %s символов":   "Must be at most %s characters long",
+	"Нужно не меньше %s элементов":       "Must contain at least %s items",
+	"Допускается не больше %s элементов": "Must contain at most %s items",
+	"Значение должно быть не меньше %s":  "Must be at least %s",
+	"Значение должно быть не больше %s":  "Must be at most %s",
+	"Ожидается значение типа %s":         "Expected a value of type %s",
+	"Некорректный идентификатор":         "Invalid identifier",
+	"Некорректный идентификатор папки":   "Invalid folder identifier",
+	"Ошибка сервера":                     "Internal server error",
+
+	// Авторизация и аккаунт
+	"Требуется заголовок Authorization":                      "Authorization header is required",
+	"Недействительный токен":                                 "Invalid token",
+	"Некорректные данные токена":                             "Invalid token claims",
+	"В токене нет ID пользователя":                           "User ID not found in token",
+	"В токене нет ID сессии":                                 "Session ID not found in token",
+	"Ошибка проверки сессии":                                 "Session check failed",
+	"Сессия истекла или завершена":                           "Session expired or revoked",
+	"Неверные учетные данные":                                "Invalid credentials",
+	"Неверный пароль":                                        "Wrong password",
+	"Пользователь создан":                                    "User created",
+	"Пользователь не найден":                                 "User not found",
+	"Передача не найдена":                                    "Transfer not found",
+	"Ошибка передачи ссылок":                                 "Failed to transfer links",
+	"Ошибка отзыва передачи":                                 "Failed to cancel the transfer",
+	"Ошибка получения передач":                               "Failed to load transfers",
+	"Ошибка поиска передачи":                                 "Failed to find the transfer",
+	"Ошибка отклонения передачи":                             "Failed to decline the transfer",
+	"Аккаунт будет удален, когда получатель примет передачу": "The account will be deleted once the recipient accepts the transfer",
+	"Нельзя передать ссылки самому себе":                     "You cannot transfer links to yourself",
+	"Email уже используется":                                 "Email is already in use",
+	"Имя пользователя уже занято":                            "Username is already taken",
+	"Язык не поддерживается":                                 "Unsupported language",
+	"Укажите имя пользователя, email или язык":               "Specify a username, email or language",
+	"Для смены email нужен текущий пароль":                   "Your current password is required to change email",
+	"Не указан токен":                                        "Token is missing",
+	"Ссылка подтверждения недействительна или устарела":      "The confirmation link is invalid or has expired",
+	"Не удалось отправить письмо подтверждения":              "Failed to send the confirmation email",
+	"Сессия не найдена":                                      "Session not found",
+	"Ошибка при создании пользователя":                       "Failed to create user",
+	"Ошибка генерации токена":                                "Failed to generate token",
+	"Ошибка хеширования":                                     "Failed to hash password",
+	"Ошибка получения профиля":                               "Failed to load profile",
+	"Ошибка обновления профиля":                              "Failed to update profile",
+	"Ошибка подтверждения email":                             "Failed to confirm email",
+	"Ошибка смены пароля":                                    "Failed to change password",
+	"Ошибка получения сессий":                                "Failed to load sessions",
+	"Ошибка завершения сессии":                               "Failed to revoke session",
+	"Ошибка выгрузки аккаунта":                               "Failed to export account",
+	"Ошибка удаления аккаунта":                               "Failed to delete account",
+
+	// Ссылки
+	"Ссылка не найдена":                                                       "Link not found",
+	"Удаленная ссылка не найдена":                                             "Deleted link not found",
+	"Срок действия ссылки истек":                                              "This link has expired",
+	"Ссылка больше не действует":                                              "This link is no longer active",
+	"Дата истечения должна быть в будущем":                                    "Expiration date must be in the future",
+	"Дата активации должна быть в будущем":                                    "Activation date must be in the future",
+	"Дата истечения должна быть позже даты активации":                         "Expiration date must be later than activation date",
+	"Для черновика дата активации задается при планировании":                  "A draft gets its activation date when it is scheduled",
+	"Для запланированной ссылки нужна дата активации в будущем":               "A scheduled link needs an activation date in the future",
+	"Дата активации задается только для состояния scheduled":                  "Activation date can only be set for the scheduled state",
+	"Состояние должно быть draft, scheduled, active, paused или archived":     "State must be draft, scheduled, active, paused or archived",
+	"Состояние ссылки изменилось, повторите запрос":                           "The link state has changed, please retry",
+	"Недопустимый переход: %s → %s":                                           "Invalid transition: %s → %s",
+	"Код должен содержать от 2 до 20 символов: латинские буквы, цифры, _ и -": "Code must be 2 to 20 characters: Latin letters, digits, _ and -",
+	"Код зарезервирован сервисом, выберите другой":                            "This code is reserved, please choose another",
+	"Код содержит недопустимое слово":                                         "This code contains a forbidden word",
+	"Код уже занят":                                 "This code is already taken",
+	"Ошибка проверки кода":                          "Failed to check code",
+	"Ошибка генерации кода":                         "Failed to generate code",
+	"Ошибка поиска ссылки":                          "Failed to look up link",
+	"Ошибка получения ссылки":                       "Failed to load link",
+	"Ошибка получения ссылок":                       "Failed to load links",
+	"Ошибка сохранения ссылки":                      "Failed to save link",
+	"Ошибка удаления ссылки":                        "Failed to delete link",
+	"Ошибка восстановления ссылки":                  "Failed to restore link",
+	"Ошибка перемещения ссылки":                     "Failed to move link",
+	"Ошибка смены состояния ссылки":                 "Failed to change link state",
+	"Ошибка сохранения вариантов":                   "Failed to save destinations",
+	"Ошибка сохранения тегов":                       "Failed to save tags",
+	"Алиас не найден":                               "Alias not found",
+	"Ошибка получения алиасов":                      "Failed to load aliases",
+	"Ошибка добавления алиаса":                      "Failed to add alias",
+	"Ошибка удаления алиаса":                        "Failed to delete alias",
+	"Версия не найдена":                             "Version not found",
+	"Настройки ссылки уже совпадают с этой версией": "Link settings already match this version",
+	"Срок действия в этой версии уже истек":         "The expiration date in this version has already passed",
+	"Ошибка получения истории":                      "Failed to load history",
+
+	// Пакетное создание и выгрузки
+	"Неизвестный режим: ожидается atomic или partial":                 "Unknown mode: expected atomic or partial",
+	"Нет строк для обработки":                                         "No rows to process",
+	"Слишком много строк: максимум %d":                                "Too many rows: at most %d",
+	"Варианты назначения не поддерживаются при пакетном создании":     "Destinations are not supported in bulk creation",
+	"Некорректная дата истечения":                                     "Invalid expiration date",
+	"Не передан файл":                                                 "No file uploaded",
+	"Не удалось прочитать файл":                                       "Failed to read file",
+	"Не удалось прочитать заголовок CSV":                              "Failed to read CSV header",
+	"В CSV нет колонки url":                                           "CSV has no url column",
+	"Ошибка разбора CSV: %v":                                          "CSV parse error: %v",
+	"Ожидается JSON-массив ссылок":                                    "Expected a JSON array of links",
+	"Ошибка сохранения ссылок":                                        "Failed to save links",
+	"Неизвестный формат: ожидается csv или json":                      "Unknown format: expected csv or json",
+	"Неизвестный формат: ожидается csv, ndjson или parquet":           "Unknown format: expected csv, ndjson or parquet",
+	"Файл parquet уже сжат, параметр gzip для него не поддерживается": "Parquet files are already compressed, gzip is not supported for them",
+	"Параметр async должен быть true или false":                       "Parameter async must be true or false",
+	"Параметр gzip должен быть true или false":                        "Parameter gzip must be true or false",
+	"Выгрузка не найдена":                                             "Export not found",
+	"Выгрузка еще не готова":                                          "Export is not ready yet",
+	"Срок хранения выгрузки истек":                                    "Export has expired",
+	"Ошибка создания выгрузки":                                        "Failed to create export",
+	"Ошибка получения выгрузки":                                       "Failed to load export",
+	"Ошибка получения выгрузок":                                       "Failed to load exports",
+	"Ошибка выгрузки кликов":                                          "Failed to export clicks",
+
+	// Статистика
+	"Время from должно быть в формате RFC 3339 или ГГГГ-ММ-ДД": "Time from must be in RFC 3339 or YYYY-MM-DD format",
+	"Время to должно быть в формате RFC 3339 или ГГГГ-ММ-ДД":   "Time to must be in RFC 3339 or YYYY-MM-DD format",
+	"Время from должно быть раньше to":                         "Time from must be earlier than to",
+	"Дата from должна быть в формате ГГГГ-ММ-ДД":               "Date from must be in YYYY-MM-DD format",
+	"Дата to должна быть в формате ГГГГ-ММ-ДД":                 "Date to must be in YYYY-MM-DD format",
+	"Дата from должна быть не позже to":                        "Date from must not be later than to",
+	"Неизвестное измерение: ожидается total, country, location, browser, os, device, referrer, tracking, source, short_code, destination или link_version": "Unknown dimension: expected total, country, location, browser, os, device, referrer, tracking, source, short_code, destination or link_version",
+	"Неизвестный шаг: ожидается minute, hour, day, week или month":                                                                                         "Unknown step: expected minute, hour, day, week or month",
+	"Слишком много интервалов: увеличьте шаг или сократите диапазон":                                                                                       "Too many intervals: increase the step or narrow the range",
+	"Ошибка получения статистики":                                                                                                                          "Failed to load statistics",
+	"Клик не найден":                                      "Click not found",
+	"Ошибка сохранения конверсии":                         "Failed to save conversion",
+	"Поток кликов недоступен":                             "Click stream is unavailable",
+	"Режим должен быть full, respect, consent или пустым": "Mode must be full, respect, consent or empty",
+	"Ошибка сохранения режима аналитики":                  "Failed to save analytics mode",
+
+	// QR-коды
+	"Формат должен быть png или svg":                              "Format must be png or svg",
+	"Параметр logo должен быть true или false":                    "Parameter logo must be true or false",
+	"Логотип для QR-кодов не настроен":                            "QR code logo is not configured",
+	"Ошибка генерации QR-кода":                                    "Failed to generate QR code",
+	"уровень коррекции должен быть L, M, Q или H":                 "error correction level must be L, M, Q or H",
+	"цвет должен быть в формате RRGGBB или RGB":                   "color must be in RRGGBB or RGB format",
+	"размер должен быть от 64 до 2048 пикселей":                   "size must be between 64 and 2048 pixels",
+	"отступ должен быть от 0 до 16 модулей":                       "margin must be between 0 and 16 modules",
+	"размер слишком мал: модули кода не помещаются в изображение": "size is too small: code modules do not fit the image",
+
+	// Теги и папки
+	"Тег не найден":                       "Tag not found",
+	"Тег с таким именем уже существует":   "A tag with this name already exists",
+	"Ошибка получения тегов":              "Failed to load tags",
+	"Ошибка создания тега":                "Failed to create tag",
+	"Ошибка переименования тега":          "Failed to rename tag",
+	"Ошибка удаления тега":                "Failed to delete tag",
+	"Папка не найдена":                    "Folder not found",
+	"Папка с таким именем уже существует": "A folder with this name already exists",
+	"Ошибка получения папок":              "Failed to load folders",
+	"Ошибка создания папки":               "Failed to create folder",
+	"Ошибка переименования папки":         "Failed to rename folder",
+	"Ошибка удаления папки":               "Failed to delete folder",
+	"Ошибка проверки папки":               "Failed to check folder",
+
+	// Домены
+	"Домен не найден":                               "Domain not found",
+	"Домен не найден или не подтвержден":            "Domain not found or not verified",
+	"Домен не обслуживается":                        "This domain is not served",
+	"Домен уже зарегистрирован":                     "Domain is already registered",
+	"Домен уже подтвержден другим пользователем":    "Domain has already been verified by another user",
+	"Это основной домен сервиса":                    "This is the service's main domain",
+	"На домене есть ссылки":                         "The domain still has links",
+	"TXT-запись с токеном подтверждения не найдена": "TXT record with the verification token not found",
+	"Ошибка поиска домена":                          "Failed to look up domain",
+	"Ошибка получения доменов":                      "Failed to load domains",
+	"Ошибка добавления домена":                      "Failed to add domain",
+	"Ошибка подтверждения домена":                   "Failed to verify domain",
+	"Ошибка удаления домена":                        "Failed to delete domain",
+
+	// Вебхуки
+	"Вебхук не найден":    "Webhook not found",
+	"Доставка не найдена": "Delivery not found",
+	"Адрес вебхука должен начинаться с http:// или https://": "Webhook URL must start with http:// or https://",
+	"Ошибка получения вебхука":                               "Failed to load webhook",
+	"Ошибка получения вебхуков":                              "Failed to load webhooks",
+	"Ошибка создания вебхука":                                "Failed to create webhook",
+	"Ошибка удаления вебхука":                                "Failed to delete webhook",
+	"Ошибка получения журнала":                               "Failed to load log",
+	"Ошибка получения журнала доставок":                      "Failed to load delivery log",
+	"Ошибка повторной доставки":                              "Failed to redeliver",
+
+	// Страницы
+	"Удобное сокращение ссылок": "Simple link shortening",
+	"На главную":                "Go to home page",
+	"Ссылка еще не активна":     "This link is not active yet",
+	"Ссылка пока не опубликована. Попробуйте открыть ее позже.": "This link has not been published yet. Please try again later.",
+	"Ссылка приостановлена":                                     "This link is paused",
+	"Владелец временно отключил эту ссылку.":                    "The owner has temporarily disabled this link.",
+	"Куда ведет ссылка":                                         "Where this link goes",
+	"Перейти":                                                   "Continue",
+	"Сократить ссылку":                                          "Shorten a link",
+	"Кастомный код (необязательно)":                             "Custom code (optional)",
+	"Сократить":                                                 "Shorten",
+	"Главная":                                                   "Home",
+	"Статистика":                                                "Statistics",
+	"Статистика переходов":                                      "Click statistics",
+
+	// Письма
+	"Подтверждение email":   "Confirm your email",
+	"Запрос на смену email": "Email change requested",
+	"Здравствуйте, %s!\n\nЧтобы подтвердить новый email, перейдите по ссылке:\n%s\n\n" +
+		"Ссылка действует %d ч. Если вы не меняли email, проигнорируйте это письмо.\n": "Hello, %s!\n\nTo confirm your new email, follow this link:\n%s\n\n" +
+		"The link is valid for %d h. If you did not change your email, ignore this message.\n",
+	"Здравствуйте, %s!\n\nДля вашего аккаунта запрошена смена email на %s. " +
+		"Адрес изменится после подтверждения по ссылке из письма.\n\n" +
+		"Если это были не вы, смените пароль.\n": "Hello, %s!\n\nA change of your account email to %s has been requested. " +
+		"The address will change once it is confirmed via the link in the email.\n\n" +
+		"If this was not you, change your password.\n",
+	"Передача ссылок": "Link transfer",
+	"Здравствуйте, %s!\n\nПользователь %s (%s) удаляет аккаунт и предлагает вам принять его ссылки, " +
+		"собственные домены, теги и папки. Принять или отклонить передачу можно в течение %d дн.:\n%s\n\n" +
+		"Если вы не ждали этого письма, просто проигнорируйте его.\n": "Hello, %s!\n\nUser %s (%s) is deleting their account and offers you their links, " +
+		"custom domains, tags and folders. You can accept or decline the transfer within %d days:\n%s\n\n" +
+		"If you did not expect this message, simply ignore it.\n",
+}
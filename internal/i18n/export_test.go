@@ -0,0 +1,6 @@
+package i18n
+
+// Catalog открывает каталог перевода для тестов.
+func Catalog(locale string) map[string]string {
+	return catalogs[locale]
+}
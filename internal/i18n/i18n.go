@@ -0,0 +1,120 @@
+// Package i18n переводит тексты для пользователей: ошибки API, страницы и письма.
+// Ключ сообщения — его русский текст, поэтому русского каталога нет, а сообщение
+// без перевода показывается по-русски.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Поддерживаемые языки.
+const (
+	Russian = "ru"
+	English = "en"
+
+	// Default — язык, если клиент не указал ни один из поддерживаемых
+	Default = Russian
+)
+
+// ContextKey — ключ контекста gin с языком ответа.
+const ContextKey = "locale"
+
+var catalogs = map[string]map[string]string{
+	English: english,
+}
+
+// Supported сообщает, есть ли перевод на язык locale.
+func Supported(locale string) bool {
+	return locale == Russian || catalogs[locale] != nil
+}
+
+// T переводит сообщение на язык locale; если перевода нет, возвращает ключ.
+func T(locale, key string) string {
+	if s, ok := catalogs[locale][key]; ok {
+		return s
+	}
+	return key
+}
+
+// Tf переводит формат и подставляет в него аргументы.
+func Tf(locale, format string, args ...any) string {
+	return fmt.Sprintf(T(locale, format), args...)
+}
+
+// Error — ошибка, текст которой переводится вместе с аргументами.
+// Error() возвращает русский текст.
+type Error struct {
+	Format string
+	Args   []any
+}
+
+// Errorf создает ошибку с переводимым форматом.
+func Errorf(format string, args ...any) *Error {
+	return &Error{Format: format, Args: args}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+// Localize переводит текст ошибки на язык locale.
+func Localize(locale string, err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return Tf(locale, e.Format, e.Args...)
+	}
+	return T(locale, err.Error())
+}
+
+// Negotiate выбирает язык по заголовку Accept-Language с учетом весов q.
+// При равных весах побеждает язык, указанный раньше.
+func Negotiate(header string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !Supported(base) {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{base, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// FromContext возвращает язык ответа, выбранный middleware.Locale.
+func FromContext(c *gin.Context) string {
+	if locale := c.GetString(ContextKey); locale != "" {
+		return locale
+	}
+	return Default
+}
+
+// FuncMap добавляет в HTML-шаблоны функцию перевода: {{ t .Lang "Текст" }}.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{"t": T}
+}
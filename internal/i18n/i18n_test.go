@@ -0,0 +1,102 @@
+package i18n_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+	"url-short/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", i18n.Russian},
+		{"en-US,en;q=0.9", i18n.English},
+		{"de,en;q=0.5", i18n.English},
+		{"ru;q=0.1,en;q=0.2", i18n.English},
+		{"en;q=0.5,ru;q=0.5", i18n.English},
+		{"en;q=0", i18n.Russian},
+		{"fr,de", i18n.Russian},
+		{"EN-gb", i18n.English},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, i18n.Negotiate(tt.header), tt.header)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	assert.Equal(t, "Link not found", i18n.T(i18n.English, "Ссылка не найдена"))
+	assert.Equal(t, "Ссылка не найдена", i18n.T(i18n.Russian, "Ссылка не найдена"))
+	// Сообщение без перевода показывается по-русски
+	assert.Equal(t, "Нет такого текста", i18n.T(i18n.English, "Нет такого текста"))
+
+	err := i18n.Errorf("Допустимые значения: %s", "a, b")
+	assert.Equal(t, "Допустимые значения: a, b", err.Error())
+	assert.Equal(t, "Allowed values: a, b", i18n.Localize(i18n.English, err))
+}
+
+// Перевод формата должен принимать те же аргументы, что и оригинал.
+func TestCatalogVerbs(t *testing.T) {
+	for _, locale := range []string{i18n.English} {
+		for key := range i18n.Catalog(locale) {
+			assert.Equal(t, verbs(key), verbs(i18n.T(locale, key)), key)
+		}
+	}
+}
+
+var (
+	templateKey     = regexp.MustCompile(`\{\{\s*t\s+\.Lang\s+"([^"]*)"\s*\}\}`)
+	templateIgnored = regexp.MustCompile(`(?s)\{\{.*?\}\}|<!--.*?-->`)
+)
+
+// Весь видимый текст шаблонов проходит через t и имеет английский перевод.
+func TestTemplatesTranslated(t *testing.T) {
+	files, err := filepath.Glob("../../web/templates/*.html")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		for _, m := range templateKey.FindAllStringSubmatch(string(content), -1) {
+			assert.Contains(t, i18n.Catalog(i18n.English), m[1], "%s: нет перевода", file)
+		}
+		rest := templateIgnored.ReplaceAllString(string(content), "")
+		for _, line := range strings.Split(rest, "\n") {
+			assert.False(t, strings.ContainsFunc(line, func(r rune) bool { return unicode.Is(unicode.Cyrillic, r) }),
+				"%s: текст без перевода: %s", file, strings.TrimSpace(line))
+		}
+	}
+}
+
+func verbs(format string) []string {
+	var found []string
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if format[i] == '%' {
+			continue
+		}
+		j := i
+		for j < len(format) && strings.ContainsRune("+-# 0123456789.", rune(format[j])) {
+			j++
+		}
+		if j < len(format) {
+			found = append(found, format[i:j+1])
+		}
+		i = j
+	}
+	return found
+}
@@ -0,0 +1,64 @@
+// Package mail отправляет письма пользователям: подтверждения адреса и уведомления.
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Sender отправляет текстовое письмо на адрес to.
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPSender отправляет письма через SMTP-сервер. Если сервер поддерживает STARTTLS,
+// соединение шифруется; логин и пароль передаются только по зашифрованному соединению.
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPSender возвращает отправителя через host:port; при пустом user письма
+// отправляются без аутентификации.
+func NewSMTPSender(host string, port int, user, password, from string) *SMTPSender {
+	s := &SMTPSender{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if user != "" {
+		s.auth = smtp.PlainAuth("", user, password, host)
+	}
+	return s
+}
+
+func (s *SMTPSender) Send(to, subject, body string) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, FormatMessage(s.from, to, subject, body, time.Now()))
+}
+
+// LogSender записывает письма в лог вместо отправки. Используется, когда SMTP
+// не настроен, чтобы при разработке ссылки из писем были доступны.
+type LogSender struct{}
+
+func (LogSender) Send(to, subject, body string) error {
+	log.Printf("[WARN] SMTP не настроен, письмо не отправлено | Кому: %s | Тема: %s\n%s", to, subject, body)
+	return nil
+}
+
+// FormatMessage собирает письмо в формате RFC 5322 с телом в UTF-8.
+func FormatMessage(from, to, subject, body string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return b.Bytes()
+}
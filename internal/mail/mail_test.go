@@ -0,0 +1,25 @@
+package mail_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/mail"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMessage(t *testing.T) {
+	date := time.Date(2024, 2, 20, 15, 4, 5, 0, time.UTC)
+	msg := string(mail.FormatMessage("noreply@example.com", "user@example.com", "Подтвердите адрес", "Текст письма", date))
+
+	headers, body, ok := strings.Cut(msg, "\r\n\r\n")
+	assert.True(t, ok)
+	assert.Equal(t, "Текст письма", body)
+	assert.Contains(t, headers, "From: noreply@example.com\r\n")
+	assert.Contains(t, headers, "To: user@example.com\r\n")
+	assert.Contains(t, headers, "Subject: =?utf-8?q?")
+	assert.NotContains(t, headers, "Подтвердите")
+	assert.Contains(t, headers, "Date: Tue, 20 Feb 2024 15:04:05 +0000\r\n")
+	assert.Contains(t, headers, "Content-Type: text/plain; charset=utf-8\r\n")
+}
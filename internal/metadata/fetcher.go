@@ -0,0 +1,266 @@
+// Package metadata загружает страницу назначения и извлекает из нее
+// заголовок и теги Open Graph для предпросмотра короткой ссылки.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	DefaultTimeout      = 5 * time.Second
+	DefaultMaxBytes     = 1 << 20
+	DefaultMaxRedirects = 3
+
+	maxTitleLength       = 255
+	maxDescriptionLength = 1000
+	maxSiteNameLength    = 255
+	maxImageURLLength    = 2048
+)
+
+var (
+	ErrForbiddenAddress = errors.New("адрес назначения находится во внутренней сети")
+	ErrUnsupportedURL   = errors.New("поддерживаются только http и https")
+	ErrNotHTML          = errors.New("страница назначения не является HTML")
+)
+
+// Metadata — сведения о странице назначения.
+type Metadata struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher загружает страницы с ограничением времени и размера ответа
+// и не ходит во внутренние сети (защита от SSRF).
+type Fetcher struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+
+	// AllowPrivateNetworks отключает защиту от SSRF. Только для тестов и локальной разработки.
+	AllowPrivateNetworks bool
+
+	once   sync.Once
+	client *http.Client
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Timeout:      DefaultTimeout,
+		MaxBytes:     DefaultMaxBytes,
+		MaxRedirects: DefaultMaxRedirects,
+	}
+}
+
+func (f *Fetcher) httpClient() *http.Client {
+	f.once.Do(f.initClient)
+	return f.client
+}
+
+func (f *Fetcher) initClient() {
+	dialer := &net.Dialer{Timeout: f.Timeout}
+	if !f.AllowPrivateNetworks {
+		// Проверяем уже разрешенный IP в момент соединения, чтобы DNS rebinding
+		// не позволил обойти проверку имени хоста
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !IsPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	f.client = &http.Client{
+		Timeout: f.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   f.Timeout,
+			ResponseHeaderTimeout: f.Timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > f.MaxRedirects {
+				return fmt.Errorf("слишком много перенаправлений")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrUnsupportedURL
+			}
+			return nil
+		},
+	}
+}
+
+// IsPublicIP сообщает, относится ли адрес к публичному интернету.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	// 100.64.0.0/10 (CGNAT) не входит в IsPrivate, но снаружи недоступен
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
+
+// Fetch загружает страницу rawURL и извлекает из нее метаданные.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "url-short-preview/1.0")
+
+	resp, err := f.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("страница вернула статус %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, ErrNotHTML
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.MaxBytes), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := Parse(body)
+	if meta.ImageURL != "" {
+		if img, err := resp.Request.URL.Parse(meta.ImageURL); err == nil {
+			meta.ImageURL = img.String()
+		}
+		// Абсолютный адрес может оказаться длиннее исходного
+		if len(meta.ImageURL) > maxImageURLLength {
+			meta.ImageURL = ""
+		}
+	}
+	return meta, nil
+}
+
+// Parse извлекает <title> и теги Open Graph из HTML. Теги og:* имеют приоритет.
+// Разбор останавливается на <body>, если заголовок уже найден.
+func Parse(r io.Reader) *Metadata {
+	meta := &Metadata{}
+	var title, description string
+
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return finish(meta, title, description)
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(z.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "title" {
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "body":
+				if title != "" || meta.Title != "" {
+					return finish(meta, title, description)
+				}
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				attrs := map[string]string{}
+				for {
+					key, val, more := z.TagAttr()
+					attrs[strings.ToLower(string(key))] = string(val)
+					if !more {
+						break
+					}
+				}
+				key := attrs["property"]
+				if key == "" {
+					key = attrs["name"]
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch strings.ToLower(key) {
+				case "og:title":
+					meta.Title = content
+				case "og:description":
+					meta.Description = content
+				case "og:image":
+					meta.ImageURL = content
+				case "og:site_name":
+					meta.SiteName = content
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+}
+
+func finish(meta *Metadata, title, description string) *Metadata {
+	if meta.Title == "" {
+		meta.Title = title
+	}
+	if meta.Description == "" {
+		meta.Description = description
+	}
+	meta.Title = truncate(meta.Title, maxTitleLength)
+	meta.Description = truncate(meta.Description, maxDescriptionLength)
+	meta.SiteName = truncate(meta.SiteName, maxSiteNameLength)
+	// Обрезанный адрес картинки бесполезен, поэтому слишком длинный отбрасываем
+	if len(meta.ImageURL) > maxImageURLLength {
+		meta.ImageURL = ""
+	}
+	return meta
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
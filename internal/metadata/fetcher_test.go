@@ -0,0 +1,132 @@
+package metadata_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-short/internal/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	page := `<!DOCTYPE html><html><head>
+		<title> Обычный заголовок </title>
+		<meta name="description" content="Описание страницы">
+		<meta property="og:image" content="/img/cover.png">
+		<meta property="og:site_name" content="Example">
+	</head><body><meta property="og:title" content="Не должен попасть"></body></html>`
+
+	meta := metadata.Parse(strings.NewReader(page))
+	assert.Equal(t, "Обычный заголовок", meta.Title)
+	assert.Equal(t, "Описание страницы", meta.Description)
+	assert.Equal(t, "/img/cover.png", meta.ImageURL)
+	assert.Equal(t, "Example", meta.SiteName)
+}
+
+func TestParse_OpenGraphWins(t *testing.T) {
+	page := `<html><head><title>Title</title>
+		<meta property="og:title" content="OG Title">
+		<meta property="og:description" content="OG Description">
+		<meta name="description" content="Plain description">
+	</head></html>`
+
+	meta := metadata.Parse(strings.NewReader(page))
+	assert.Equal(t, "OG Title", meta.Title)
+	assert.Equal(t, "OG Description", meta.Description)
+}
+
+func TestParse_Limits(t *testing.T) {
+	page := `<html><head>
+		<meta property="og:site_name" content="` + strings.Repeat("я", 300) + `">
+		<meta property="og:image" content="/` + strings.Repeat("a", 2048) + `">
+	</head></html>`
+
+	meta := metadata.Parse(strings.NewReader(page))
+	assert.Equal(t, strings.Repeat("я", 255), meta.SiteName)
+	assert.Empty(t, meta.ImageURL)
+}
+
+func TestFetch_DropsLongImageURL(t *testing.T) {
+	// Относительный путь укладывается в лимит, а абсолютный адрес уже нет
+	image := "/" + strings.Repeat("a", 2040)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>T</title><meta property="og:image" content="` + image + `"></head></html>`))
+	}))
+	defer server.Close()
+
+	f := metadata.NewFetcher()
+	f.AllowPrivateNetworks = true
+
+	meta, err := f.Fetch(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, meta.ImageURL)
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Магазин</title><meta property="og:image" content="/cover.png"></head></html>`))
+	}))
+	defer server.Close()
+
+	f := metadata.NewFetcher()
+	f.AllowPrivateNetworks = true
+
+	meta, err := f.Fetch(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "Магазин", meta.Title)
+	assert.Equal(t, server.URL+"/cover.png", meta.ImageURL)
+}
+
+func TestFetch_SizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>` + strings.Repeat(" ", 4096) + `<title>Слишком далеко</title></head></html>`))
+	}))
+	defer server.Close()
+
+	f := metadata.NewFetcher()
+	f.AllowPrivateNetworks = true
+	f.MaxBytes = 1024
+
+	meta, err := f.Fetch(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, meta.Title)
+}
+
+func TestFetch_BlocksPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach the server")
+	}))
+	defer server.Close()
+
+	_, err := metadata.NewFetcher().Fetch(context.Background(), server.URL)
+	assert.True(t, errors.Is(err, metadata.ErrForbiddenAddress), "unexpected error: %v", err)
+}
+
+func TestFetch_RejectsNonHTTP(t *testing.T) {
+	_, err := metadata.NewFetcher().Fetch(context.Background(), "file:///etc/passwd")
+	assert.ErrorIs(t, err, metadata.ErrUnsupportedURL)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"192.168.0.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"::1":             false,
+		"fd00::1":         false,
+		"2a00:1450::1":    true,
+	} {
+		assert.Equal(t, public, metadata.IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -1,17 +1,27 @@
 package middleware
 
 import (
+	"log"
+	"net/http"
+	"url-short/internal/apierror"
 	"url-short/internal/config"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// SessionChecker проверяет, что сессия входа пользователя не отозвана и не истекла.
+type SessionChecker interface {
+	SessionActive(userID int, sessionID string) (bool, error)
+}
+
+// AuthMiddleware проверяет токен и кладет в контекст userID и sessionID.
+// Если sessions задан, токен должен ссылаться на действующую сессию.
+func AuthMiddleware(cfg *config.Config, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Authorization header is required"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenMissing, "Требуется заголовок Authorization")
 			return
 		}
 
@@ -19,23 +29,42 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return []byte(cfg.JWTSecret), nil
 		})
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Недействительный токен")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token claims"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Некорректные данные токена")
 			return
 		}
 
 		userID, ok := claims["user_id"].(float64)
 		if !ok {
-			c.AbortWithStatusJSON(401, gin.H{"error": "User ID not found in token"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "В токене нет ID пользователя")
 			return
 		}
 
+		sessionID, _ := claims["sid"].(string)
+		if sessions != nil {
+			if sessionID == "" {
+				apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "В токене нет ID сессии")
+				return
+			}
+			active, err := sessions.SessionActive(int(userID), sessionID)
+			if err != nil {
+				log.Printf("[ERROR] Ошибка проверки сессии: %v", err)
+				apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Ошибка проверки сессии")
+				return
+			}
+			if !active {
+				apierror.Respond(c, http.StatusUnauthorized, apierror.CodeSessionExpired, "Сессия истекла или завершена")
+				return
+			}
+		}
+
 		c.Set("userID", int(userID))
+		c.Set("sessionID", sessionID)
 		c.Next()
 	}
 }
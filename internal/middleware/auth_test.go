@@ -28,7 +28,7 @@ func TestAuthMiddleware(t *testing.T) {
 		c.Request = httptest.NewRequest("GET", "/", nil)
 		c.Request.Header.Set("Authorization", tokenString)
 
-		middlewareFunc := middleware.AuthMiddleware(cfg)
+		middlewareFunc := middleware.AuthMiddleware(cfg, nil)
 		middlewareFunc(c)
 
 		userID, exists := c.Get("userID")
@@ -42,10 +42,11 @@ func TestAuthMiddleware(t *testing.T) {
 		c, _ := gin.CreateTestContext(w)
 		c.Request = httptest.NewRequest("GET", "/", nil)
 
-		middlewareFunc := middleware.AuthMiddleware(cfg)
+		middlewareFunc := middleware.AuthMiddleware(cfg, nil)
 		middlewareFunc(c)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"token_missing"`)
 		assert.True(t, c.IsAborted())
 	})
 
@@ -55,10 +56,51 @@ func TestAuthMiddleware(t *testing.T) {
 		c.Request = httptest.NewRequest("GET", "/", nil)
 		c.Request.Header.Set("Authorization", "invalid_token")
 
-		middlewareFunc := middleware.AuthMiddleware(cfg)
+		middlewareFunc := middleware.AuthMiddleware(cfg, nil)
 		middlewareFunc(c)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.True(t, c.IsAborted())
 	})
 }
+
+type fakeSessions map[string]bool
+
+func (f fakeSessions) SessionActive(userID int, sessionID string) (bool, error) {
+	return f[sessionID], nil
+}
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "test_secret"}
+	sessions := fakeSessions{"active": true, "revoked": false}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected int
+	}{
+		{name: "Active session", claims: jwt.MapClaims{"user_id": 42, "sid": "active"}, expected: http.StatusOK},
+		{name: "Revoked session", claims: jwt.MapClaims{"user_id": 42, "sid": "revoked"}, expected: http.StatusUnauthorized},
+		{name: "Token without session", claims: jwt.MapClaims{"user_id": 42}, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte(cfg.JWTSecret))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/", nil)
+			c.Request.Header.Set("Authorization", tokenString)
+
+			middleware.AuthMiddleware(cfg, sessions)(c)
+
+			assert.Equal(t, tt.expected, w.Code)
+			assert.Equal(t, tt.expected != http.StatusOK, c.IsAborted())
+			if tt.expected == http.StatusOK {
+				assert.Equal(t, "active", c.GetString("sessionID"))
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log"
+	"url-short/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleFinder возвращает язык, выбранный пользователем; пустая строка — не выбран.
+type LocaleFinder interface {
+	FindLocale(userID int) (string, error)
+}
+
+// Locale выбирает язык ответа по заголовку Accept-Language.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18n.Negotiate(c.GetHeader("Accept-Language")))
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// UserLocale заменяет язык ответа на выбранный пользователем в профиле.
+// Ставится после AuthMiddleware.
+func UserLocale(users LocaleFinder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale, err := users.FindLocale(c.MustGet("userID").(int))
+		if err != nil {
+			log.Printf("[WARN] Не удалось получить язык пользователя: %v", err)
+		} else if i18n.Supported(locale) {
+			setLocale(c, locale)
+		}
+		c.Next()
+	}
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Set(i18n.ContextKey, locale)
+	c.Header("Content-Language", locale)
+}
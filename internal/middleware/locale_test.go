@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-short/internal/apierror"
+	"url-short/internal/i18n"
+	"url-short/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLocales struct {
+	locale string
+	err    error
+}
+
+func (f fakeLocales) FindLocale(userID int) (string, error) {
+	return f.locale, f.err
+}
+
+func TestLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		user           *fakeLocales
+		expected       string
+		expectedBody   string
+	}{
+		{
+			name:         "Default",
+			expected:     i18n.Russian,
+			expectedBody: `"error":"Ссылка не найдена"`,
+		},
+		{
+			name:           "Accept-Language",
+			acceptLanguage: "en-US,en;q=0.9,ru;q=0.8",
+			expected:       i18n.English,
+			expectedBody:   `"error":"Link not found"`,
+		},
+		{
+			name:           "User preference wins",
+			acceptLanguage: "en",
+			user:           &fakeLocales{locale: "ru"},
+			expected:       i18n.Russian,
+			expectedBody:   `"error":"Ссылка не найдена"`,
+		},
+		{
+			name:           "No user preference",
+			acceptLanguage: "en",
+			user:           &fakeLocales{},
+			expected:       i18n.English,
+			expectedBody:   `"error":"Link not found"`,
+		},
+		{
+			name:           "Preference lookup fails",
+			acceptLanguage: "en",
+			user:           &fakeLocales{err: errors.New("db down")},
+			expected:       i18n.English,
+			expectedBody:   `"error":"Link not found"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.Locale())
+			if tt.user != nil {
+				r.Use(func(c *gin.Context) { c.Set("userID", 1) }, middleware.UserLocale(*tt.user))
+			}
+			r.GET("/", func(c *gin.Context) {
+				apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
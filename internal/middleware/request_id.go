@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log"
+	"regexp"
+	"url-short/internal/apierror"
+	"url-short/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader — заголовок с ID запроса во входящих запросах и в ответах.
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID присваивает запросу ID и возвращает его в заголовке ответа. ID от клиента
+// или прокси сохраняется, если он безопасен для логов, иначе генерируется новый.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			var err error
+			if id, err = utils.GenerateRandomCode(16); err != nil {
+				log.Printf("[WARN] Не удалось сгенерировать ID запроса: %v", err)
+				c.Next()
+				return
+			}
+		}
+		c.Set(apierror.RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
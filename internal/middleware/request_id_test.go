@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-short/internal/apierror"
+	"url-short/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Generated", incoming: "", keep: false},
+		{name: "Kept from client", incoming: "edge-1a2b.3c_4d", keep: true},
+		{name: "Unsafe replaced", incoming: "bad id\nwith newline", keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.RequestID())
+			r.GET("/", func(c *gin.Context) {
+				apierror.Respond(c, http.StatusNotFound, apierror.CodeLinkNotFound, "Ссылка не найдена")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+			}
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			assert.NotEmpty(t, id)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, id)
+			} else {
+				assert.NotEqual(t, tt.incoming, id)
+			}
+			assert.Contains(t, w.Body.String(), `"request_id":"`+id+`"`)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// UserProfile — профиль текущего пользователя
+// swagger:model UserProfile
+type UserProfile struct {
+	// example: john_doe
+	Username string `json:"username"`
+	// example: user@example.com
+	Email string `json:"email"`
+
+	// PendingEmail — новый адрес, который еще не подтвержден по ссылке из письма
+	// example: new@example.com
+	PendingEmail string `json:"pending_email,omitempty"`
+
+	// Язык интерфейса, ошибок и писем: ru или en; пусто — по заголовку Accept-Language
+	// example: en
+	Locale string `json:"locale"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UpdateProfileRequest — изменение имени пользователя, email и языка. Незаданные поля не меняются
+// swagger:model UpdateProfileRequest
+type UpdateProfileRequest struct {
+	Username *string `json:"username" binding:"omitempty,min=1,max=50" example:"john_doe"`
+
+	// Новый email начинает действовать после подтверждения по ссылке из письма
+	Email *string `json:"email" binding:"omitempty,email,max=100" example:"new@example.com"`
+
+	// Текущий пароль; обязателен при смене email
+	Password string `json:"password" example:"qwerty123"`
+
+	// Язык: ru или en; пустая строка — определять по заголовку Accept-Language
+	Locale *string `json:"locale" example:"en"`
+}
+
+// ChangePasswordRequest — смена пароля
+// swagger:model ChangePasswordRequest
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required" example:"qwerty123"`
+	NewPassword string `json:"new_password" binding:"required,min=6" example:"s3cret-passw0rd"`
+}
+
+// AccountTransfer — предложение принять ссылки, домены, теги и папки аккаунта,
+// который владелец хочет удалить
+// swagger:model AccountTransfer
+type AccountTransfer struct {
+	// example: 3
+	ID         int `json:"id"`
+	FromUserID int `json:"-"`
+	ToUserID   int `json:"-"`
+
+	// Владелец аккаунта, который передает ссылки
+	// example: john_doe
+	FromUsername string `json:"from_username"`
+	// example: john@example.com
+	FromEmail string `json:"from_email"`
+
+	// Сколько ссылок перейдет к получателю
+	// example: 42
+	LinkCount int `json:"link_count"`
+
+	// До этого момента предложение можно принять
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransferOfferResponse — ответ на удаление аккаунта с передачей ссылок
+// swagger:model TransferOfferResponse
+type TransferOfferResponse struct {
+	Message string `json:"message" example:"Аккаунт будет удален, когда получатель примет передачу"`
+}
+
+// DeleteAccountRequest — подтверждение удаления аккаунта
+// swagger:model DeleteAccountRequest
+type DeleteAccountRequest struct {
+	// Текущий пароль пользователя
+	Password string `json:"password" binding:"required" example:"qwerty123"`
+
+	// Email пользователя, которому предлагается принять ссылки, домены, теги и папки.
+	// Аккаунт удаляется, когда получатель примет передачу. Если не задан, аккаунт
+	// удаляется сразу, а ссылки — вместе с кликами
+	TransferTo string `json:"transfer_to" binding:"omitempty,email" example:"colleague@example.com"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// LinkAlias — дополнительный короткий код ссылки. Переходы по нему ведут туда же,
+// что и основной код, и учитываются в статистике ссылки.
+// swagger:model LinkAlias
+type LinkAlias struct {
+	// example: 4
+	ID int `json:"id"`
+
+	LinkID   int  `json:"-"`
+	DomainID *int `json:"-"`
+
+	// example: spring-sale
+	ShortCode string `json:"short_code"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AliasRequest представляет запрос на добавление алиаса
+// swagger:model AliasRequest
+type AliasRequest struct {
+	// Дополнительный код ссылки
+	// required: true
+	// example: spring-sale
+	ShortCode string `json:"short_code" binding:"required"`
+}
@@ -9,16 +9,63 @@ type AnalyticsResponse struct {
 	// example: 42
 	TotalClicks int `json:"total_clicks"`
 
-	// Список кликов
+	// Последние клики, не больше 100; сводки ниже считаются по всем кликам
 	Clicks []ClickStatistic `json:"clicks"`
+
+	// Клики по источникам: link — обычные переходы, qr — сканирования QR-кода
+	Sources map[string]int `json:"sources"`
+
+	// Клики по кодам: основному и алиасам, в том числе уже удаленным
+	Codes map[string]int `json:"codes"`
+
+	// Действующий режим аналитики ссылки: full, respect или consent
+	// example: respect
+	AnalyticsMode string `json:"analytics_mode"`
+
+	// Клики за все время: full — сохраненные целиком, anonymous — без согласия посетителя
+	Tracking map[string]int `json:"tracking"`
+
+	// Клики в разрезе версий настроек ссылки
+	Versions []VersionStatistic `json:"versions"`
+
+	// Клики в разрезе вариантов A/B-теста
+	Variants []VariantStatistic `json:"variants,omitempty"`
+
+	// Конверсии, если для ссылки включен трекинг
+	Conversions *ConversionReport `json:"conversions,omitempty"`
+}
+
+// VariantStatistic представляет количество кликов по одному варианту назначения
+// swagger:model VariantStatistic
+type VariantStatistic struct {
+	// Идентификатор варианта
+	// example: 3
+	DestinationID int `json:"destination_id"`
+
+	// Адрес назначения
+	// example: https://example.com/landing-a
+	URL string `json:"url"`
+
+	// Вес варианта
+	// example: 50
+	Weight int `json:"weight"`
+
+	// Когда вариант был заменен и выведен из ротации; пусто у текущих вариантов
+	// example: 2024-01-01T12:00:00Z
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+
+	// Количество кликов
+	// example: 21
+	Clicks int `json:"clicks"`
 }
 
 // ClickStatistic представляет данные одного клика для Swagger
 // swagger:model ClickStatistic
 type ClickStatistic struct {
-	// IP-адрес клиента
-	// example: 192.168.1.1
-	IPAddress string `json:"ip_address"`
+	// IP-адрес клиента в том виде, в котором он сохранен (см. IP_MODE);
+	// отдается, только если включен EXPOSE_CLICK_IPS
+	// example: 192.168.1.0
+	IPAddress string `json:"ip_address,omitempty"`
 
 	// Геолокация клиента
 	// example: Moscow, Russia
@@ -36,7 +83,59 @@ type ClickStatistic struct {
 	// example: Chrome 115
 	Browser string `json:"browser"`
 
+	// Источник перехода
+	// example: https://t.me/
+	Referrer string `json:"referrer"`
+
+	// Источник: link или qr
+	// example: qr
+	Source string `json:"source"`
+
+	// Код, по которому был переход: основной или алиас
+	// example: spring-sale
+	ShortCode string `json:"short_code"`
+
 	// Время клика
 	// example: 2024-02-20T15:04:05Z
 	ClickedAt time.Time `json:"clicked_at"`
+
+	// Клик без согласия посетителя: IP-адрес, user agent и геолокация не сохранены
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// AggregatedAnalytics — суммарная статистика по группе ссылок (тегу или папке)
+// swagger:model AggregatedAnalytics
+type AggregatedAnalytics struct {
+	// Количество ссылок в выборке
+	// example: 12
+	LinkCount int `json:"link_count"`
+
+	// Суммарное количество кликов
+	// example: 1500
+	TotalClicks int `json:"total_clicks"`
+
+	// Клики по каждой ссылке
+	Links []LinkClicks `json:"links"`
+
+	// Клики по типам устройств
+	Devices map[string]int `json:"devices"`
+
+	// Клики по браузерам
+	Browsers map[string]int `json:"browsers"`
+
+	// Клики по геолокации
+	Locations map[string]int `json:"locations"`
+
+	// Клики, сохраненные целиком (full) и без согласия посетителя (anonymous)
+	Tracking map[string]int `json:"tracking"`
+}
+
+// LinkClicks — количество кликов по одной ссылке
+// swagger:model LinkClicks
+type LinkClicks struct {
+	// example: a1b2c3
+	ShortCode string `json:"short_code"`
+
+	// example: 120
+	Clicks int `json:"clicks"`
 }
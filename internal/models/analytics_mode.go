@@ -0,0 +1,43 @@
+package models
+
+// Режимы аналитики. Режим ссылки задает владелец, пустой режим означает режим сервера.
+const (
+	// AnalyticsModeFull — клики сохраняются целиком независимо от сигналов браузера
+	AnalyticsModeFull = "full"
+	// AnalyticsModeRespect — заголовки DNT: 1 и Sec-GPC: 1 делают клик анонимным
+	AnalyticsModeRespect = "respect"
+	// AnalyticsModeConsent — клик анонимный, пока посетитель не дал согласие в cookie;
+	// DNT и Sec-GPC тоже учитываются
+	AnalyticsModeConsent = "consent"
+)
+
+// IsAnalyticsMode сообщает, известен ли режим аналитики.
+func IsAnalyticsMode(mode string) bool {
+	return mode == AnalyticsModeFull || mode == AnalyticsModeRespect || mode == AnalyticsModeConsent
+}
+
+// Значения измерения tracking счетчиков кликов.
+const (
+	TrackingFull      = "full"
+	TrackingAnonymous = "anonymous"
+)
+
+// AnalyticsModeRequest задает режим аналитики ссылки
+// swagger:model AnalyticsModeRequest
+type AnalyticsModeRequest struct {
+	// full, respect, consent или пусто — режим сервера
+	// example: consent
+	Mode string `json:"mode" binding:"omitempty,oneof=full respect consent"`
+}
+
+// AnalyticsModeResponse — режим аналитики ссылки
+// swagger:model AnalyticsModeResponse
+type AnalyticsModeResponse struct {
+	// Режим ссылки; пусто — используется режим сервера
+	// example: consent
+	Mode string `json:"mode"`
+
+	// Действующий режим с учетом режима сервера
+	// example: consent
+	Effective string `json:"effective"`
+}
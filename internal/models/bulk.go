@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+const (
+	BulkModeAtomic  = "atomic"
+	BulkModePartial = "partial"
+
+	BulkStatusCreated = "created"
+	BulkStatusFailed  = "failed"
+	BulkStatusSkipped = "skipped"
+)
+
+// BulkLinkResult — результат обработки одной строки пакетного создания
+// swagger:model BulkLinkResult
+type BulkLinkResult struct {
+	// Номер строки, начиная с 1 (для CSV — без учета заголовка)
+	// example: 1
+	Row int `json:"row"`
+
+	// example: https://shop.example.com/item/42
+	OriginalURL string `json:"original_url"`
+
+	// example: a1b2c3
+	ShortCode string `json:"short_code,omitempty"`
+
+	// example: http://localhost:8080/a1b2c3
+	FullURL string `json:"full_url,omitempty"`
+
+	// created, failed или skipped (строка корректна, но не сохранена из-за ошибок в других строках)
+	// example: created
+	Status string `json:"status"`
+
+	// example: Код уже занят
+	Error string `json:"error,omitempty"`
+
+	// Машиночитаемый код ошибки строки, как в ErrorResponse
+	// example: code_taken
+	Code string `json:"code,omitempty"`
+
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// BulkLinkResponse — итог пакетного создания ссылок
+// swagger:model BulkLinkResponse
+type BulkLinkResponse struct {
+	// example: partial
+	Mode string `json:"mode"`
+
+	// example: 998
+	Created int `json:"created"`
+
+	// example: 2
+	Failed int `json:"failed"`
+
+	Results []BulkLinkResult `json:"results"`
+}
+
+// LinkSummary — ссылка пользователя в списке и при экспорте
+// swagger:model LinkSummary
+type LinkSummary struct {
+	ShortCode   string     `json:"short_code" example:"a1b2c3"`
+	Domain      string     `json:"domain,omitempty" example:"go.brand.com"`
+	FullURL     string     `json:"full_url" example:"http://localhost:8080/a1b2c3"`
+	OriginalURL string     `json:"original_url" example:"https://google.com"`
+	ClickCount  int        `json:"click_count" example:"42"`
+	Tags        []string   `json:"tags"`
+	FolderID    *int       `json:"folder_id"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Title       string     `json:"title" example:"Летняя распродажа"`
+	Description string     `json:"description" example:"Скидки до 50% на всё"`
+	Notes       string     `json:"notes" example:"Для рассылки 12 июня"`
+	ImageURL    string     `json:"image_url" example:"https://shop.example.com/cover.png"`
+	SiteName    string     `json:"site_name" example:"Example Shop"`
+	Status      string     `json:"status" example:"active"`
+	StartsAt    *time.Time `json:"starts_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Источники перехода по короткой ссылке.
+const (
+	ClickSourceLink = "link"
+	ClickSourceQR   = "qr"
+)
+
 type ClickAnalytic struct {
 	ID         int       `json:"-" gorm:"primaryKey"`
 	LinkID     int       `json:"link_id"`
@@ -12,4 +18,24 @@ type ClickAnalytic struct {
 	OS         string    `json:"os"`
 	Browser    string    `json:"browser"`
 	ClickedAt  time.Time `json:"clicked_at"`
+
+	// DestinationID — вариант A/B-теста, на который был отправлен посетитель.
+	// nil, если у ссылки нет вариантов.
+	DestinationID *int `json:"destination_id,omitempty"`
+
+	Referrer string `json:"referrer"`
+
+	// Source — откуда пришел посетитель: ClickSourceLink или ClickSourceQR.
+	Source string `json:"source"`
+
+	// ShortCode — код, по которому пришел посетитель: основной код ссылки или алиас.
+	ShortCode string `json:"short_code"`
+
+	// ClickUID — публичный идентификатор клика для трекинга конверсий.
+	// Пустой, если трекинг для ссылки выключен.
+	ClickUID string `json:"-"`
+
+	// Anonymous — посетитель не дал согласия на аналитику: клик засчитан,
+	// но IP-адрес, user agent и геолокация не сохранены.
+	Anonymous bool `json:"anonymous"`
 }
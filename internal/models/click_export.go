@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Форматы выгрузки кликов.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	// ExportFormatParquet — Parquet с группами до 10 000 кликов; сжат по колонкам, без gzip
+	ExportFormatParquet = "parquet"
+)
+
+// Состояния фоновой выгрузки.
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// ClickExportFilter — какие клики выгружать: все ссылки пользователя или одну,
+// за полуинтервал [From, To).
+type ClickExportFilter struct {
+	UserID int
+	LinkID *int
+	From   time.Time
+	To     time.Time
+}
+
+// ClickExportRow — строка выгрузки кликов. IP-адреса в выгрузку не попадают.
+type ClickExportRow struct {
+	ID          int64     `json:"id"`
+	LinkCode    string    `json:"link_code"`
+	ClickedCode string    `json:"clicked_code"`
+	Domain      string    `json:"domain"`
+	Destination string    `json:"destination"`
+	LinkVersion int       `json:"link_version"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	Source      string    `json:"source"`
+	Location    string    `json:"location"`
+	DeviceType  string    `json:"device_type"`
+	OS          string    `json:"os"`
+	Browser     string    `json:"browser"`
+	Referrer    string    `json:"referrer"`
+	UserAgent   string    `json:"user_agent"`
+}
+
+// ExportJob — фоновая выгрузка кликов
+// swagger:model ExportJob
+type ExportJob struct {
+	// example: 12
+	ID int `json:"id"`
+
+	UserID int  `json:"-"`
+	LinkID *int `json:"link_id,omitempty"`
+
+	// example: csv
+	Format string `json:"format"`
+	Gzip   bool   `json:"gzip"`
+
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// pending, running, completed или failed
+	// example: completed
+	Status string `json:"status"`
+
+	// example: 250000
+	Rows int64 `json:"rows"`
+	// example: 10485760
+	SizeBytes int64 `json:"size_bytes"`
+
+	FilePath string `json:"-"`
+
+	Error string `json:"error,omitempty"`
+
+	// Адрес для скачивания готового файла
+	// example: /api/exports/12/download
+	DownloadURL string `json:"download_url,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// Время, после которого файл будет удален
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ConversionRequest — тело запроса, которым сайт назначения сообщает о конверсии.
+type ConversionRequest struct {
+	ClickID string   `json:"click_id" binding:"required" example:"Zk3xQ9aLm2Pq7RtY"`
+	Value   *float64 `json:"value" binding:"omitempty,min=0" example:"19.99"`
+}
+
+type Conversion struct {
+	ID        int       `json:"-"`
+	ClickID   int       `json:"-"`
+	LinkID    int       `json:"-"`
+	Value     float64   `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversionStatistic — конверсии по одному срезу (ссылке, источнику или варианту)
+// swagger:model ConversionStatistic
+type ConversionStatistic struct {
+	// Значение среза (хост источника перехода или direct, адрес варианта); пусто для итогов по ссылке
+	// example: t.me
+	Key string `json:"key,omitempty"`
+
+	// Количество кликов
+	// example: 200
+	Clicks int `json:"clicks"`
+
+	// Количество конверсий
+	// example: 9
+	Conversions int `json:"conversions"`
+
+	// Доля кликов, которые привели хотя бы к одной конверсии
+	// example: 0.045
+	ConversionRate float64 `json:"conversion_rate"`
+
+	// Суммарная выручка
+	// example: 179.91
+	Revenue float64 `json:"revenue"`
+}
+
+// ConversionReport — сводка конверсий по ссылке
+// swagger:model ConversionReport
+type ConversionReport struct {
+	ConversionStatistic
+	ByReferrer []ConversionStatistic `json:"by_referrer"`
+	ByVariant  []ConversionStatistic `json:"by_variant,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Domain — собственный брендированный домен пользователя для коротких ссылок
+// swagger:model Domain
+type Domain struct {
+	// example: 2
+	ID int `json:"id"`
+
+	UserID int `json:"-"`
+
+	// example: go.brand.com
+	Hostname string `json:"hostname"`
+
+	// Токен, который нужно опубликовать в TXT-записи домена
+	// example: 3f9c2a7b1e
+	VerificationToken string `json:"verification_token"`
+
+	// Время подтверждения; null — домен еще не подтвержден
+	VerifiedAt *time.Time `json:"verified_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (d *Domain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// DomainResponse — домен вместе с инструкцией по подтверждению
+// swagger:model DomainResponse
+type DomainResponse struct {
+	Domain
+
+	// Имя TXT-записи для подтверждения
+	// example: _urlshort-verify.go.brand.com
+	TXTName string `json:"txt_name"`
+
+	// Значение TXT-записи
+	// example: urlshort-verification=3f9c2a7b1e
+	TXTValue string `json:"txt_value"`
+}
+
+type DomainRequest struct {
+	Hostname string `json:"hostname" binding:"required,fqdn,max=253" example:"go.brand.com"`
+}
@@ -3,7 +3,41 @@ package models
 // ErrorResponse: Универсальный ответ для ошибок
 // swagger:response ErrorResponse
 type ErrorResponse struct {
-	// Описание ошибки
+	// Описание ошибки для человека; текст может меняться
 	// example: Неверные данные
 	Error string `json:"error"`
+
+	// Машиночитаемый код ошибки; в отличие от текста, не меняется
+	// example: validation_failed
+	Code string `json:"code"`
+
+	// HTTP-статус ответа
+	// example: 400
+	Status int `json:"status"`
+
+	// Ошибки отдельных полей запроса
+	Details []FieldError `json:"details,omitempty"`
+
+	// ID запроса из заголовка X-Request-ID, по нему ошибку можно найти в логах
+	// example: 3f9a1c0e7b2d4e5f
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FieldError — ошибка проверки одного поля запроса
+// swagger:model FieldError
+type FieldError struct {
+	// Путь к полю в JSON
+	// example: destinations[0].url
+	Field string `json:"field"`
+
+	// Нарушенное правило: required, url, email, min, max, oneof, type и другие
+	// example: url
+	Rule string `json:"rule"`
+
+	// Параметр правила, например минимальная длина для min
+	// example: 6
+	Param string `json:"param,omitempty"`
+
+	// example: Некорректный URL
+	Message string `json:"message"`
 }
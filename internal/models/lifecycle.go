@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Состояния ссылки. В базе хранятся draft, scheduled, active, paused и archived;
+// expired вычисляется по expires_at, а scheduled после наступления starts_at
+// считается active — фоновая задача для этого не нужна.
+const (
+	LinkStatusDraft     = "draft"
+	LinkStatusScheduled = "scheduled"
+	LinkStatusActive    = "active"
+	LinkStatusPaused    = "paused"
+	LinkStatusExpired   = "expired"
+	LinkStatusArchived  = "archived"
+)
+
+// linkTransitions — допустимые переходы из текущего (вычисленного) состояния.
+var linkTransitions = map[string][]string{
+	LinkStatusDraft:     {LinkStatusScheduled, LinkStatusActive, LinkStatusArchived},
+	LinkStatusScheduled: {LinkStatusDraft, LinkStatusScheduled, LinkStatusActive, LinkStatusPaused, LinkStatusArchived},
+	LinkStatusActive:    {LinkStatusPaused, LinkStatusArchived},
+	LinkStatusPaused:    {LinkStatusScheduled, LinkStatusActive, LinkStatusArchived},
+	LinkStatusExpired:   {LinkStatusArchived},
+	LinkStatusArchived:  {LinkStatusDraft, LinkStatusPaused},
+}
+
+// CanTransition сообщает, можно ли перевести ссылку из состояния from в to.
+func CanTransition(from, to string) bool {
+	for _, s := range linkTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// State возвращает состояние ссылки на момент now.
+func (l *Link) State(now time.Time) string {
+	switch l.Status {
+	case LinkStatusDraft, LinkStatusPaused, LinkStatusArchived:
+		return l.Status
+	case LinkStatusScheduled:
+		if l.StartsAt != nil && now.Before(*l.StartsAt) {
+			return LinkStatusScheduled
+		}
+	}
+	if l.IsExpired(now) {
+		return LinkStatusExpired
+	}
+	return LinkStatusActive
+}
+
+// LinkStatusRequest переводит ссылку в новое состояние
+// swagger:model LinkStatusRequest
+type LinkStatusRequest struct {
+	// Новое состояние: draft, scheduled, active, paused или archived
+	// required: true
+	// example: scheduled
+	Status string `json:"status" binding:"required,oneof=draft scheduled active paused archived"`
+
+	// Момент активации; обязателен для scheduled и должен быть в будущем
+	// example: 2025-06-01T09:00:00Z
+	StartsAt *time.Time `json:"starts_at"`
+}
+
+// LinkStatusResponse — состояние ссылки после перехода
+// swagger:model LinkStatusResponse
+type LinkStatusResponse struct {
+	// example: scheduled
+	Status string `json:"status"`
+
+	// example: 2025-06-01T09:00:00Z
+	StartsAt *time.Time `json:"starts_at"`
+}
+
+// LinkStatusEvent — запись журнала: кто и когда перевел ссылку в другое состояние
+// swagger:model LinkStatusEvent
+type LinkStatusEvent struct {
+	// example: 12
+	ID int `json:"id"`
+
+	LinkID int `json:"-"`
+
+	// Пользователь, изменивший состояние; null, если он удален
+	// example: 1
+	UserID *int `json:"user_id"`
+
+	// example: draft
+	FromStatus string `json:"from_status"`
+
+	// example: scheduled
+	ToStatus string `json:"to_status"`
+
+	// example: 2025-06-01T09:00:00Z
+	StartsAt *time.Time `json:"starts_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -3,13 +3,69 @@ package models
 import "time"
 
 type CreateLinkRequest struct {
-	OriginalURL string `json:"original_url" binding:"required,url" example:"https://google.com"`
-	CustomCode  string `json:"custom_code" example:"my_custom_code"`
+	OriginalURL  string               `json:"original_url" binding:"required,url" example:"https://google.com"`
+	CustomCode   string               `json:"custom_code" example:"my_custom_code"`
+	Destinations []DestinationRequest `json:"destinations" binding:"omitempty,dive"`
+
+	// Domain — подтвержденный собственный домен; пусто — основной домен сервиса
+	Domain string `json:"domain" example:"go.brand.com"`
+
+	// TrackConversions включает передачу идентификатора клика в адрес назначения
+	TrackConversions bool `json:"track_conversions" example:"false"`
+
+	Tags      []string   `json:"tags" binding:"omitempty,max=20,dive,min=1,max=50" example:"summer,email"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-12-31T23:59:59Z"`
+	FolderID  *int       `json:"folder_id" example:"1"`
+
+	Title       string `json:"title" binding:"max=255" example:"Летняя распродажа"`
+	Description string `json:"description" binding:"max=1000" example:"Скидки до 50% на всё"`
+	Notes       string `json:"notes" binding:"max=2000" example:"Для рассылки 12 июня"`
+
+	// FetchMetadata загружает страницу назначения и заполняет пустые title и description
+	FetchMetadata bool `json:"fetch_metadata" example:"true"`
+
+	// Status — начальное состояние: active (по умолчанию) или draft.
+	// Ссылка с starts_at в будущем создается в состоянии scheduled
+	Status   string     `json:"status" binding:"omitempty,oneof=draft active" example:"active"`
+	StartsAt *time.Time `json:"starts_at" example:"2025-06-01T09:00:00Z"`
+
+	// ReuseExisting возвращает уже существующую ссылку пользователя на тот же адрес
+	// (после нормализации) вместо создания новой. Не действует вместе с custom_code и destinations
+	ReuseExisting bool `json:"reuse_existing" example:"true"`
+}
+
+// UpdateLinkRequest изменяет редактируемые поля ссылки; отсутствующие поля не меняются.
+type UpdateLinkRequest struct {
+	Title       *string `json:"title" binding:"omitempty,max=255" example:"Летняя распродажа"`
+	Description *string `json:"description" binding:"omitempty,max=1000" example:"Скидки до 50% на всё"`
+	Notes       *string `json:"notes" binding:"omitempty,max=2000" example:"Для рассылки 12 июня"`
+
+	// RefreshMetadata заново загружает заголовок и Open Graph со страницы назначения
+	RefreshMetadata bool `json:"refresh_metadata" example:"false"`
+
+	// Настройки перехода; их изменение сохраняет новую версию в истории ссылки
+	OriginalURL      *string               `json:"original_url" binding:"omitempty,url" example:"https://example.com/new"`
+	Destinations     *[]DestinationRequest `json:"destinations" binding:"omitempty,dive"`
+	ExpiresAt        *time.Time            `json:"expires_at" example:"2025-12-31T23:59:59Z"`
+	TrackConversions *bool                 `json:"track_conversions" example:"true"`
+
+	// ClearExpiresAt снимает срок действия ссылки
+	ClearExpiresAt bool `json:"clear_expires_at" example:"false"`
+}
+
+// DestinationRequest описывает один вариант A/B-теста.
+// Вероятность выбора варианта пропорциональна его весу.
+type DestinationRequest struct {
+	URL    string `json:"url" binding:"required,url" example:"https://example.com/landing-a"`
+	Weight int    `json:"weight" binding:"required,min=1" example:"50"`
 }
 
 type LinkResponse struct {
 	ShortCode string `json:"short_code" example:"a1b2c3"`
 	FullURL   string `json:"full_url" example:"http://localhost:8080/a1b2c3"`
+
+	// Reused — вернулась существующая ссылка, новая не создавалась
+	Reused bool `json:"reused" example:"false"`
 }
 
 type Link struct {
@@ -19,4 +75,45 @@ type Link struct {
 	ShortCode   string    `json:"-"`
 	ClickCount  int       `json:"-"`
 	CreatedAt   time.Time `json:"-"`
+
+	TrackConversions bool       `json:"-"`
+	ExpiresAt        *time.Time `json:"-"`
+	Tags             []string   `json:"-"`
+	FolderID         *int       `json:"-"`
+
+	Title       string `json:"-"`
+	Description string `json:"-"`
+	Notes       string `json:"-"`
+	ImageURL    string `json:"-"`
+	SiteName    string `json:"-"`
+
+	// DomainID — собственный домен ссылки; nil — основной домен сервиса
+	DomainID *int   `json:"-"`
+	Domain   string `json:"-"`
+
+	// NormalizedURL — OriginalURL в нормализованном виде, ключ для поиска дубликатов
+	NormalizedURL string `json:"-"`
+
+	// Status — сохраненное состояние (LinkStatus*); итоговое состояние дает State
+	Status   string     `json:"-"`
+	StartsAt *time.Time `json:"-"`
+
+	// AnalyticsMode — режим аналитики ссылки (AnalyticsMode*); пусто — режим сервера
+	AnalyticsMode string `json:"-"`
+
+	// DeletedAt — момент мягкого удаления; заполняется только при выборке удаленных ссылок
+	DeletedAt *time.Time `json:"-"`
+}
+
+// IsExpired сообщает, истек ли срок действия ссылки к моменту now.
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// LinkDestination — вариант назначения ссылки с весом для ротации.
+type LinkDestination struct {
+	ID     int    `json:"id"`
+	LinkID int    `json:"-"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
 }
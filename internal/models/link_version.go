@@ -0,0 +1,88 @@
+package models
+
+import (
+	"reflect"
+	"time"
+)
+
+// LinkSettings — настройки перехода, изменения которых попадают в историю версий.
+// swagger:model LinkSettings
+type LinkSettings struct {
+	// example: https://example.com/landing
+	OriginalURL string `json:"original_url"`
+
+	Destinations []DestinationRequest `json:"destinations"`
+
+	// example: 2025-12-31T23:59:59Z
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// example: false
+	TrackConversions bool `json:"track_conversions"`
+}
+
+// LinkVersion — одна версия настроек ссылки: кто и когда ее создал и что изменилось
+// по сравнению с предыдущей.
+// swagger:model LinkVersion
+type LinkVersion struct {
+	// example: 2
+	Version int `json:"version"`
+
+	// Пользователь, сохранивший версию; null, если он удален
+	// example: 1
+	UserID *int `json:"user_id"`
+
+	Settings LinkSettings `json:"settings"`
+
+	// Изменения относительно предыдущей версии; у первой версии пусто
+	Changes []SettingChange `json:"changes"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SettingChange — старое и новое значение одной настройки.
+// swagger:model SettingChange
+type SettingChange struct {
+	// example: original_url
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// Diff перечисляет настройки, которые отличаются в next.
+func (s LinkSettings) Diff(next LinkSettings) []SettingChange {
+	changes := []SettingChange{}
+	if s.OriginalURL != next.OriginalURL {
+		changes = append(changes, SettingChange{"original_url", s.OriginalURL, next.OriginalURL})
+	}
+	if !reflect.DeepEqual(s.Destinations, next.Destinations) {
+		changes = append(changes, SettingChange{"destinations", s.Destinations, next.Destinations})
+	}
+	if !equalTimes(s.ExpiresAt, next.ExpiresAt) {
+		changes = append(changes, SettingChange{"expires_at", s.ExpiresAt, next.ExpiresAt})
+	}
+	if s.TrackConversions != next.TrackConversions {
+		changes = append(changes, SettingChange{"track_conversions", s.TrackConversions, next.TrackConversions})
+	}
+	return changes
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// VersionStatistic — клики, пришедшиеся на одну версию настроек ссылки.
+// swagger:model VersionStatistic
+type VersionStatistic struct {
+	// example: 2
+	Version int `json:"version"`
+
+	// Адрес назначения в этой версии
+	// example: https://example.com/landing
+	OriginalURL string `json:"original_url"`
+
+	// example: 120
+	Clicks int `json:"clicks"`
+}
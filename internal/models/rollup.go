@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Шаг временного ряда кликов. Ряды с шагом от часа строятся по предагрегированным
+// счетчикам, поминутный — по сырым кликам.
+const (
+	GranularityMinute = "minute"
+	GranularityHour   = "hour"
+	GranularityDay    = "day"
+	GranularityWeek   = "week"
+	GranularityMonth  = "month"
+)
+
+// Измерения счетчиков кликов. DimensionTotal — все клики без разбивки.
+const (
+	DimensionTotal    = "total"
+	DimensionCountry  = "country"
+	DimensionLocation = "location"
+	DimensionBrowser  = "browser"
+	DimensionOS       = "os"
+	DimensionDevice   = "device"
+	DimensionReferrer = "referrer"
+	// DimensionTracking делит клики на сохраненные целиком и анонимные (TrackingFull, TrackingAnonymous)
+	DimensionTracking = "tracking"
+	// DimensionSource — источник клика: ClickSourceLink или ClickSourceQR
+	DimensionSource = "source"
+	// DimensionCode — код, по которому перешли: основной или алиас
+	DimensionCode = "short_code"
+	// DimensionDestination — идентификатор варианта назначения или DestinationNone
+	DimensionDestination = "destination"
+	// DimensionVersion — номер версии настроек ссылки, действовавшей при клике
+	DimensionVersion = "link_version"
+)
+
+// DestinationNone — значение измерения destination для кликов по ссылке без вариантов.
+const DestinationNone = "none"
+
+// IsRollupDimension сообщает, ведутся ли счетчики по измерению.
+func IsRollupDimension(dimension string) bool {
+	switch dimension {
+	case DimensionTotal, DimensionCountry, DimensionLocation, DimensionBrowser,
+		DimensionOS, DimensionDevice, DimensionReferrer, DimensionTracking,
+		DimensionSource, DimensionCode, DimensionDestination, DimensionVersion:
+		return true
+	}
+	return false
+}
+
+// TimeseriesQuery — какой ряд кликов ссылки построить: шаг, измерение и
+// полуинтервал [From, To), выровненный по шагу.
+type TimeseriesQuery struct {
+	Granularity string
+	Dimension   string
+	From        time.Time
+	To          time.Time
+}
+
+// TimeseriesPoint — клики за один интервал ряда
+// swagger:model TimeseriesPoint
+type TimeseriesPoint struct {
+	// Начало интервала
+	// example: 2024-02-20T15:00:00Z
+	Bucket time.Time `json:"bucket"`
+
+	// Значение измерения; пусто для total
+	// example: Chrome
+	Value string `json:"value,omitempty"`
+
+	// example: 42
+	Clicks int64 `json:"clicks"`
+}
+
+// Timeseries — клики ссылки по интервалам
+// swagger:model Timeseries
+type Timeseries struct {
+	// minute, hour, day, week или month
+	// example: hour
+	Granularity string `json:"granularity"`
+
+	// total, country, location, browser, os, device, referrer, tracking, source, short_code, destination или link_version
+	// example: total
+	Dimension string `json:"dimension"`
+
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// Откуда посчитан ряд: rollup — предагрегированные счетчики, raw — сырые клики
+	// example: rollup
+	Source string `json:"source"`
+
+	// Интервалы без кликов не возвращаются
+	Points []TimeseriesPoint `json:"points"`
+}
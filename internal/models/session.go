@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Session — сессия входа пользователя
+// swagger:model Session
+type Session struct {
+	// example: 9hQ2xUuZ1v_0kDfWcX4lT7aR3sYmB8pE
+	ID     string `json:"id"`
+	UserID int    `json:"-"`
+
+	// example: Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15
+	UserAgent string `json:"user_agent"`
+	// example: 203.0.113.7
+	IPAddress string `json:"ip_address"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+
+	// Current — сессия, с которой пришел запрос
+	Current bool `json:"current"`
+}
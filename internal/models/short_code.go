@@ -0,0 +1,37 @@
+package models
+
+import "strings"
+
+// CaseMode — как сервер сравнивает короткие коды. Режим один на весь сервер
+// и соблюдается везде: в уникальном индексе, генерации, проверке и поиске кодов.
+type CaseMode string
+
+const (
+	// CaseSensitive — abC и ABC разные коды.
+	CaseSensitive CaseMode = "sensitive"
+	// CaseInsensitive — abC и ABC один и тот же код; генерируются только строчные коды.
+	CaseInsensitive CaseMode = "insensitive"
+)
+
+func ParseCaseMode(s string) (CaseMode, bool) {
+	switch mode := CaseMode(strings.ToLower(s)); mode {
+	case CaseSensitive, CaseInsensitive:
+		return mode, true
+	}
+	return "", false
+}
+
+// Fold приводит код к виду, в котором коды сравниваются в этом режиме.
+func (m CaseMode) Fold(code string) string {
+	if m == CaseInsensitive {
+		return strings.ToLower(code)
+	}
+	return code
+}
+
+// CaseConflict — коды одного домена, совпадающие без учета регистра.
+// Мешают включить режим CaseInsensitive.
+type CaseConflict struct {
+	Domain string
+	Codes  []string
+}
@@ -0,0 +1,53 @@
+package models
+
+// Tag — метка пользователя; одна ссылка может иметь несколько тегов
+// swagger:model Tag
+type Tag struct {
+	// example: 3
+	ID int `json:"id"`
+
+	// example: summer-sale
+	Name string `json:"name"`
+
+	// Количество ссылок с этим тегом
+	// example: 12
+	LinkCount int `json:"link_count"`
+}
+
+// Folder — папка пользователя; ссылка лежит не более чем в одной папке
+// swagger:model Folder
+type Folder struct {
+	// example: 1
+	ID int `json:"id"`
+
+	// example: Q3 кампании
+	Name string `json:"name"`
+
+	// Количество ссылок в папке
+	// example: 40
+	LinkCount int `json:"link_count"`
+}
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required,max=50" example:"summer-sale"`
+}
+
+type FolderRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"Q3 кампании"`
+}
+
+// LinkTagsRequest полностью заменяет набор тегов ссылки.
+type LinkTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=20,dive,min=1,max=50" example:"summer,email"`
+}
+
+// LinkFolderRequest перемещает ссылку в папку; null убирает ее из папки.
+type LinkFolderRequest struct {
+	FolderID *int `json:"folder_id" example:"1"`
+}
+
+// LinkFilter — условия отбора ссылок пользователя. Пустые поля не фильтруют.
+type LinkFilter struct {
+	Tag      string
+	FolderID *int
+}
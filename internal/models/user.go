@@ -9,4 +9,21 @@ type User struct {
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"-"`
+
+	// PendingEmail — новый адрес, ожидающий подтверждения; пусто, если смены нет
+	PendingEmail string `json:"-"`
+
+	// Locale — выбранный язык; пусто, если язык берется из Accept-Language
+	Locale string `json:"-"`
+}
+
+// Profile возвращает профиль пользователя для ответа API.
+func (u *User) Profile() UserProfile {
+	return UserProfile{
+		Username:     u.Username,
+		Email:        u.Email,
+		PendingEmail: u.PendingEmail,
+		Locale:       u.Locale,
+		CreatedAt:    u.CreatedAt,
+	}
 }
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// События вебхуков.
+const (
+	EventLinkCreated    = "link.created"
+	EventLinkUpdated    = "link.updated"
+	EventLinkDeleted    = "link.deleted"
+	EventLinkExpired    = "link.expired"
+	EventClick          = "click"
+	EventClickMilestone = "click.milestone"
+)
+
+// Состояния доставки вебхука.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook — адрес, на который отправляются события пользователя
+// swagger:model Webhook
+type Webhook struct {
+	// example: 3
+	ID int `json:"id"`
+
+	UserID int `json:"-"`
+
+	// example: https://crm.example.com/hooks/shortener
+	URL string `json:"url"`
+
+	// Секрет подписи показывается только при создании
+	Secret string `json:"-"`
+
+	// example: ["link.created","click"]
+	Events []string `json:"events"`
+
+	// Число кликов, при достижении которого отправляется click.milestone
+	// example: [100,1000]
+	Milestones []int64 `json:"milestones"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribed сообщает, подписан ли вебхук на событие.
+func (w *Webhook) Subscribed(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMilestone сообщает, отмечен ли у вебхука порог кликов clicks.
+func (w *Webhook) HasMilestone(clicks int) bool {
+	for _, m := range w.Milestones {
+		if m == int64(clicks) {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048" example:"https://crm.example.com/hooks/shortener"`
+	Events     []string `json:"events" binding:"required,min=1,dive,oneof=link.created link.updated link.deleted link.expired click click.milestone" example:"link.created,click"`
+	Milestones []int64  `json:"milestones" binding:"omitempty,max=20,dive,min=1" example:"100,1000"`
+}
+
+// WebhookCreatedResponse — созданный вебхук вместе с секретом подписи
+// swagger:model WebhookCreatedResponse
+type WebhookCreatedResponse struct {
+	Webhook
+
+	// Секрет для проверки заголовка X-Webhook-Signature; больше не показывается
+	// example: 9f86d081884c7d659a2feaa0c55ad015
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery — одна отправка события на адрес вебхука
+// swagger:model WebhookDelivery
+type WebhookDelivery struct {
+	// example: 42
+	ID int `json:"id"`
+
+	// example: 3
+	WebhookID int `json:"webhook_id"`
+
+	// example: click
+	Event string `json:"event"`
+
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+
+	// pending, succeeded или failed
+	// example: succeeded
+	Status string `json:"status"`
+
+	// example: 1
+	Attempts int `json:"attempts"`
+
+	// HTTP-статус последнего ответа
+	// example: 200
+	ResponseCode *int `json:"response_code"`
+
+	// Ошибка последней попытки
+	Error string `json:"error"`
+
+	// Время следующей попытки для доставки в состоянии pending
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// WebhookEvent — тело запроса, которое получает вебхук
+// swagger:model WebhookEvent
+type WebhookEvent struct {
+	// example: click
+	Event string `json:"event"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	Data any `json:"data"`
+}
+
+// ClickEvent — данные события click и click.milestone
+// swagger:model ClickEvent
+type ClickEvent struct {
+	// Основной код ссылки
+	// example: a1b2c3
+	ShortCode string `json:"short_code"`
+
+	// Код, по которому пришел посетитель: основной или алиас
+	// example: spring-sale
+	ClickedCode string `json:"clicked_code"`
+
+	// example: go.brand.com
+	Domain string `json:"domain,omitempty"`
+
+	// example: https://example.com/landing
+	Destination string `json:"destination"`
+
+	// Число кликов по ссылке с учетом этого
+	// example: 100
+	ClickCount int `json:"click_count"`
+
+	// example: link
+	Source     string `json:"source"`
+	DeviceType string `json:"device_type"`
+	OS         string `json:"os"`
+	Browser    string `json:"browser"`
+	Location   string `json:"location"`
+	Referrer   string `json:"referrer"`
+
+	ClickedAt time.Time `json:"clicked_at"`
+}
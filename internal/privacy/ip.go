@@ -0,0 +1,76 @@
+// Package privacy обезличивает персональные данные кликов перед сохранением.
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+)
+
+// Режимы хранения IP-адресов кликов.
+const (
+	// IPModeFull — адрес хранится целиком
+	IPModeFull = "full"
+	// IPModeTruncate — у IPv4 обнуляется последний октет, у IPv6 остается префикс /48
+	IPModeTruncate = "truncate"
+	// IPModeHash — вместо адреса хранится HMAC-SHA256 с секретным ключом: одинаковые
+	// адреса дают одинаковый хеш, но восстановить адрес без ключа нельзя
+	IPModeHash = "hash"
+)
+
+// hashPrefix отличает хеши от адресов в колонке ip_address.
+const hashPrefix = "h:"
+
+var (
+	ErrUnknownIPMode = errors.New("неизвестный режим хранения IP-адресов")
+	ErrMissingIPKey  = errors.New("для режима hash нужен ключ IP_HASH_KEY")
+)
+
+// IPAnonymizer приводит IP-адрес клика к виду, в котором он хранится.
+type IPAnonymizer struct {
+	Mode string
+	key  []byte
+}
+
+// NewIPAnonymizer проверяет режим; key обязателен только для IPModeHash.
+func NewIPAnonymizer(mode, key string) (*IPAnonymizer, error) {
+	switch mode {
+	case IPModeFull, IPModeTruncate:
+	case IPModeHash:
+		if key == "" {
+			return nil, ErrMissingIPKey
+		}
+	default:
+		return nil, ErrUnknownIPMode
+	}
+	return &IPAnonymizer{Mode: mode, key: []byte(key)}, nil
+}
+
+var (
+	ipv4Mask = net.CIDRMask(24, 32)
+	ipv6Mask = net.CIDRMask(48, 128)
+)
+
+// Anonymize возвращает адрес для сохранения. Строка, не являющаяся IP-адресом,
+// в режимах truncate и hash превращается в пустую.
+func (a *IPAnonymizer) Anonymize(ip string) string {
+	if a.Mode == IPModeFull {
+		return ip
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if a.Mode == IPModeHash {
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte(parsed.String()))
+		return hashPrefix + hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(ipv4Mask).String()
+	}
+	return parsed.Mask(ipv6Mask).String()
+}
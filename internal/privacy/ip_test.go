@@ -0,0 +1,40 @@
+package privacy_test
+
+import (
+	"testing"
+	"url-short/internal/privacy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAnonymizer(t *testing.T) {
+	tests := []struct {
+		mode     string
+		input    string
+		expected string
+	}{
+		{mode: privacy.IPModeFull, input: "203.0.113.7", expected: "203.0.113.7"},
+		{mode: privacy.IPModeTruncate, input: "203.0.113.7", expected: "203.0.113.0"},
+		{mode: privacy.IPModeTruncate, input: "::ffff:203.0.113.7", expected: "203.0.113.0"},
+		{mode: privacy.IPModeTruncate, input: "2001:db8:abcd:12:1:2:3:4", expected: "2001:db8:abcd::"},
+		{mode: privacy.IPModeTruncate, input: "not-an-ip", expected: ""},
+		{mode: privacy.IPModeHash, input: "203.0.113.7", expected: "h:d1eda5f85436ad2d5e25828b7bc77ca2"},
+		{mode: privacy.IPModeHash, input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.input, func(t *testing.T) {
+			a, err := privacy.NewIPAnonymizer(tt.mode, "secret")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, a.Anonymize(tt.input))
+		})
+	}
+}
+
+func TestNewIPAnonymizer(t *testing.T) {
+	_, err := privacy.NewIPAnonymizer("mask", "")
+	assert.ErrorIs(t, err, privacy.ErrUnknownIPMode)
+
+	_, err = privacy.NewIPAnonymizer(privacy.IPModeHash, "")
+	assert.ErrorIs(t, err, privacy.ErrMissingIPKey)
+}
@@ -0,0 +1,295 @@
+// Package qr рисует QR-коды коротких ссылок в PNG и SVG с настраиваемыми
+// размером, уровнем коррекции ошибок, отступом, цветами и логотипом в центре.
+package qr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "image/jpeg"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultMargin = 4
+	MaxMargin     = 16
+
+	// logoRatio — доля стороны кода, которую занимает логотип.
+	// При уровне коррекции H код читается, даже если закрыто около 30% площади.
+	logoRatio = 0.22
+)
+
+var (
+	ErrInvalidLevel  = errors.New("уровень коррекции должен быть L, M, Q или H")
+	ErrInvalidColor  = errors.New("цвет должен быть в формате RRGGBB или RGB")
+	ErrInvalidSize   = fmt.Errorf("размер должен быть от %d до %d пикселей", MinSize, MaxSize)
+	ErrInvalidMargin = fmt.Errorf("отступ должен быть от 0 до %d модулей", MaxMargin)
+	ErrTooSmall      = errors.New("размер слишком мал: модули кода не помещаются в изображение")
+)
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options — параметры отрисовки QR-кода.
+type Options struct {
+	// Size — сторона изображения в пикселях
+	Size int
+	// Level — уровень коррекции ошибок: L, M, Q или H
+	Level string
+	// Margin — ширина белой рамки в модулях
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+	// Logo, если задан, рисуется в центре кода; уровень коррекции при этом повышается до H
+	Logo image.Image
+}
+
+// DefaultOptions возвращает черный код на белом фоне без логотипа.
+func DefaultOptions() Options {
+	return Options{
+		Size:       DefaultSize,
+		Level:      "M",
+		Margin:     DefaultMargin,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Validate проверяет, что параметры в допустимых пределах.
+func (o Options) Validate() error {
+	if _, ok := levels[o.Level]; !ok {
+		return ErrInvalidLevel
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return ErrInvalidMargin
+	}
+	return nil
+}
+
+// ParseColor разбирает цвет в шестнадцатеричной записи: "1a2b3c", "#1a2b3c" или "abc".
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// LoadLogo читает логотип в формате PNG или JPEG.
+func LoadLogo(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	logo, _, err := image.Decode(f)
+	return logo, err
+}
+
+// ETag возвращает тег для кеширования: одинаковое содержимое и параметры дают одинаковый код.
+// Логотип задается на весь сервер, поэтому в тег входит только факт его наличия.
+func ETag(content, format string, o Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%d|%s|%d|%x|%x|%t",
+		content, format, o.Size, o.effectiveLevel(), o.Margin,
+		[]byte{o.Foreground.R, o.Foreground.G, o.Foreground.B},
+		[]byte{o.Background.R, o.Background.G, o.Background.B},
+		o.Logo != nil,
+	)
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+func (o Options) effectiveLevel() string {
+	if o.Logo != nil {
+		return "H"
+	}
+	return o.Level
+}
+
+// matrix кодирует содержимое и вычисляет размер модуля в пикселях и смещение,
+// чтобы вписать код с рамкой в изображение Size×Size.
+func matrix(content string, o Options) (bits [][]bool, scale, offset int, err error) {
+	code, err := qrcode.New(content, levels[o.effectiveLevel()])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	code.DisableBorder = true
+	bits = code.Bitmap()
+
+	total := len(bits) + 2*o.Margin
+	scale = o.Size / total
+	if scale < 1 {
+		return nil, 0, 0, ErrTooSmall
+	}
+	offset = (o.Size-total*scale)/2 + o.Margin*scale
+	return bits, scale, offset, nil
+}
+
+// logoBox возвращает квадрат в центре изображения, отведенный под логотип.
+func logoBox(o Options) image.Rectangle {
+	side := int(float64(o.Size) * logoRatio)
+	start := (o.Size - side) / 2
+	return image.Rect(start, start, start+side, start+side)
+}
+
+// WritePNG рисует QR-код в формате PNG.
+func WritePNG(w io.Writer, content string, o Options) error {
+	bits, scale, offset, err := matrix(content, o)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, o.Size, o.Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(o.Background), image.Point{}, draw.Src)
+
+	fg := image.NewUniform(o.Foreground)
+	for y, row := range bits {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			r := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+			draw.Draw(img, r, fg, image.Point{}, draw.Src)
+		}
+	}
+
+	if o.Logo != nil {
+		box := logoBox(o)
+		draw.Draw(img, box, image.NewUniform(o.Background), image.Point{}, draw.Src)
+		pad := box.Dx() / 10
+		drawScaled(img, box.Inset(pad), o.Logo)
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawScaled вписывает изображение в прямоугольник с сохранением пропорций
+// (ближайший сосед — для логотипа такого качества достаточно).
+func drawScaled(dst draw.Image, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() || r.Empty() {
+		return
+	}
+	w, h := r.Dx(), r.Dy()
+	if sb.Dx()*h > sb.Dy()*w {
+		h = sb.Dy() * w / sb.Dx()
+	} else {
+		w = sb.Dx() * h / sb.Dy()
+	}
+	x0 := r.Min.X + (r.Dx()-w)/2
+	y0 := r.Min.Y + (r.Dy()-h)/2
+
+	for y := 0; y < h; y++ {
+		sy := sb.Min.Y + y*sb.Dy()/h
+		for x := 0; x < w; x++ {
+			sx := sb.Min.X + x*sb.Dx()/w
+			c := color.RGBAModel.Convert(src.At(sx, sy)).(color.RGBA)
+			if c.A == 0 {
+				continue
+			}
+			if c.A < 0xff {
+				bg := color.RGBAModel.Convert(dst.At(x0+x, y0+y)).(color.RGBA)
+				c = blend(c, bg)
+			}
+			dst.Set(x0+x, y0+y, c)
+		}
+	}
+}
+
+// blend накладывает полупрозрачный цвет (с предумноженной альфой) на непрозрачный фон.
+func blend(c, bg color.RGBA) color.RGBA {
+	inv := 0xff - uint32(c.A)
+	return color.RGBA{
+		R: uint8(uint32(c.R) + uint32(bg.R)*inv/0xff),
+		G: uint8(uint32(c.G) + uint32(bg.G)*inv/0xff),
+		B: uint8(uint32(c.B) + uint32(bg.B)*inv/0xff),
+		A: 0xff,
+	}
+}
+
+// WriteSVG рисует QR-код в формате SVG. Темные модули собираются в один path,
+// логотип встраивается как PNG в data URI.
+func WriteSVG(w io.Writer, content string, o Options) error {
+	bits, scale, offset, err := matrix(content, o)
+	if err != nil {
+		return err
+	}
+
+	var path strings.Builder
+	for y, row := range bits {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			// Соседние темные модули в строке объединяются в один прямоугольник
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv%dh-%dz",
+				offset+start*scale, offset+y*scale, (x-start+1)*scale, scale, (x-start+1)*scale)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		o.Size, o.Size, o.Size, o.Size)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(o.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="%s"/>`, hexColor(o.Foreground), path.String())
+
+	if o.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, o.Logo); err != nil {
+			return err
+		}
+		box := logoBox(o)
+		pad := box.Dx() / 10
+		inner := box.Inset(pad)
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			box.Min.X, box.Min.Y, box.Dx(), box.Dy(), hexColor(o.Background))
+		fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`,
+			inner.Min.X, inner.Min.Y, inner.Dx(), inner.Dy(), base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+	buf.WriteString(`</svg>`)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,130 @@
+package qr_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"url-short/internal/qr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const content = "https://sho.rt/abc123?src=qr"
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected color.RGBA
+		wantErr  bool
+	}{
+		{input: "ff8000", expected: color.RGBA{R: 0xff, G: 0x80, A: 0xff}},
+		{input: "#0A0B0C", expected: color.RGBA{R: 0x0a, G: 0x0b, B: 0x0c, A: 0xff}},
+		{input: "fff", expected: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{input: "red", wantErr: true},
+		{input: "12345", wantErr: true},
+		{input: "gggggg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			c, err := qr.ParseColor(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, qr.ErrInvalidColor)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, c)
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	opts := qr.DefaultOptions()
+	assert.NoError(t, opts.Validate())
+
+	opts.Level = "X"
+	assert.ErrorIs(t, opts.Validate(), qr.ErrInvalidLevel)
+
+	opts = qr.DefaultOptions()
+	opts.Size = 10000
+	assert.ErrorIs(t, opts.Validate(), qr.ErrInvalidSize)
+
+	opts = qr.DefaultOptions()
+	opts.Margin = -1
+	assert.ErrorIs(t, opts.Validate(), qr.ErrInvalidMargin)
+}
+
+func TestWritePNG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Size = 300
+	opts.Background = color.RGBA{R: 0xff, G: 0xee, B: 0xdd, A: 0xff}
+
+	var buf bytes.Buffer
+	require.NoError(t, qr.WritePNG(&buf, content, opts))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 300), img.Bounds())
+
+	// Угол изображения — это рамка, она закрашена фоном
+	assert.Equal(t, opts.Background, color.RGBAModel.Convert(img.At(0, 0)))
+}
+
+func TestWritePNG_WithLogo(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0xff
+	}
+	logo.Set(5, 5, color.RGBA{R: 0xff, A: 0xff})
+
+	opts := qr.DefaultOptions()
+	opts.Logo = logo
+
+	var buf bytes.Buffer
+	require.NoError(t, qr.WritePNG(&buf, content, opts))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	// Центр кода занят логотипом
+	center := color.RGBAModel.Convert(img.At(opts.Size/2, opts.Size/2)).(color.RGBA)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, center)
+}
+
+func TestWritePNG_TooSmall(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Size = qr.MinSize
+	opts.Margin = qr.MaxMargin
+
+	err := qr.WritePNG(&bytes.Buffer{}, content+strings.Repeat("x", 200), opts)
+	assert.ErrorIs(t, err, qr.ErrTooSmall)
+}
+
+func TestWriteSVG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Foreground = color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+
+	var buf bytes.Buffer
+	require.NoError(t, qr.WriteSVG(&buf, content, opts))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`))
+	assert.Contains(t, svg, `<path fill="#123456" d="M`)
+	assert.NotContains(t, svg, "<image")
+	assert.True(t, strings.HasSuffix(svg, "</svg>"))
+}
+
+func TestETag(t *testing.T) {
+	opts := qr.DefaultOptions()
+	base := qr.ETag(content, qr.FormatPNG, opts)
+
+	assert.Equal(t, base, qr.ETag(content, qr.FormatPNG, opts))
+	assert.NotEqual(t, base, qr.ETag(content, qr.FormatSVG, opts))
+	assert.NotEqual(t, base, qr.ETag("https://sho.rt/other?src=qr", qr.FormatPNG, opts))
+
+	opts.Margin = 2
+	assert.NotEqual(t, base, qr.ETag(content, qr.FormatPNG, opts))
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import "time"
+
+// DeleteAccount удаляет пользователя. Если transferTo задан, ссылки пользователя
+// вместе с кликами, собственными доменами, тегами и папками переходят к этому
+// пользователю; теги и папки с совпадающими именами объединяются. Иначе ссылки
+// удаляются, а их коды на основном домене уходят в карантин на quarantine.
+// Возвращает пути к файлам выгрузок пользователя, которые нужно удалить с диска.
+func (r *UserRepository) DeleteAccount(userID int, transferTo *int, quarantine time.Duration) ([]string, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if transferTo != nil {
+		err = transferLinks(tx, userID, *transferTo)
+	} else {
+		err = deleteLinks(tx, userID, quarantine)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("DELETE FROM export_jobs WHERE user_id = $1 RETURNING file_path", userID)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Вебхуки, оставшиеся теги, папки и домены удаляются каскадом
+	res, err := tx.Exec("DELETE FROM users WHERE id = $1", userID)
+	if err := requireAffected(res, err, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// transferLinks передает ссылки, домены, теги и папки пользователя from пользователю to.
+// Из двух заявок на одно имя домена остается подтвержденная; если не подтверждена
+// ни одна, остается заявка получателя.
+func transferLinks(tx DBTX, from, to int) error {
+	queries := []string{
+		`DELETE FROM domains src USING domains dst 
+        WHERE src.user_id = $1 AND dst.user_id = $2 AND dst.hostname = src.hostname AND src.verified_at IS NULL`,
+		`DELETE FROM domains dst USING domains src 
+        WHERE src.user_id = $1 AND dst.user_id = $2 AND dst.hostname = src.hostname AND dst.verified_at IS NULL`,
+		`INSERT INTO tags (user_id, name) 
+        SELECT $2, name FROM tags WHERE user_id = $1 
+        ON CONFLICT (user_id, name) DO NOTHING`,
+		`UPDATE link_tags lt SET tag_id = dst.id 
+        FROM tags src JOIN tags dst ON dst.user_id = $2 AND dst.name = src.name 
+        WHERE lt.tag_id = src.id AND src.user_id = $1`,
+		`INSERT INTO folders (user_id, name) 
+        SELECT $2, name FROM folders WHERE user_id = $1 
+        ON CONFLICT (user_id, name) DO NOTHING`,
+		`UPDATE links l SET folder_id = dst.id 
+        FROM folders src JOIN folders dst ON dst.user_id = $2 AND dst.name = src.name 
+        WHERE l.folder_id = src.id AND src.user_id = $1`,
+		"UPDATE domains SET user_id = $2 WHERE user_id = $1",
+		"UPDATE links SET user_id = $2 WHERE user_id = $1",
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLinks удаляет ссылки пользователя вместе с кликами. Коды собственных
+// доменов в карантин не попадают: домены удаляются вместе с пользователем.
+func deleteLinks(tx DBTX, userID int, quarantine time.Duration) error {
+	if quarantine > 0 {
+		_, err := tx.Exec(`
+            INSERT INTO quarantined_codes (domain_id, short_code, released_at) 
+            SELECT NULL, c.short_code, NOW() + make_interval(secs => $2) 
+            FROM ( 
+                SELECT domain_id, short_code FROM links WHERE user_id = $1 
+                UNION ALL
+                SELECT a.domain_id, a.short_code FROM link_aliases a JOIN links l ON l.id = a.link_id WHERE l.user_id = $1
+            ) c 
+            WHERE c.domain_id IS NULL 
+        `, userID, quarantine.Seconds())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Ссылки удаляются до пользователя: домены нельзя удалить, пока на них есть ссылки
+	_, err := tx.Exec("DELETE FROM links WHERE user_id = $1", userID)
+	return err
+}
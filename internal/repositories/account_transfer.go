@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+)
+
+var ErrTransferNotFound = errors.New("передача не найдена")
+
+const transferColumns = `
+    t.id, t.from_user_id, t.to_user_id, u.username, u.email, 
+    (SELECT COUNT(*) FROM links WHERE user_id = t.from_user_id), 
+    t.expires_at, t.created_at`
+
+// OfferTransfer предлагает пользователю to принять ссылки аккаунта from на срок ttl.
+// Прежнее предложение аккаунта from заменяется.
+func (r *UserRepository) OfferTransfer(from, to int, ttl time.Duration) error {
+	_, err := r.DB.Exec(`
+        INSERT INTO account_transfers (from_user_id, to_user_id, expires_at) 
+        VALUES ($1, $2, NOW() + make_interval(secs => $3)) 
+        ON CONFLICT (from_user_id) DO UPDATE 
+        SET to_user_id = EXCLUDED.to_user_id, expires_at = EXCLUDED.expires_at, created_at = NOW()
+    `, from, to, ttl.Seconds())
+	return err
+}
+
+// CancelTransfer отзывает предложение аккаунта from, если оно есть.
+func (r *UserRepository) CancelTransfer(from int) error {
+	_, err := r.DB.Exec("DELETE FROM account_transfers WHERE from_user_id = $1", from)
+	return err
+}
+
+// FindTransfers возвращает действующие предложения, адресованные пользователю to.
+func (r *UserRepository) FindTransfers(to int) ([]models.AccountTransfer, error) {
+	rows, err := r.DB.Query(`
+        SELECT `+transferColumns+` 
+        FROM account_transfers t JOIN users u ON u.id = t.from_user_id 
+        WHERE t.to_user_id = $1 AND t.expires_at > NOW() 
+        ORDER BY t.created_at
+    `, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []models.AccountTransfer{}
+	for rows.Next() {
+		var t models.AccountTransfer
+		if err := rows.Scan(&t.ID, &t.FromUserID, &t.ToUserID, &t.FromUsername, &t.FromEmail, &t.LinkCount, &t.ExpiresAt, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+// FindTransfer возвращает действующее предложение, только если оно адресовано пользователю to.
+func (r *UserRepository) FindTransfer(to, id int) (*models.AccountTransfer, error) {
+	var t models.AccountTransfer
+	err := r.DB.QueryRow(`
+        SELECT `+transferColumns+` 
+        FROM account_transfers t JOIN users u ON u.id = t.from_user_id 
+        WHERE t.id = $1 AND t.to_user_id = $2 AND t.expires_at > NOW()
+    `, id, to).Scan(&t.ID, &t.FromUserID, &t.ToUserID, &t.FromUsername, &t.FromEmail, &t.LinkCount, &t.ExpiresAt, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeclineTransfer отклоняет предложение, адресованное пользователю to.
+func (r *UserRepository) DeclineTransfer(to, id int) error {
+	res, err := r.DB.Exec("DELETE FROM account_transfers WHERE id = $1 AND to_user_id = $2", id, to)
+	return requireAffected(res, err, ErrTransferNotFound)
+}
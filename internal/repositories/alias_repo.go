@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+)
+
+type AliasRepository struct {
+	DB *sql.DB
+}
+
+func NewAliasRepository(db *sql.DB) *AliasRepository {
+	return &AliasRepository{DB: db}
+}
+
+var (
+	ErrAliasNotFound = errors.New("алиас не найден")
+	ErrAliasExists   = errors.New("код уже занят")
+)
+
+func (r *AliasRepository) FindByLinkID(linkID int) ([]models.LinkAlias, error) {
+	rows, err := r.DB.Query(`
+        SELECT id, link_id, domain_id, short_code, created_at 
+        FROM link_aliases 
+        WHERE link_id = $1 
+        ORDER BY id
+    `, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []models.LinkAlias{}
+	for rows.Next() {
+		var a models.LinkAlias
+		if err := rows.Scan(&a.ID, &a.LinkID, &a.DomainID, &a.ShortCode, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// Create добавляет алиас на домене ссылки. Занятость кода среди ссылок проверяет
+// вызывающий; уникальный индекс защищает только от гонки между алиасами.
+func (r *AliasRepository) Create(alias *models.LinkAlias) error {
+	err := r.DB.QueryRow(`
+        INSERT INTO link_aliases (link_id, domain_id, short_code) 
+        VALUES ($1, $2, $3) 
+        RETURNING id, created_at
+    `, alias.LinkID, alias.DomainID, alias.ShortCode).Scan(&alias.ID, &alias.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAliasExists
+	}
+	return err
+}
+
+// Delete удаляет алиас ссылки. Клики по нему остаются в статистике ссылки.
+// Код алиаса уходит в карантин на quarantine, чтобы старые переходы по нему не
+// попали на чужую ссылку; quarantine == 0 освобождает код сразу.
+func (r *AliasRepository) Delete(linkID, aliasID int, quarantine time.Duration) error {
+	if quarantine <= 0 {
+		res, err := r.DB.Exec("DELETE FROM link_aliases WHERE id = $1 AND link_id = $2", aliasID, linkID)
+		return requireAffected(res, err, ErrAliasNotFound)
+	}
+
+	res, err := r.DB.Exec(`
+        WITH deleted AS (
+            DELETE FROM link_aliases WHERE id = $1 AND link_id = $2 RETURNING domain_id, short_code
+        )
+        INSERT INTO quarantined_codes (domain_id, short_code, released_at) 
+        SELECT domain_id, short_code, NOW() + make_interval(secs => $3) FROM deleted
+    `, aliasID, linkID, quarantine.Seconds())
+	return requireAffected(res, err, ErrAliasNotFound)
+}
@@ -0,0 +1,70 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasRepository_Create(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAliasRepository(db)
+
+	mock.ExpectQuery("INSERT INTO link_aliases").
+		WithArgs(10, 5, "spring-sale").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, time.Now()))
+	mock.ExpectQuery("INSERT INTO link_aliases").
+		WithArgs(10, 5, "spring-sale").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	domainID := 5
+	alias := &models.LinkAlias{LinkID: 10, DomainID: &domainID, ShortCode: "spring-sale"}
+	assert.NoError(t, repo.Create(alias))
+	assert.Equal(t, 4, alias.ID)
+
+	assert.ErrorIs(t, repo.Create(alias), repositories.ErrAliasExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAliasRepository_Delete(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAliasRepository(db)
+
+	mock.ExpectExec("DELETE FROM link_aliases WHERE id = \\$1 AND link_id = \\$2").
+		WithArgs(4, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM link_aliases WHERE id = \\$1 AND link_id = \\$2").
+		WithArgs(4, 11).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.Delete(10, 4, 0))
+	assert.ErrorIs(t, repo.Delete(11, 4, 0), repositories.ErrAliasNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAliasRepository_Delete_Quarantine(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAliasRepository(db)
+
+	mock.ExpectExec("WITH deleted AS \\( DELETE FROM link_aliases WHERE id = \\$1 AND link_id = \\$2 RETURNING domain_id, short_code \\) INSERT INTO quarantined_codes").
+		WithArgs(4, 10, float64(90*24*60*60)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("WITH deleted AS").
+		WithArgs(4, 11, float64(90*24*60*60)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.Delete(10, 4, 90*24*time.Hour))
+	assert.ErrorIs(t, repo.Delete(11, 4, 90*24*time.Hour), repositories.ErrAliasNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"time"
 	"url-short/internal/models"
 )
 
@@ -14,6 +15,15 @@ func NewAnalyticRepository(db *sql.DB) *AnalyticRepository {
 }
 
 func (r *AnalyticRepository) SaveClick(click *models.ClickAnalytic) error {
+	if click.ClickedAt.IsZero() {
+		click.ClickedAt = time.Now()
+	}
+	if click.Source == "" {
+		click.Source = models.ClickSourceLink
+	}
+
+	// Счетчики кликов обновляет RollupPending вне запроса: частые клики по одной
+	// ссылке иначе выстраивались бы в очередь за одними и теми же строками счетчиков
 	query := `
         INSERT INTO click_analytics (
             link_id, 
@@ -22,11 +32,20 @@ func (r *AnalyticRepository) SaveClick(click *models.ClickAnalytic) error {
             location, 
             device_type, 
             os, 
-            browser
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+            browser,
+            clicked_at,
+            destination_id,
+            referrer,
+            click_uid,
+            source,
+            short_code,
+            anonymous,
+            link_version
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, (SELECT version FROM links WHERE id = $1))
+        RETURNING id
     `
 
-	_, err := r.DB.Exec(
+	return r.DB.QueryRow(
 		query,
 		click.LinkID,
 		click.IPAddress,
@@ -35,10 +54,23 @@ func (r *AnalyticRepository) SaveClick(click *models.ClickAnalytic) error {
 		click.DeviceType,
 		click.OS,
 		click.Browser,
-	)
-	return err
+		click.ClickedAt,
+		click.DestinationID,
+		click.Referrer,
+		nullString(click.ClickUID),
+		click.Source,
+		click.ShortCode,
+		click.Anonymous,
+	).Scan(&click.ID)
+}
+
+// nullString превращает пустую строку в NULL, чтобы не нарушать UNIQUE-ограничения.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
-func (r *AnalyticRepository) GetAnalytics(linkID int) ([]models.ClickAnalytic, error) {
+
+// GetAnalytics возвращает не больше limit последних сырых кликов ссылки.
+func (r *AnalyticRepository) GetAnalytics(linkID, limit int) ([]models.ClickAnalytic, error) {
 	query := `
         SELECT 
             ip_address, 
@@ -46,12 +78,18 @@ func (r *AnalyticRepository) GetAnalytics(linkID int) ([]models.ClickAnalytic, e
             device_type, 
             os, 
             browser, 
-            clicked_at 
+            COALESCE(referrer, ''), 
+            source, 
+            short_code, 
+            clicked_at, 
+            anonymous 
         FROM click_analytics 
-        WHERE link_id = $1
+        WHERE link_id = $1 
+        ORDER BY clicked_at DESC, id DESC 
+        LIMIT $2
     `
 
-	rows, err := r.DB.Query(query, linkID)
+	rows, err := r.DB.Query(query, linkID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -66,13 +104,182 @@ func (r *AnalyticRepository) GetAnalytics(linkID int) ([]models.ClickAnalytic, e
 			&ca.DeviceType,
 			&ca.OS,
 			&ca.Browser,
+			&ca.Referrer,
+			&ca.Source,
+			&ca.ShortCode,
 			&ca.ClickedAt,
+			&ca.Anonymous,
 		)
 		if err != nil {
 			return nil, err
 		}
 		analytics = append(analytics, ca)
 	}
+	return analytics, rows.Err()
+}
+
+// GetVersionClicks возвращает количество кликов по каждой версии настроек ссылки
+// вместе с адресом назначения версии. Адрес пуст, пока ссылку не правили.
+// Клики берутся из счетчиков, поэтому не зависят от срока хранения сырых кликов.
+func (r *AnalyticRepository) GetVersionClicks(linkID int) ([]models.VersionStatistic, error) {
+	query := `
+        SELECT 
+            r.value::int, 
+            COALESCE(v.original_url, ''), 
+            SUM(r.clicks) 
+        FROM click_rollups_daily r
+        LEFT JOIN link_versions v ON v.link_id = r.link_id AND v.version = r.value::int
+        WHERE r.link_id = $1 AND r.dimension = 'link_version'
+        GROUP BY 1, 2
+        ORDER BY 1
+    `
+	rows, err := r.DB.Query(query, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.VersionStatistic{}
+	for rows.Next() {
+		var v models.VersionStatistic
+		if err := rows.Scan(&v.Version, &v.OriginalURL, &v.Clicks); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVariantClicks возвращает количество кликов по каждому варианту назначения ссылки.
+// Текущие варианты без кликов тоже попадают в результат, выведенные из ротации — только с кликами.
+func (r *AnalyticRepository) GetVariantClicks(linkID int) ([]models.VariantStatistic, error) {
+	query := `
+        SELECT 
+            d.id, 
+            d.url, 
+            d.weight, 
+            d.retired_at, 
+            COALESCE(SUM(r.clicks), 0) 
+        FROM link_destinations d
+        LEFT JOIN click_rollups_daily r 
+            ON r.link_id = d.link_id AND r.dimension = 'destination' AND r.value = d.id::text
+        WHERE d.link_id = $1
+        GROUP BY d.id, d.url, d.weight, d.retired_at
+        HAVING d.retired_at IS NULL OR SUM(r.clicks) > 0
+        ORDER BY d.id
+    `
+	rows, err := r.DB.Query(query, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []models.VariantStatistic
+	for rows.Next() {
+		var v models.VariantStatistic
+		if err := rows.Scan(&v.DestinationID, &v.URL, &v.Weight, &v.RetiredAt, &v.Clicks); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+// GetAggregated суммирует статистику по всем ссылкам пользователя, подходящим под фильтр.
+func (r *AnalyticRepository) GetAggregated(userID int, filter models.LinkFilter) (*models.AggregatedAnalytics, error) {
+	where, args := linkFilterClause(userID, filter)
+
+	rows, err := r.DB.Query(`
+        SELECT links.short_code, links.click_count 
+        FROM links 
+        WHERE `+where+` 
+        ORDER BY links.click_count DESC, links.id
+    `, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &models.AggregatedAnalytics{Links: []models.LinkClicks{}}
+	for rows.Next() {
+		var lc models.LinkClicks
+		if err := rows.Scan(&lc.ShortCode, &lc.Clicks); err != nil {
+			return nil, err
+		}
+		result.Links = append(result.Links, lc)
+		result.TotalClicks += lc.Clicks
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result.LinkCount = len(result.Links)
 
-	return analytics, nil
+	if result.Devices, err = r.countBy(models.DimensionDevice, where, args); err != nil {
+		return nil, err
+	}
+	if result.Browsers, err = r.countBy(models.DimensionBrowser, where, args); err != nil {
+		return nil, err
+	}
+	if result.Locations, err = r.countBy(models.DimensionLocation, where, args); err != nil {
+		return nil, err
+	}
+	if result.Tracking, err = r.countBy(models.DimensionTracking, where, args); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// countBy считает клики по значениям измерения для ссылок из where. Читает
+// посуточные счетчики, а не сырые клики: суммы за все время от шага не зависят.
+// dimension подставляется в запрос как есть и не должен содержать пользовательский ввод.
+func (r *AnalyticRepository) countBy(dimension, where string, args []any) (map[string]int, error) {
+	rows, err := r.DB.Query(`
+        SELECT r.value, SUM(r.clicks) 
+        FROM click_rollups_daily r 
+        JOIN links ON links.id = r.link_id 
+        WHERE r.dimension = '`+dimension+`' AND `+where+` 
+        GROUP BY 1
+    `, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		counts[key] += n
+	}
+	return counts, rows.Err()
+}
+
+// DeleteOldClicks удаляет сырые клики старше retention пачками по batchSize и
+// возвращает их число. Счетчики кликов и click_count ссылок не меняются; клики,
+// еще не учтенные в счетчиках, не удаляются.
+func (r *AnalyticRepository) DeleteOldClicks(retention time.Duration, batchSize int) (int64, error) {
+	var total int64
+	for {
+		res, err := r.DB.Exec(`
+        DELETE FROM click_analytics 
+        WHERE id IN ( 
+            SELECT id FROM click_analytics 
+            WHERE clicked_at < NOW() - make_interval(secs => $1) AND rolled_up 
+            LIMIT $2 
+        )`, retention.Seconds(), batchSize)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
 }
@@ -2,6 +2,7 @@ package repositories_test
 
 import (
 	"testing"
+	"time"
 	"url-short/internal/models"
 	"url-short/internal/repositories"
 
@@ -22,9 +23,10 @@ func TestAnalyticRepository_SaveClick(t *testing.T) {
 		DeviceType: "desktop",
 		OS:         "Windows",
 		Browser:    "Chrome",
+		ShortCode:  "promo",
 	}
 
-	mock.ExpectExec("INSERT INTO click_analytics").
+	mock.ExpectQuery("INSERT INTO click_analytics (.+) RETURNING id$").
 		WithArgs(
 			click.LinkID,
 			click.IPAddress,
@@ -33,12 +35,90 @@ func TestAnalyticRepository_SaveClick(t *testing.T) {
 			click.DeviceType,
 			click.OS,
 			click.Browser,
-			click.ClickedAt,
+			sqlmock.AnyArg(), // clicked_at проставляется репозиторием
+			nil,
+			"",
+			nil,
+			models.ClickSourceLink,
+			"promo",
+			false,
 		).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	err := repo.SaveClick(click)
 	assert.NoError(t, err)
+	assert.False(t, click.ClickedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_SaveClick_WithTracking(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+
+	destinationID := 7
+	click := &models.ClickAnalytic{
+		LinkID:        1,
+		IPAddress:     "127.0.0.1",
+		DestinationID: &destinationID,
+		Referrer:      "https://t.me/",
+		ClickUID:      "uid123",
+		Source:        models.ClickSourceQR,
+		ShortCode:     "spring-sale",
+	}
+
+	mock.ExpectQuery("INSERT INTO click_analytics").
+		WithArgs(1, "127.0.0.1", "", "", "", "", "", sqlmock.AnyArg(), 7, "https://t.me/", "uid123", "qr", "spring-sale", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	assert.NoError(t, repo.SaveClick(click))
+	assert.Equal(t, 42, click.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_GetVariantClicks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+
+	retired := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM link_destinations d LEFT JOIN click_rollups_daily r ON (.+) r.dimension = 'destination' AND r.value = d.id::text (.+) HAVING d.retired_at IS NULL OR SUM\\(r.clicks\\) > 0").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "weight", "retired_at", "count"}).
+			AddRow(1, "https://old.example.com", 100, retired, 7).
+			AddRow(2, "https://a.example.com", 50, nil, 12).
+			AddRow(3, "https://b.example.com", 50, nil, 0))
+
+	variants, err := repo.GetVariantClicks(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.VariantStatistic{
+		{DestinationID: 1, URL: "https://old.example.com", Weight: 100, RetiredAt: &retired, Clicks: 7},
+		{DestinationID: 2, URL: "https://a.example.com", Weight: 50, Clicks: 12},
+		{DestinationID: 3, URL: "https://b.example.com", Weight: 50, Clicks: 0},
+	}, variants)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_GetVersionClicks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM click_rollups_daily r LEFT JOIN link_versions v ON v.link_id = r.link_id AND v.version = r.value::int WHERE r.link_id = \\$1 AND r.dimension = 'link_version'").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"link_version", "original_url", "count"}).
+			AddRow(1, "https://example.com/old", 30).
+			AddRow(2, "https://example.com/new", 5))
+
+	versions, err := repo.GetVersionClicks(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.VersionStatistic{
+		{Version: 1, OriginalURL: "https://example.com/old", Clicks: 30},
+		{Version: 2, OriginalURL: "https://example.com/new", Clicks: 5},
+	}, versions)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -46,36 +126,40 @@ func TestAnalyticRepository_GetAnalytics(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	//repo := repositories.NewAnalyticRepository(db)
-	linkID := 1
-
-	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM click_analytics WHERE link_id = \\$1").
-		WithArgs(linkID).
-		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
-
-	mock.ExpectQuery("SELECT device_type, COUNT\\(\\*\\) FROM click_analytics WHERE link_id = \\$1 GROUP BY device_type").
-		WithArgs(linkID).
-		WillReturnRows(sqlmock.NewRows([]string{"device_type", "count"}).
-			AddRow("mobile", 60).
-			AddRow("desktop", 40))
-
-	mock.ExpectQuery("SELECT browser, COUNT\\(\\*\\) FROM click_analytics WHERE link_id = \\$1 GROUP BY browser").
-		WithArgs(linkID).
-		WillReturnRows(sqlmock.NewRows([]string{"browser", "count"}).
-			AddRow("Chrome", 70).
-			AddRow("Firefox", 30))
-
-	mock.ExpectQuery("SELECT location, COUNT\\(\\*\\) FROM click_analytics WHERE link_id = \\$1 GROUP BY location").
-		WithArgs(linkID).
-		WillReturnRows(sqlmock.NewRows([]string{"location", "count"}).
-			AddRow("Moscow", 50).
-			AddRow("New York", 50))
-
-	//stats, err := repo.GetAnalytics(linkID)
-	//assert.NoError(t, err)
-	//assert.Equal(t, 100, stats["total_clicks"])
-	//assert.Equal(t, map[string]int{"mobile": 60, "desktop": 40}, stats["devices"])
-	//assert.Equal(t, map[string]int{"Chrome": 70, "Firefox": 30}, stats["browsers"])
-	//assert.Equal(t, map[string]int{"Moscow": 50, "New York": 50}, stats["locations"])
+	repo := repositories.NewAnalyticRepository(db)
+	clickedAt := time.Date(2024, 2, 20, 15, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+) FROM click_analytics WHERE link_id = \\$1 ORDER BY clicked_at DESC, id DESC LIMIT \\$2").
+		WithArgs(1, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"ip_address", "location", "device_type", "os", "browser", "referrer", "source", "short_code", "clicked_at", "anonymous"}).
+			AddRow("127.0.0.1", "Moscow, Russia", "mobile", "Android", "Chrome", "", "link", "promo", clickedAt, false).
+			AddRow("", "", "", "", "", "https://t.me/", "qr", "old-promo", clickedAt, true))
+
+	clicks, err := repo.GetAnalytics(1, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ClickAnalytic{
+		{IPAddress: "127.0.0.1", Location: "Moscow, Russia", DeviceType: "mobile", OS: "Android", Browser: "Chrome", Source: "link", ShortCode: "promo", ClickedAt: clickedAt},
+		{Referrer: "https://t.me/", Source: "qr", ShortCode: "old-promo", ClickedAt: clickedAt, Anonymous: true},
+	}, clicks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_DeleteOldClicks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+	retention := 30 * 24 * time.Hour
+
+	mock.ExpectExec("DELETE FROM click_analytics WHERE id IN \\( SELECT id FROM click_analytics WHERE clicked_at < NOW\\(\\) - make_interval\\(secs => \\$1\\) AND rolled_up LIMIT \\$2 \\)").
+		WithArgs(retention.Seconds(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM click_analytics").
+		WithArgs(retention.Seconds(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := repo.DeleteOldClicks(retention, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
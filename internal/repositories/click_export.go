@@ -0,0 +1,92 @@
+package repositories
+
+import "url-short/internal/models"
+
+// exportFilterClause — условие выборки кликов для выгрузки; аргументы — exportFilterArgs.
+func exportFilterClause(filter models.ClickExportFilter) string {
+	clause := "l.user_id = $1 AND l.deleted_at IS NULL AND ca.clicked_at >= $2 AND ca.clicked_at < $3"
+	if filter.LinkID != nil {
+		clause += " AND ca.link_id = $4"
+	}
+	return clause
+}
+
+func exportFilterArgs(filter models.ClickExportFilter) []any {
+	args := []any{filter.UserID, filter.From, filter.To}
+	if filter.LinkID != nil {
+		args = append(args, *filter.LinkID)
+	}
+	return args
+}
+
+// CountExportClicks возвращает число кликов, которые попадут в выгрузку.
+func (r *AnalyticRepository) CountExportClicks(filter models.ClickExportFilter) (int64, error) {
+	var n int64
+	err := r.DB.QueryRow(`
+        SELECT COUNT(*) 
+        FROM click_analytics ca JOIN links l ON l.id = ca.link_id 
+        WHERE `+exportFilterClause(filter),
+		exportFilterArgs(filter)...,
+	).Scan(&n)
+	return n, err
+}
+
+// StreamClicks читает клики для выгрузки по одному и передает их в fn, не загружая
+// выборку в память целиком. Ошибка fn прерывает чтение и возвращается как есть.
+func (r *AnalyticRepository) StreamClicks(filter models.ClickExportFilter, fn func(row *models.ClickExportRow) error) error {
+	rows, err := r.DB.Query(`
+        SELECT 
+            ca.id, 
+            l.short_code, 
+            ca.short_code, 
+            COALESCE((SELECT hostname FROM domains WHERE domains.id = l.domain_id), ''), 
+            COALESCE(d.url, v.original_url, l.original_url), 
+            ca.link_version, 
+            ca.clicked_at, 
+            ca.source, 
+            COALESCE(ca.location, ''), 
+            COALESCE(ca.device_type, ''), 
+            COALESCE(ca.os, ''), 
+            COALESCE(ca.browser, ''), 
+            COALESCE(ca.referrer, ''), 
+            ca.user_agent 
+        FROM click_analytics ca 
+        JOIN links l ON l.id = ca.link_id 
+        LEFT JOIN link_destinations d ON d.id = ca.destination_id 
+        LEFT JOIN link_versions v ON v.link_id = ca.link_id AND v.version = ca.link_version 
+        WHERE `+exportFilterClause(filter)+` 
+        ORDER BY ca.id`,
+		exportFilterArgs(filter)...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row models.ClickExportRow
+	for rows.Next() {
+		err := rows.Scan(
+			&row.ID,
+			&row.LinkCode,
+			&row.ClickedCode,
+			&row.Domain,
+			&row.Destination,
+			&row.LinkVersion,
+			&row.ClickedAt,
+			&row.Source,
+			&row.Location,
+			&row.DeviceType,
+			&row.OS,
+			&row.Browser,
+			&row.Referrer,
+			&row.UserAgent,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+	"url-short/internal/models"
+)
+
+// clickDimensions раскладывает клик ca на строки d(dimension, value) для счетчиков.
+// Пустые значения считаются как unknown, страна — последняя часть location,
+// у referrer учитывается только хост, переходы без него — direct; tracking
+// отличает анонимные клики от сохраненных целиком, source — переходы по QR-коду,
+// short_code — переходы по алиасам, destination — клики по вариантам назначения,
+// link_version — клики по версиям настроек ссылки.
+const clickDimensions = `
+        LATERAL (VALUES
+            ('total', ''),
+            ('country', COALESCE(NULLIF(btrim(regexp_replace(COALESCE(ca.location, ''), '^.*,', '')), ''), 'unknown')),
+            ('location', COALESCE(NULLIF(ca.location, ''), 'unknown')),
+            ('browser', COALESCE(NULLIF(ca.browser, ''), 'unknown')),
+            ('os', COALESCE(NULLIF(ca.os, ''), 'unknown')),
+            ('device', COALESCE(NULLIF(ca.device_type, ''), 'unknown')),
+            ('tracking', CASE WHEN ca.anonymous THEN 'anonymous' ELSE 'full' END),
+            ('source', COALESCE(NULLIF(ca.source, ''), 'link')),
+            ('short_code', COALESCE(NULLIF(ca.short_code, ''), 'unknown')),
+            ('destination', COALESCE(ca.destination_id::text, 'none')),
+            ('link_version', ca.link_version::text),
+            ('referrer', ` + referrerHost + `)
+        ) AS d(dimension, value)`
+
+// rollupClickColumns — колонки клика, которые читает clickDimensions. Источник кликов
+// для rollupInsert должен отдавать их все.
+const rollupClickColumns = "link_id, clicked_at, location, device_type, os, browser, referrer, anonymous, source, short_code, destination_id, link_version"
+
+// referrerHost сводит referrer клика ca к хосту так же, как счетчики: без referrer — direct.
+const referrerHost = `CASE WHEN COALESCE(ca.referrer, '') = '' THEN 'direct'
+                ELSE COALESCE(left(lower(substring(ca.referrer FROM '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/@]*@)?([^/:?#]+)')), 255), 'unknown') END`
+
+// Таблицы счетчиков и шаг их интервалов.
+var rollupTables = []struct{ table, unit string }{
+	{"click_rollups_hourly", models.GranularityHour},
+	{"click_rollups_daily", models.GranularityDay},
+}
+
+// rollupInsert прибавляет к счетчикам table клики из source, отобранные условием where.
+// В source клики доступны как ca.
+func rollupInsert(table, unit, source, where string) string {
+	return `
+        INSERT INTO ` + table + ` (link_id, dimension, bucket, value, clicks)
+        SELECT ca.link_id, d.dimension, date_trunc('` + unit + `', ca.clicked_at), d.value, COUNT(*)
+        FROM ` + source + ` CROSS JOIN ` + clickDimensions + `
+        WHERE ` + where + `
+        GROUP BY 1, 2, 3, 4
+        ORDER BY 1, 2, 3, 4
+        ON CONFLICT (link_id, dimension, bucket, value) DO UPDATE SET clicks = ` + table + `.clicks + EXCLUDED.clicks`
+}
+
+// rollupTable возвращает таблицу счетчиков, по которой строится ряд с шагом granularity,
+// или пустую строку, если шаг мельче часа и ряд считается по сырым кликам.
+func rollupTable(granularity string) string {
+	switch granularity {
+	case models.GranularityHour:
+		return "click_rollups_hourly"
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth:
+		return "click_rollups_daily"
+	}
+	return ""
+}
+
+// Timeseries возвращает клики ссылки по интервалам q.Granularity в разрезе q.Dimension.
+// Ряды с шагом от часа читаются из счетчиков, поэтому их стоимость не зависит от числа кликов.
+func (r *AnalyticRepository) Timeseries(linkID int, q models.TimeseriesQuery) (*models.Timeseries, error) {
+	series := &models.Timeseries{
+		Granularity: q.Granularity,
+		Dimension:   q.Dimension,
+		From:        q.From,
+		To:          q.To,
+		Source:      "rollup",
+		Points:      []models.TimeseriesPoint{},
+	}
+
+	var query string
+	if table := rollupTable(q.Granularity); table != "" {
+		query = `
+        SELECT date_trunc($5, bucket), value, SUM(clicks)
+        FROM ` + table + `
+        WHERE link_id = $1 AND dimension = $2 AND bucket >= $3 AND bucket < $4
+        GROUP BY 1, 2
+        ORDER BY 1, 2`
+	} else {
+		series.Source = "raw"
+		query = `
+        SELECT date_trunc($5, ca.clicked_at), d.value, COUNT(*)
+        FROM click_analytics ca CROSS JOIN ` + clickDimensions + `
+        WHERE ca.link_id = $1 AND d.dimension = $2 AND ca.clicked_at >= $3 AND ca.clicked_at < $4
+        GROUP BY 1, 2
+        ORDER BY 1, 2`
+	}
+
+	rows, err := r.DB.Query(query, linkID, q.Dimension, q.From, q.To, q.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.TimeseriesPoint
+		if err := rows.Scan(&p.Bucket, &p.Value, &p.Clicks); err != nil {
+			return nil, err
+		}
+		series.Points = append(series.Points, p)
+	}
+	return series, rows.Err()
+}
+
+// CountDimension возвращает клики ссылки за все время по значениям измерения.
+func (r *AnalyticRepository) CountDimension(linkID int, dimension string) (map[string]int, error) {
+	rows, err := r.DB.Query(`
+        SELECT value, SUM(clicks) 
+        FROM click_rollups_daily 
+        WHERE link_id = $1 AND dimension = $2 
+        GROUP BY value`, linkID, dimension)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		counts[key] = n
+	}
+	return counts, rows.Err()
+}
+
+// RollupLinkIDs возвращает ссылки, по которым были клики, в порядке id.
+func (r *AnalyticRepository) RollupLinkIDs() ([]int, error) {
+	rows, err := r.DB.Query("SELECT id FROM links WHERE click_count > 0 ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FirstClick возвращает время первого клика по ссылке; ok = false, если кликов нет.
+func (r *AnalyticRepository) FirstClick(linkID int) (first time.Time, ok bool, err error) {
+	var t sql.NullTime
+	if err := r.DB.QueryRow("SELECT MIN(clicked_at) FROM click_analytics WHERE link_id = $1", linkID).Scan(&t); err != nil {
+		return time.Time{}, false, err
+	}
+	return t.Time, t.Valid, nil
+}
+
+// RollupsBackfilled сообщает, пересобраны ли счетчики по всем сырым кликам.
+// До этого удалять старые клики нельзя: часть из них есть только в click_analytics.
+func (r *AnalyticRepository) RollupsBackfilled() (bool, error) {
+	var done bool
+	err := r.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM click_rollup_backfills)").Scan(&done)
+	return done, err
+}
+
+// MarkRollupsBackfilled отмечает, что счетчики пересобраны по всем сырым кликам.
+func (r *AnalyticRepository) MarkRollupsBackfilled() error {
+	_, err := r.DB.Exec(`
+        INSERT INTO click_rollup_backfills (id, completed_at) VALUES (TRUE, NOW())
+        ON CONFLICT (id) DO UPDATE SET completed_at = EXCLUDED.completed_at`)
+	return err
+}
+
+// RollupPending учитывает в счетчиках до batchSize еще не учтенных кликов и
+// возвращает их число. Клики пачки складываются в счетчики одним запросом, поэтому
+// строка счетчика обновляется раз за пачку, а не за каждый клик. Клики, которые
+// учитывает другой процесс, пропускаются.
+func (r *AnalyticRepository) RollupPending(batchSize int) (int64, error) {
+	var n int64
+	err := r.DB.QueryRow(`
+        WITH ca AS (
+            UPDATE click_analytics SET rolled_up = TRUE 
+            WHERE id IN ( 
+                SELECT id FROM click_analytics 
+                WHERE NOT rolled_up 
+                ORDER BY id 
+                LIMIT $1 
+                FOR UPDATE SKIP LOCKED 
+            ) 
+            RETURNING `+rollupClickColumns+`
+        ), 
+        hourly AS (`+rollupInsert("click_rollups_hourly", models.GranularityHour, "ca", "TRUE")+`), 
+        daily AS (`+rollupInsert("click_rollups_daily", models.GranularityDay, "ca", "TRUE")+`) 
+        SELECT COUNT(*) FROM ca`, batchSize).Scan(&n)
+	return n, err
+}
+
+// RebuildRollups пересчитывает счетчики ссылки за [from, to) по сырым кликам и
+// возвращает число записанных строк. from и to должны быть началом суток.
+// Текущие сутки не трогаются: их ведет RollupPending. Еще не учтенные клики
+// пересчитываемых суток отмечаются учтенными, чтобы RollupPending не прибавил их повторно.
+func (r *AnalyticRepository) RebuildRollups(linkID int, from, to time.Time) (int64, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	until := "LEAST($3::timestamp, date_trunc('day', LOCALTIMESTAMP - interval '1 minute'))"
+	_, err = tx.Exec(`
+        UPDATE click_analytics SET rolled_up = TRUE
+        WHERE link_id = $1 AND NOT rolled_up AND clicked_at >= $2 AND clicked_at < `+until, linkID, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	for _, t := range rollupTables {
+		_, err := tx.Exec(`
+        DELETE FROM `+t.table+`
+        WHERE link_id = $1 AND bucket >= $2 AND bucket < `+until, linkID, from, to)
+		if err != nil {
+			return 0, err
+		}
+
+		res, err := tx.Exec(rollupInsert(t.table, t.unit, "click_analytics ca",
+			"ca.link_id = $1 AND ca.clicked_at >= $2 AND ca.clicked_at < "+until), linkID, from, to)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		written += n
+	}
+	return written, tx.Commit()
+}
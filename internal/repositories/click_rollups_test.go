@@ -0,0 +1,179 @@
+package repositories_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticRepository_Timeseries(t *testing.T) {
+	from := time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+
+	tests := []struct {
+		name        string
+		granularity string
+		query       string
+		source      string
+	}{
+		{"Hourly rollup", models.GranularityHour, "SELECT date_trunc\\(\\$5, bucket\\), value, SUM\\(clicks\\) FROM click_rollups_hourly WHERE link_id = \\$1 AND dimension = \\$2", "rollup"},
+		{"Weekly from daily rollup", models.GranularityWeek, "SELECT date_trunc\\(\\$5, bucket\\), value, SUM\\(clicks\\) FROM click_rollups_daily", "rollup"},
+		{"Minutes from raw clicks", models.GranularityMinute, "SELECT date_trunc\\(\\$5, ca.clicked_at\\), d.value, COUNT\\(\\*\\) FROM click_analytics ca CROSS JOIN LATERAL", "raw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repositories.NewAnalyticRepository(db)
+			q := models.TimeseriesQuery{Granularity: tt.granularity, Dimension: models.DimensionBrowser, From: from, To: to}
+
+			mock.ExpectQuery(tt.query).
+				WithArgs(1, "browser", from, to, tt.granularity).
+				WillReturnRows(sqlmock.NewRows([]string{"bucket", "value", "clicks"}).
+					AddRow(from, "Chrome", 30).
+					AddRow(from, "Firefox", 12))
+
+			series, err := repo.Timeseries(1, q)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.source, series.Source)
+			assert.Equal(t, []models.TimeseriesPoint{
+				{Bucket: from, Value: "Chrome", Clicks: 30},
+				{Bucket: from, Value: "Firefox", Clicks: 12},
+			}, series.Points)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAnalyticRepository_RebuildRollups(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE click_analytics SET rolled_up = TRUE WHERE link_id = \\$1 AND NOT rolled_up AND clicked_at >= \\$2 AND clicked_at < LEAST\\(\\$3::timestamp, date_trunc\\('day'").
+		WithArgs(7, from, to).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM click_rollups_hourly WHERE link_id = \\$1 AND bucket >= \\$2 AND bucket < LEAST\\(\\$3::timestamp, date_trunc\\('day'").
+		WithArgs(7, from, to).
+		WillReturnResult(sqlmock.NewResult(0, 40))
+	mock.ExpectExec("INSERT INTO click_rollups_hourly (.+) FROM click_analytics ca CROSS JOIN LATERAL (.+) WHERE ca.link_id = \\$1").
+		WithArgs(7, from, to).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	mock.ExpectExec("DELETE FROM click_rollups_daily (.+) date_trunc\\('day'").
+		WithArgs(7, from, to).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec("INSERT INTO click_rollups_daily (.+) ON CONFLICT").
+		WithArgs(7, from, to).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectCommit()
+
+	written, err := repo.RebuildRollups(7, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(54), written)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_FirstClick(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+	first := time.Date(2023, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT MIN\\(clicked_at\\) FROM click_analytics WHERE link_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(first))
+	mock.ExpectQuery("SELECT MIN\\(clicked_at\\) FROM click_analytics WHERE link_id = \\$1").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+
+	got, ok, err := repo.FirstClick(1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, first, got)
+
+	_, ok, err = repo.FirstClick(2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_RollupsBackfilled(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM click_rollup_backfills\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO click_rollup_backfills").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM click_rollup_backfills\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	done, err := repo.RollupsBackfilled()
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	assert.NoError(t, repo.MarkRollupsBackfilled())
+
+	done, err = repo.RollupsBackfilled()
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_RollupPending(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+
+	mock.ExpectQuery("UPDATE click_analytics SET rolled_up = TRUE WHERE id IN \\( SELECT id FROM click_analytics WHERE NOT rolled_up ORDER BY id LIMIT \\$1 FOR UPDATE SKIP LOCKED \\) RETURNING link_id, clicked_at, location, device_type, os, browser, referrer, anonymous, source, short_code, destination_id, link_version \\), hourly AS (.+) INSERT INTO click_rollups_hourly (.+) INSERT INTO click_rollups_daily (.+) SELECT COUNT\\(\\*\\) FROM ca").
+		WithArgs(500).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(120))
+
+	n, err := repo.RollupPending(500)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(120), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Каждая колонка клика, которую читают измерения счетчиков, должна быть в RETURNING
+// очереди RollupPending, иначе Postgres отвергнет всю пачку.
+func TestAnalyticRepository_RollupPending_ReturnsDimensionColumns(t *testing.T) {
+	var query string
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(_, actual string) error {
+		query = actual
+		return nil
+	})))
+	defer db.Close()
+
+	mock.ExpectQuery("").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	_, err := repositories.NewAnalyticRepository(db).RollupPending(500)
+	assert.NoError(t, err)
+
+	returning := regexp.MustCompile(`RETURNING ([^)]+)\)`).FindStringSubmatch(query)
+	if !assert.Len(t, returning, 2) {
+		return
+	}
+	columns := map[string]bool{}
+	for _, c := range strings.Split(returning[1], ",") {
+		columns[strings.TrimSpace(c)] = true
+	}
+	for _, m := range regexp.MustCompile(`\bca\.(\w+)`).FindAllStringSubmatch(query, -1) {
+		assert.True(t, columns[m[1]], "колонки %s нет в RETURNING", m[1])
+	}
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import "database/sql"
+
+// CodeSequence выдает номера из short_code_seq для счетчиковой генерации кодов.
+type CodeSequence struct {
+	DB *sql.DB
+}
+
+func NewCodeSequence(db *sql.DB) *CodeSequence {
+	return &CodeSequence{DB: db}
+}
+
+func (s *CodeSequence) Next() (uint64, error) {
+	var n uint64
+	err := s.DB.QueryRow("SELECT nextval('short_code_seq')").Scan(&n)
+	return n, err
+}
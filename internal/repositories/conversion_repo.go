@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"url-short/internal/models"
+)
+
+type ConversionRepository struct {
+	DB *sql.DB
+}
+
+func NewConversionRepository(db *sql.DB) *ConversionRepository {
+	return &ConversionRepository{DB: db}
+}
+
+var ErrClickNotFound = errors.New("клик не найден")
+
+// Create привязывает конверсию к клику по его публичному идентификатору.
+// Срезы клика копируются в конверсию, чтобы отчет пережил удаление старых кликов.
+func (r *ConversionRepository) Create(clickUID string, conv *models.Conversion) error {
+	query := `
+        INSERT INTO conversions (click_id, link_id, value, click_uid, referrer, destination_id)
+        SELECT ca.id, ca.link_id, $2, ca.click_uid, ` + referrerHost + `, ca.destination_id 
+        FROM click_analytics ca 
+        WHERE ca.click_uid = $1
+        RETURNING id, click_id, link_id, created_at
+    `
+	err := r.DB.QueryRow(query, clickUID, conv.Value).
+		Scan(&conv.ID, &conv.ClickID, &conv.LinkID, &conv.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrClickNotFound
+	}
+	return err
+}
+
+// GetReport считает конверсию и выручку по ссылке целиком,
+// а также в разрезе источников перехода и вариантов A/B-теста.
+// Клики берутся из счетчиков, конверсии — из сохраненных в них срезов,
+// поэтому отчет не зависит от срока хранения сырых кликов.
+func (r *ConversionRepository) GetReport(linkID int) (*models.ConversionReport, error) {
+	totals, err := r.breakdown(linkID, models.DimensionTotal, "''", "k.key")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ConversionReport{}
+	if len(totals) > 0 {
+		report.ConversionStatistic = totals[0]
+		report.ConversionStatistic.Key = ""
+	}
+
+	report.ByReferrer, err = r.breakdown(linkID, models.DimensionReferrer, "COALESCE(cv.referrer, 'unknown')", "k.key")
+	if err != nil {
+		return nil, err
+	}
+	report.ByVariant, err = r.breakdown(linkID, models.DimensionDestination,
+		"COALESCE(cv.destination_id::text, '"+models.DestinationNone+"')", "COALESCE(d.url, '')")
+	if err != nil {
+		return nil, err
+	}
+	// Если вариантов нет, срез по ним не несет информации
+	if len(report.ByVariant) == 1 && report.ByVariant[0].Key == "" {
+		report.ByVariant = nil
+	}
+	return report, nil
+}
+
+// breakdown сопоставляет клики из счетчиков измерения dimension с конверсиями,
+// сгруппированными по convKey (значение того же измерения, вычисленное по конверсии cv).
+// labelExpr превращает значение k.key в подпись среза; вариант назначения доступен как d.
+// Выражения подставляются в запрос как есть и не должны содержать пользовательский ввод.
+func (r *ConversionRepository) breakdown(linkID int, dimension, convKey, labelExpr string) ([]models.ConversionStatistic, error) {
+	query := `
+        WITH clicks AS (
+            SELECT value AS key, SUM(clicks) AS clicks 
+            FROM click_rollups_daily 
+            WHERE link_id = $1 AND dimension = $2 
+            GROUP BY value
+        ), conv AS (
+            SELECT 
+                ` + convKey + ` AS key, 
+                COUNT(DISTINCT cv.click_uid) AS converted, 
+                COUNT(*) AS conversions, 
+                SUM(cv.value) AS revenue 
+            FROM conversions cv 
+            WHERE cv.link_id = $1 
+            GROUP BY 1
+        )
+        SELECT 
+            ` + labelExpr + `, 
+            COALESCE(k.clicks, 0), 
+            COALESCE(k.converted, 0), 
+            COALESCE(k.conversions, 0), 
+            COALESCE(k.revenue, 0) 
+        FROM (
+            SELECT COALESCE(cl.key, cv.key) AS key, cl.clicks, cv.converted, cv.conversions, cv.revenue 
+            FROM clicks cl 
+            FULL JOIN conv cv ON cv.key = cl.key
+        ) k
+        LEFT JOIN link_destinations d ON d.id::text = k.key AND d.link_id = $1
+        ORDER BY 2 DESC, 1
+    `
+	rows, err := r.DB.Query(query, linkID, dimension)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.ConversionStatistic
+	for rows.Next() {
+		var s models.ConversionStatistic
+		var convertedClicks int
+		if err := rows.Scan(&s.Key, &s.Clicks, &convertedClicks, &s.Conversions, &s.Revenue); err != nil {
+			return nil, err
+		}
+		if s.Clicks > 0 {
+			s.ConversionRate = float64(convertedClicks) / float64(s.Clicks)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,104 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversionRepository_Create(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewConversionRepository(db)
+
+	mock.ExpectQuery("INSERT INTO conversions \\(click_id, link_id, value, click_uid, referrer, destination_id\\) SELECT ca.id, ca.link_id, \\$2, ca.click_uid, CASE (.+), ca.destination_id FROM click_analytics ca WHERE ca.click_uid = \\$1").
+		WithArgs("uid123", 19.99).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "click_id", "link_id", "created_at"}).
+			AddRow(1, 42, 7, time.Now()))
+
+	conv := &models.Conversion{Value: 19.99}
+	err := repo.Create("uid123", conv)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, conv.ClickID)
+	assert.Equal(t, 7, conv.LinkID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversionRepository_Create_UnknownClick(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewConversionRepository(db)
+
+	mock.ExpectQuery("INSERT INTO conversions").
+		WithArgs("missing", 0.0).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Create("missing", &models.Conversion{})
+	assert.ErrorIs(t, err, repositories.ErrClickNotFound)
+}
+
+func TestConversionRepository_GetReport(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewConversionRepository(db)
+	columns := []string{"key", "clicks", "converted_clicks", "conversions", "revenue"}
+
+	mock.ExpectQuery("FROM click_rollups_daily (.+) SELECT '' AS key, COUNT\\(DISTINCT cv.click_uid\\) (.+) FROM conversions cv WHERE cv.link_id = \\$1").
+		WithArgs(7, "total").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("", 200, 10, 12, 240.5))
+	mock.ExpectQuery("SELECT COALESCE\\(cv.referrer, 'unknown'\\) AS key").
+		WithArgs(7, "referrer").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("direct", 150, 5, 5, 100.0).
+			AddRow("t.me", 50, 5, 7, 140.5))
+	mock.ExpectQuery("SELECT COALESCE\\(cv.destination_id::text, 'none'\\) AS key(.+) SELECT COALESCE\\(d.url, ''\\)").
+		WithArgs(7, "destination").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("", 200, 10, 12, 240.5))
+
+	report, err := repo.GetReport(7)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, report.Clicks)
+	assert.Equal(t, 12, report.Conversions)
+	assert.InDelta(t, 0.05, report.ConversionRate, 1e-9)
+	assert.Equal(t, 240.5, report.Revenue)
+	assert.Len(t, report.ByReferrer, 2)
+	assert.InDelta(t, 0.1, report.ByReferrer[1].ConversionRate, 1e-9)
+	assert.Nil(t, report.ByVariant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversionRepository_GetReport_Variants(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewConversionRepository(db)
+	columns := []string{"key", "clicks", "converted_clicks", "conversions", "revenue"}
+
+	mock.ExpectQuery("SELECT '' AS key").
+		WithArgs(7, "total").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("", 100, 4, 4, 80.0))
+	mock.ExpectQuery("SELECT COALESCE\\(cv.referrer").
+		WithArgs(7, "referrer").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("direct", 100, 4, 4, 80.0))
+	// Конверсии по кликам, которые уже удалены по сроку хранения, остаются в отчете
+	mock.ExpectQuery("SELECT COALESCE\\(cv.destination_id::text").
+		WithArgs(7, "destination").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("https://a.example.com", 60, 3, 3, 60.0).
+			AddRow("https://b.example.com", 40, 1, 1, 20.0))
+
+	report, err := repo.GetReport(7)
+	assert.NoError(t, err)
+	assert.Len(t, report.ByVariant, 2)
+	assert.InDelta(t, 0.05, report.ByVariant[0].ConversionRate, 1e-9)
+	assert.InDelta(t, 0.025, report.ByVariant[1].ConversionRate, 1e-9)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// DBTX — общий интерфейс *sql.DB и *sql.Tx, чтобы одни и те же запросы
+// можно было выполнять как отдельно, так и внутри транзакции.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// isUniqueViolation сообщает, нарушено ли UNIQUE-ограничение PostgreSQL.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// requireAffected возвращает notFound, если запрос не затронул ни одной строки.
+func requireAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
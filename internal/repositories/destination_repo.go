@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"database/sql"
+	"url-short/internal/models"
+)
+
+type DestinationRepository struct {
+	DB *sql.DB
+}
+
+func NewDestinationRepository(db *sql.DB) *DestinationRepository {
+	return &DestinationRepository{DB: db}
+}
+
+// ReplaceForLink атомарно заменяет набор вариантов назначения ссылки.
+// Прежние варианты выводятся из ротации, но остаются в базе вместе с их кликами.
+func (r *DestinationRepository) ReplaceForLink(linkID int, destinations []models.LinkDestination) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceDestinations(tx, linkID, destinations); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceDestinations(q DBTX, linkID int, destinations []models.LinkDestination) error {
+	_, err := q.Exec(
+		"UPDATE link_destinations SET retired_at = NOW() WHERE link_id = $1 AND retired_at IS NULL",
+		linkID,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range destinations {
+		err := q.QueryRow(
+			"INSERT INTO link_destinations (link_id, url, weight) VALUES ($1, $2, $3) RETURNING id",
+			linkID,
+			destinations[i].URL,
+			destinations[i].Weight,
+		).Scan(&destinations[i].ID)
+		if err != nil {
+			return err
+		}
+		destinations[i].LinkID = linkID
+	}
+	return nil
+}
+
+func (r *DestinationRepository) FindByLinkID(linkID int) ([]models.LinkDestination, error) {
+	rows, err := r.DB.Query(
+		"SELECT id, link_id, url, weight FROM link_destinations WHERE link_id = $1 AND retired_at IS NULL ORDER BY id",
+		linkID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var destinations []models.LinkDestination
+	for rows.Next() {
+		var d models.LinkDestination
+		if err := rows.Scan(&d.ID, &d.LinkID, &d.URL, &d.Weight); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, d)
+	}
+	return destinations, rows.Err()
+}
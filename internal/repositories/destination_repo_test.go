@@ -0,0 +1,76 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestinationRepository_ReplaceForLink(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDestinationRepository(db)
+
+	destinations := []models.LinkDestination{
+		{URL: "https://a.example.com", Weight: 70},
+		{URL: "https://b.example.com", Weight: 30},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE link_destinations SET retired_at = NOW\\(\\) WHERE link_id = \\$1 AND retired_at IS NULL").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO link_destinations").
+		WithArgs(5, "https://a.example.com", 70).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery("INSERT INTO link_destinations").
+		WithArgs(5, "https://b.example.com", 30).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	err := repo.ReplaceForLink(5, destinations)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, destinations[0].ID)
+	assert.Equal(t, 11, destinations[1].ID)
+	assert.Equal(t, 5, destinations[1].LinkID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDestinationRepository_ReplaceForLink_Rollback(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDestinationRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE link_destinations SET retired_at").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO link_destinations").
+		WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	err := repo.ReplaceForLink(5, []models.LinkDestination{{URL: "https://a.example.com", Weight: 1}})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDestinationRepository_FindByLinkID(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDestinationRepository(db)
+
+	mock.ExpectQuery("SELECT id, link_id, url, weight FROM link_destinations WHERE link_id = \\$1 AND retired_at IS NULL").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "link_id", "url", "weight"}).
+			AddRow(10, 5, "https://a.example.com", 70))
+
+	destinations, err := repo.FindByLinkID(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LinkDestination{{ID: 10, LinkID: 5, URL: "https://a.example.com", Weight: 70}}, destinations)
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"url-short/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type DomainRepository struct {
+	DB *sql.DB
+}
+
+func NewDomainRepository(db *sql.DB) *DomainRepository {
+	return &DomainRepository{DB: db}
+}
+
+var (
+	ErrDomainNotFound = errors.New("домен не найден")
+	ErrDomainExists   = errors.New("домен уже зарегистрирован")
+	ErrDomainInUse    = errors.New("на домене есть ссылки")
+)
+
+const domainColumns = "id, user_id, hostname, verification_token, verified_at, created_at"
+
+func scanDomain(row rowScanner) (*models.Domain, error) {
+	var d models.Domain
+	err := row.Scan(&d.ID, &d.UserID, &d.Hostname, &d.VerificationToken, &d.VerifiedAt, &d.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDomainNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *DomainRepository) FindByUserID(userID int) ([]models.Domain, error) {
+	rows, err := r.DB.Query(`
+        SELECT `+domainColumns+` 
+        FROM domains 
+        WHERE user_id = $1 
+        ORDER BY hostname
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []models.Domain{}
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *d)
+	}
+	return domains, rows.Err()
+}
+
+// FindByHostname ищет подтвержденный домен по имени хоста (в нижнем регистре, без порта).
+// Неподтвержденные заявки на имя не находятся: их может быть несколько.
+func (r *DomainRepository) FindByHostname(hostname string) (*models.Domain, error) {
+	return scanDomain(r.DB.QueryRow(
+		"SELECT "+domainColumns+" FROM domains WHERE hostname = $1 AND verified_at IS NOT NULL",
+		hostname,
+	))
+}
+
+// FindOwnByHostname ищет домен пользователя по имени хоста, в том числе неподтвержденный.
+func (r *DomainRepository) FindOwnByHostname(userID int, hostname string) (*models.Domain, error) {
+	return scanDomain(r.DB.QueryRow(
+		"SELECT "+domainColumns+" FROM domains WHERE hostname = $1 AND user_id = $2",
+		hostname,
+		userID,
+	))
+}
+
+// FindByID возвращает домен, только если он принадлежит пользователю.
+func (r *DomainRepository) FindByID(userID, domainID int) (*models.Domain, error) {
+	return scanDomain(r.DB.QueryRow(
+		"SELECT "+domainColumns+" FROM domains WHERE id = $1 AND user_id = $2",
+		domainID,
+		userID,
+	))
+}
+
+// Create добавляет заявку пользователя на домен. ErrDomainExists — пользователь уже
+// заявил этот домен или домен подтвержден другим пользователем.
+func (r *DomainRepository) Create(domain *models.Domain) error {
+	err := r.DB.QueryRow(`
+        INSERT INTO domains (user_id, hostname, verification_token) 
+        SELECT $1, $2, $3 
+        WHERE NOT EXISTS (SELECT 1 FROM domains WHERE hostname = $2 AND verified_at IS NOT NULL) 
+        RETURNING id, created_at
+    `, domain.UserID, domain.Hostname, domain.VerificationToken).Scan(&domain.ID, &domain.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) || isUniqueViolation(err) {
+		return ErrDomainExists
+	}
+	return err
+}
+
+// MarkVerified отмечает домен подтвержденным. Если домен уже подтвердил другой
+// пользователь, возвращает ErrDomainExists: имя получает тот, кто подтвердил первым.
+func (r *DomainRepository) MarkVerified(domain *models.Domain) error {
+	err := r.DB.QueryRow(
+		"UPDATE domains SET verified_at = NOW() WHERE id = $1 RETURNING verified_at",
+		domain.ID,
+	).Scan(&domain.VerifiedAt)
+	if isUniqueViolation(err) {
+		return ErrDomainExists
+	}
+	return err
+}
+
+// Delete удаляет домен пользователя; домен, на котором есть ссылки, удалить нельзя.
+func (r *DomainRepository) Delete(userID, domainID int) error {
+	res, err := r.DB.Exec("DELETE FROM domains WHERE id = $1 AND user_id = $2", domainID, userID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrDomainInUse
+	}
+	return requireAffected(res, err, ErrDomainNotFound)
+}
@@ -0,0 +1,96 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var domainColumns = []string{"id", "user_id", "hostname", "verification_token", "verified_at", "created_at"}
+
+func TestDomainRepository_Create(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDomainRepository(db)
+
+	mock.ExpectQuery("INSERT INTO domains").
+		WithArgs(1, "go.brand.com", "token").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+	mock.ExpectQuery("INSERT INTO domains").
+		WithArgs(1, "go.brand.com", "token").
+		WillReturnError(&pq.Error{Code: "23505"})
+	// Домен уже подтвержден другим пользователем: вставка ничего не возвращает
+	mock.ExpectQuery("INSERT INTO domains").
+		WithArgs(1, "go.brand.com", "token").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+
+	domain := &models.Domain{UserID: 1, Hostname: "go.brand.com", VerificationToken: "token"}
+	assert.NoError(t, repo.Create(domain))
+	assert.Equal(t, 2, domain.ID)
+
+	assert.ErrorIs(t, repo.Create(domain), repositories.ErrDomainExists)
+	assert.ErrorIs(t, repo.Create(domain), repositories.ErrDomainExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDomainRepository_FindByHostname(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDomainRepository(db)
+
+	verifiedAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND verified_at IS NOT NULL").
+		WithArgs("go.brand.com").
+		WillReturnRows(sqlmock.NewRows(domainColumns).
+			AddRow(2, 1, "go.brand.com", "token", verifiedAt, verifiedAt))
+	mock.ExpectQuery("SELECT (.+) FROM domains WHERE hostname = \\$1 AND verified_at IS NOT NULL").
+		WithArgs("unknown.com").
+		WillReturnRows(sqlmock.NewRows(domainColumns))
+
+	domain, err := repo.FindByHostname("go.brand.com")
+	assert.NoError(t, err)
+	assert.True(t, domain.IsVerified())
+
+	_, err = repo.FindByHostname("unknown.com")
+	assert.ErrorIs(t, err, repositories.ErrDomainNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDomainRepository_MarkVerified(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDomainRepository(db)
+
+	mock.ExpectQuery("UPDATE domains SET verified_at = NOW\\(\\) WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	assert.ErrorIs(t, repo.MarkVerified(&models.Domain{ID: 2}), repositories.ErrDomainExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDomainRepository_Delete(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewDomainRepository(db)
+
+	mock.ExpectExec("DELETE FROM domains WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(2, 1).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectExec("DELETE FROM domains WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.Delete(1, 2), repositories.ErrDomainInUse)
+	assert.ErrorIs(t, repo.Delete(1, 3), repositories.ErrDomainNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
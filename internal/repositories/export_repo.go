@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+)
+
+type ExportRepository struct {
+	DB *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{DB: db}
+}
+
+var ErrExportNotFound = errors.New("выгрузка не найдена")
+
+const exportColumns = "id, user_id, link_id, format, gzip, range_from, range_to, status, rows_count, size_bytes, " +
+	"file_path, error, created_at, started_at, completed_at, expires_at"
+
+func scanExport(row rowScanner) (*models.ExportJob, error) {
+	var j models.ExportJob
+	err := row.Scan(
+		&j.ID,
+		&j.UserID,
+		&j.LinkID,
+		&j.Format,
+		&j.Gzip,
+		&j.From,
+		&j.To,
+		&j.Status,
+		&j.Rows,
+		&j.SizeBytes,
+		&j.FilePath,
+		&j.Error,
+		&j.CreatedAt,
+		&j.StartedAt,
+		&j.CompletedAt,
+		&j.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Create ставит выгрузку в очередь.
+func (r *ExportRepository) Create(job *models.ExportJob) error {
+	job.Status = models.ExportPending
+	return r.DB.QueryRow(`
+        INSERT INTO export_jobs (user_id, link_id, format, gzip, range_from, range_to, status) 
+        VALUES ($1, $2, $3, $4, $5, $6, $7) 
+        RETURNING id, created_at
+    `, job.UserID, job.LinkID, job.Format, job.Gzip, job.From, job.To, job.Status).Scan(&job.ID, &job.CreatedAt)
+}
+
+func (r *ExportRepository) FindByID(userID, id int) (*models.ExportJob, error) {
+	return scanExport(r.DB.QueryRow("SELECT "+exportColumns+" FROM export_jobs WHERE id = $1 AND user_id = $2", id, userID))
+}
+
+// FindByUserID возвращает выгрузки пользователя, новые первыми.
+func (r *ExportRepository) FindByUserID(userID int) ([]models.ExportJob, error) {
+	rows, err := r.DB.Query("SELECT "+exportColumns+" FROM export_jobs WHERE user_id = $1 ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ExportJob{}
+	for rows.Next() {
+		j, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimNext переводит в running самую старую ожидающую выгрузку и возвращает ее.
+// Выгрузка, которая выполняется дольше stale, считается брошенной упавшим сервером
+// и запускается заново. Если выгружать нечего, возвращает ErrExportNotFound.
+func (r *ExportRepository) ClaimNext(stale time.Duration) (*models.ExportJob, error) {
+	return scanExport(r.DB.QueryRow(`
+        UPDATE export_jobs SET status = 'running', started_at = NOW() 
+        WHERE id = ( 
+            SELECT id FROM export_jobs 
+            WHERE status = 'pending' 
+                OR (status = 'running' AND started_at < NOW() - make_interval(secs => $1)) 
+            ORDER BY id 
+            LIMIT 1 
+            FOR UPDATE SKIP LOCKED 
+        ) 
+        RETURNING `+exportColumns, stale.Seconds()))
+}
+
+// Complete сохраняет результат выгрузки; файл хранится retention.
+func (r *ExportRepository) Complete(job *models.ExportJob, retention time.Duration) error {
+	err := r.DB.QueryRow(`
+        UPDATE export_jobs 
+        SET status = 'completed', rows_count = $1, size_bytes = $2, file_path = $3, 
+            completed_at = NOW(), expires_at = NOW() + make_interval(secs => $4) 
+        WHERE id = $5 
+        RETURNING completed_at, expires_at
+    `, job.Rows, job.SizeBytes, job.FilePath, retention.Seconds(), job.ID).Scan(&job.CompletedAt, &job.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	job.Status = models.ExportCompleted
+	return nil
+}
+
+// Fail помечает выгрузку неудачной; запись о ней хранится retention.
+func (r *ExportRepository) Fail(id int, message string, retention time.Duration) error {
+	_, err := r.DB.Exec(`
+        UPDATE export_jobs 
+        SET status = 'failed', error = $1, completed_at = NOW(), expires_at = NOW() + make_interval(secs => $2) 
+        WHERE id = $3
+    `, message, retention.Seconds(), id)
+	return err
+}
+
+// DeleteExpired удаляет выгрузки, срок хранения файлов которых истек, и возвращает
+// пути к их файлам.
+func (r *ExportRepository) DeleteExpired() ([]string, error) {
+	rows, err := r.DB.Query("DELETE FROM export_jobs WHERE expires_at <= NOW() RETURNING file_path")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, rows.Err()
+}
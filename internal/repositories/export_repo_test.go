@@ -0,0 +1,83 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportRowColumns = []string{
+	"id", "link_code", "clicked_code", "domain", "destination", "link_version", "clicked_at",
+	"source", "location", "device_type", "os", "browser", "referrer", "user_agent",
+}
+
+func TestAnalyticRepository_StreamClicks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+	linkID := 5
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery("FROM click_analytics ca JOIN links l ON l.id = ca.link_id (.+) "+
+		"WHERE l.user_id = \\$1 AND l.deleted_at IS NULL AND ca.clicked_at >= \\$2 AND ca.clicked_at < \\$3 AND ca.link_id = \\$4 ORDER BY ca.id").
+		WithArgs(1, from, to, 5).
+		WillReturnRows(sqlmock.NewRows(exportRowColumns).
+			AddRow(1, "abc", "abc", "", "https://example.com", 1, from, "link", "RU", "desktop", "Linux", "Firefox", "", "Mozilla/5.0").
+			AddRow(2, "abc", "sale", "", "https://example.com/v2", 2, from, "qr", "RU", "mobile", "iOS", "Safari", "", "Mozilla/5.0"))
+
+	var codes []string
+	err := repo.StreamClicks(models.ClickExportFilter{UserID: 1, LinkID: &linkID, From: from, To: to}, func(row *models.ClickExportRow) error {
+		codes = append(codes, row.ClickedCode)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "sale"}, codes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnalyticRepository_StreamClicksStopsOnError(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewAnalyticRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("FROM click_analytics ca").
+		WithArgs(1, now, now).
+		WillReturnRows(sqlmock.NewRows(exportRowColumns).
+			AddRow(1, "abc", "abc", "", "https://example.com", 1, now, "link", "", "", "", "", "", "").
+			AddRow(2, "abc", "abc", "", "https://example.com", 1, now, "link", "", "", "", "", "", ""))
+
+	stop := errors.New("клиент отключился")
+	calls := 0
+	err := repo.StreamClicks(models.ClickExportFilter{UserID: 1, From: now, To: now}, func(*models.ClickExportRow) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestExportRepository_ClaimNext(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewExportRepository(db)
+
+	mock.ExpectQuery("UPDATE export_jobs SET status = 'running', started_at = NOW\\(\\) WHERE id = \\( SELECT id FROM export_jobs " +
+		"WHERE status = 'pending' OR \\(status = 'running' AND started_at < NOW\\(\\) - make_interval\\(secs => \\$1\\)\\)").
+		WithArgs(time.Hour.Seconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.ClaimNext(time.Hour)
+	assert.ErrorIs(t, err, repositories.ErrExportNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
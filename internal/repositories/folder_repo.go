@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"url-short/internal/models"
+)
+
+type FolderRepository struct {
+	DB *sql.DB
+}
+
+func NewFolderRepository(db *sql.DB) *FolderRepository {
+	return &FolderRepository{DB: db}
+}
+
+var (
+	ErrFolderNotFound = errors.New("папка не найдена")
+	ErrFolderExists   = errors.New("папка с таким именем уже существует")
+)
+
+func (r *FolderRepository) FindByUserID(userID int) ([]models.Folder, error) {
+	query := `
+        SELECT f.id, f.name, COUNT(l.id) 
+        FROM folders f 
+        LEFT JOIN links l ON l.folder_id = f.id AND l.deleted_at IS NULL 
+        WHERE f.user_id = $1 
+        GROUP BY f.id, f.name 
+        ORDER BY f.name
+    `
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	folders := []models.Folder{}
+	for rows.Next() {
+		var f models.Folder
+		if err := rows.Scan(&f.ID, &f.Name, &f.LinkCount); err != nil {
+			return nil, err
+		}
+		folders = append(folders, f)
+	}
+	return folders, rows.Err()
+}
+
+// Exists проверяет, что папка существует и принадлежит пользователю.
+func (r *FolderRepository) Exists(userID, folderID int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM folders WHERE id = $1 AND user_id = $2)",
+		folderID,
+		userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *FolderRepository) Create(userID int, folder *models.Folder) error {
+	err := r.DB.QueryRow(
+		"INSERT INTO folders (user_id, name) VALUES ($1, $2) RETURNING id",
+		userID,
+		folder.Name,
+	).Scan(&folder.ID)
+	if isUniqueViolation(err) {
+		return ErrFolderExists
+	}
+	return err
+}
+
+func (r *FolderRepository) Rename(userID, folderID int, name string) error {
+	res, err := r.DB.Exec(
+		"UPDATE folders SET name = $1 WHERE id = $2 AND user_id = $3",
+		name,
+		folderID,
+		userID,
+	)
+	if isUniqueViolation(err) {
+		return ErrFolderExists
+	}
+	return requireAffected(res, err, ErrFolderNotFound)
+}
+
+// Delete удаляет папку; ссылки из нее остаются без папки.
+func (r *FolderRepository) Delete(userID, folderID int) error {
+	res, err := r.DB.Exec("DELETE FROM folders WHERE id = $1 AND user_id = $2", folderID, userID)
+	return requireAffected(res, err, ErrFolderNotFound)
+}
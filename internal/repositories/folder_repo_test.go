@@ -0,0 +1,53 @@
+package repositories_test
+
+import (
+	"testing"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFolderRepository_Create(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewFolderRepository(db)
+
+	mock.ExpectQuery("INSERT INTO folders").
+		WithArgs(1, "Q3").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	folder := &models.Folder{Name: "Q3"}
+	assert.NoError(t, repo.Create(1, folder))
+	assert.Equal(t, 4, folder.ID)
+}
+
+func TestFolderRepository_Exists(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewFolderRepository(db)
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM folders WHERE id = \\$1 AND user_id = \\$2\\)").
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.Exists(1, 4)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestFolderRepository_Delete_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewFolderRepository(db)
+
+	mock.ExpectExec("DELETE FROM folders WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.Delete(1, 4), repositories.ErrFolderNotFound)
+}
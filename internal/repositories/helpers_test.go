@@ -0,0 +1,10 @@
+package repositories_test
+
+// linkColumns — колонки, которые LinkRepository читает из links.
+var linkColumns = []string{
+	"id", "user_id", "original_url", "short_code", "click_count", "created_at",
+	"track_conversions", "expires_at", "folder_id",
+	"title", "description", "notes", "image_url", "site_name",
+	"domain_id", "domain",
+	"status", "starts_at", "analytics_mode",
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"errors"
+	"url-short/internal/models"
+)
+
+// ErrStatusConflict — состояние ссылки изменилось, пока обрабатывался переход.
+var ErrStatusConflict = errors.New("состояние ссылки изменилось")
+
+// ChangeStatus сохраняет новое состояние ссылки и запись о переходе в одной транзакции.
+// Переход применяется, только если в базе все еще хранится link.Status.
+func (r *LinkRepository) ChangeStatus(link *models.Link, event *models.LinkStatusEvent) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE links SET status = $1, starts_at = $2 WHERE id = $3 AND status = $4",
+		event.ToStatus,
+		event.StartsAt,
+		link.ID,
+		link.Status,
+	)
+	if err := requireAffected(res, err, ErrStatusConflict); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+        INSERT INTO link_status_events (link_id, user_id, from_status, to_status, starts_at) 
+        VALUES ($1, $2, $3, $4, $5) 
+        RETURNING id, created_at
+    `, link.ID, event.UserID, event.FromStatus, event.ToStatus, event.StartsAt).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	event.LinkID = link.ID
+	link.Status = event.ToStatus
+	link.StartsAt = event.StartsAt
+	return nil
+}
+
+// FindStatusEvents возвращает журнал переходов ссылки, старые записи первыми.
+func (r *LinkRepository) FindStatusEvents(linkID int) ([]models.LinkStatusEvent, error) {
+	rows, err := r.DB.Query(`
+        SELECT id, link_id, user_id, from_status, to_status, starts_at, created_at 
+        FROM link_status_events 
+        WHERE link_id = $1 
+        ORDER BY id
+    `, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.LinkStatusEvent{}
+	for rows.Next() {
+		var e models.LinkStatusEvent
+		if err := rows.Scan(&e.ID, &e.LinkID, &e.UserID, &e.FromStatus, &e.ToStatus, &e.StartsAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ClaimExpired отмечает до limit истекших ссылок как уведомленные и возвращает их.
+// Каждая ссылка возвращается один раз, даже если очистку запускают несколько серверов.
+func (r *LinkRepository) ClaimExpired(limit int) ([]models.Link, error) {
+	rows, err := r.DB.Query(`
+        UPDATE links SET expiry_notified_at = NOW() 
+        WHERE id IN ( 
+            SELECT id FROM links 
+            WHERE expires_at <= NOW() AND expiry_notified_at IS NULL AND deleted_at IS NULL 
+            ORDER BY expires_at 
+            LIMIT $1 
+            FOR UPDATE SKIP LOCKED 
+        ) 
+        RETURNING `+linkColumns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.Link{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
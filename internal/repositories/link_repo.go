@@ -3,11 +3,17 @@ package repositories
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"url-short/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type LinkRepository struct {
 	DB *sql.DB
+
+	// CaseMode определяет, как сравниваются коды; пустое значение — CaseSensitive
+	CaseMode models.CaseMode
 }
 
 func NewLinkRepository(db *sql.DB) *LinkRepository {
@@ -16,55 +22,407 @@ func NewLinkRepository(db *sql.DB) *LinkRepository {
 
 var ErrLinkNotFound = errors.New("ссылка не найдена")
 
-func (r *LinkRepository) CreateLink(link *models.Link) error {
+// linkColumns — список колонок, которые читает scanLink.
+const linkColumns = "id, user_id, original_url, short_code, click_count, created_at, track_conversions, expires_at, folder_id, " +
+	"title, description, notes, image_url, site_name, " +
+	"domain_id, COALESCE((SELECT hostname FROM domains WHERE domains.id = links.domain_id), ''), " +
+	"status, starts_at, analytics_mode"
+
+// linkTagsColumn — подзапрос, собирающий теги ссылки в массив.
+const linkTagsColumn = `(
+            SELECT COALESCE(ARRAY_AGG(t.name ORDER BY t.name), '{}') 
+            FROM link_tags lt JOIN tags t ON t.id = lt.tag_id 
+            WHERE lt.link_id = links.id
+        )`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLink(row rowScanner, extra ...any) (*models.Link, error) {
+	var link models.Link
+	dest := append([]any{
+		&link.ID,
+		&link.UserID,
+		&link.OriginalURL,
+		&link.ShortCode,
+		&link.ClickCount,
+		&link.CreatedAt,
+		&link.TrackConversions,
+		&link.ExpiresAt,
+		&link.FolderID,
+		&link.Title,
+		&link.Description,
+		&link.Notes,
+		&link.ImageURL,
+		&link.SiteName,
+		&link.DomainID,
+		&link.Domain,
+		&link.Status,
+		&link.StartsAt,
+		&link.AnalyticsMode,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func insertLink(q DBTX, link *models.Link) error {
 	query := `
-        INSERT INTO links (user_id, original_url, short_code, created_at)
-        VALUES ($1, $2, $3, NOW())
+        INSERT INTO links (
+            user_id, original_url, short_code, track_conversions, expires_at, folder_id,
+            title, description, notes, image_url, site_name, domain_id, normalized_url,
+            status, starts_at, created_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW())
         RETURNING id
     `
-	err := r.DB.QueryRow(
+	if link.Status == "" {
+		link.Status = models.LinkStatusActive
+	}
+	return q.QueryRow(
 		query,
 		link.UserID,
 		link.OriginalURL,
 		link.ShortCode,
+		link.TrackConversions,
+		link.ExpiresAt,
+		link.FolderID,
+		link.Title,
+		link.Description,
+		link.Notes,
+		link.ImageURL,
+		link.SiteName,
+		link.DomainID,
+		nullString(link.NormalizedURL),
+		link.Status,
+		link.StartsAt,
 	).Scan(&link.ID)
-	if err != nil {
+}
+
+func (r *LinkRepository) CreateLink(link *models.Link) error {
+	if err := insertLink(r.DB, link); err != nil {
 		return errors.New("ошибка при создании ссылки")
 	}
 	return nil
 }
 
-func (r *LinkRepository) FindByShortCode(shortCode string) (*models.Link, error) {
+// CreateLinksAtomic создает ссылки вместе с тегами в одной транзакции:
+// либо сохраняются все, либо ни одной.
+func (r *LinkRepository) CreateLinksAtomic(links []*models.Link) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, link := range links {
+		if err := insertLink(tx, link); err != nil {
+			return err
+		}
+		if err := attachTags(tx, link.UserID, link.ID, link.Tags); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CreateLinkAtomic создает ссылку вместе с вариантами назначения и тегами
+// в одной транзакции, чтобы при ошибке не оставалось ссылки без вариантов.
+func (r *LinkRepository) CreateLinkAtomic(link *models.Link, destinations []models.LinkDestination) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertLink(tx, link); err != nil {
+		return err
+	}
+	if len(destinations) > 0 {
+		if err := replaceDestinations(tx, link.ID, destinations); err != nil {
+			return err
+		}
+	}
+	if err := attachTags(tx, link.UserID, link.ID, link.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// linkFilterClause строит условие WHERE для ссылок пользователя и его аргументы.
+// Условие рассчитано на таблицу links без алиаса.
+func linkFilterClause(userID int, filter models.LinkFilter) (string, []any) {
+	args := []any{userID}
+	clause := "links.user_id = $1 AND links.deleted_at IS NULL"
+
+	if filter.FolderID != nil {
+		args = append(args, *filter.FolderID)
+		clause += fmt.Sprintf(" AND links.folder_id = $%d", len(args))
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		clause += fmt.Sprintf(` AND EXISTS (
+            SELECT 1 FROM link_tags lt JOIN tags t ON t.id = lt.tag_id 
+            WHERE lt.link_id = links.id AND t.name = $%d
+        )`, len(args))
+	}
+	return clause, args
+}
+
+// FindByUserID возвращает ссылки пользователя вместе с тегами, новые первыми.
+func (r *LinkRepository) FindByUserID(userID int, filter models.LinkFilter) ([]models.Link, error) {
+	where, args := linkFilterClause(userID, filter)
 	query := `
-        SELECT id, user_id, original_url, short_code, created_at 
+        SELECT ` + linkColumns + `, ` + linkTagsColumn + ` 
         FROM links 
-        WHERE LOWER(short_code) = LOWER($1)  -- Регистронезависимый поиск
+        WHERE ` + where + ` 
+        ORDER BY created_at DESC, id DESC
     `
-	row := r.DB.QueryRow(query, shortCode)
-	var link models.Link
-	err := row.Scan(
-		&link.ID,
-		&link.UserID,
-		&link.OriginalURL,
-		&link.ShortCode,
-		&link.CreatedAt,
-	)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []models.Link
+	for rows.Next() {
+		var tags []string
+		link, err := scanLink(rows, pq.Array(&tags))
+		if err != nil {
+			return nil, err
+		}
+		link.Tags = tags
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+// UpdateMetadata сохраняет заголовок, описание, заметки и данные Open Graph ссылки.
+func (r *LinkRepository) UpdateMetadata(link *models.Link) error {
+	_, err := r.DB.Exec(`
+        UPDATE links 
+        SET title = $1, description = $2, notes = $3, image_url = $4, site_name = $5 
+        WHERE id = $6
+    `, link.Title, link.Description, link.Notes, link.ImageURL, link.SiteName, link.ID)
+	return err
+}
+
+// SetFolder перемещает ссылку в папку; nil убирает ее из папки.
+func (r *LinkRepository) SetFolder(linkID int, folderID *int) error {
+	_, err := r.DB.Exec("UPDATE links SET folder_id = $1 WHERE id = $2", folderID, linkID)
+	return err
+}
+
+// SetAnalyticsMode задает режим аналитики ссылки; пустой режим — режим сервера.
+func (r *LinkRepository) SetAnalyticsMode(linkID int, mode string) error {
+	res, err := r.DB.Exec("UPDATE links SET analytics_mode = $1 WHERE id = $2 AND deleted_at IS NULL", mode, linkID)
+	return requireAffected(res, err, ErrLinkNotFound)
+}
+
+// codeMatch — условие сравнения short_code с параметром $n в текущем режиме регистра.
+func (r *LinkRepository) codeMatch(n int) string {
+	if r.CaseMode == models.CaseInsensitive {
+		return fmt.Sprintf("LOWER(short_code) = LOWER($%d)", n)
+	}
+	return fmt.Sprintf("short_code = $%d", n)
+}
+
+// FindByShortCode ищет ссылку по коду в пределах домена; domainID == nil — основной домен.
+// Удаленные ссылки не находятся.
+func (r *LinkRepository) FindByShortCode(domainID *int, shortCode string) (*models.Link, error) {
+	query := `
+        SELECT ` + linkColumns + ` 
+        FROM links 
+        WHERE domain_id IS NOT DISTINCT FROM $1 AND ` + r.codeMatch(2) + ` AND deleted_at IS NULL`
+	link, err := scanLink(r.DB.QueryRow(query, domainID, shortCode))
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrLinkNotFound
 	}
-	return &link, err
+	return link, err
 }
 
-func (r *LinkRepository) IncrementClickCount(shortCode string) error {
-	_, err := r.DB.Exec("UPDATE links SET click_count = click_count + 1 WHERE short_code = $1", shortCode)
-	return err
+// IncrementClickCount засчитывает клик и возвращает новое значение счетчика.
+func (r *LinkRepository) IncrementClickCount(linkID int) (int, error) {
+	var count int
+	err := r.DB.QueryRow("UPDATE links SET click_count = click_count + 1 WHERE id = $1 RETURNING click_count", linkID).
+		Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrLinkNotFound
+	}
+	return count, err
 }
 
-func (r *LinkRepository) IsShortCodeExist(code string) (bool, error) {
+// IsShortCodeExist проверяет, занят ли код на домене; domainID == nil — основной домен.
+// Коды удаленных ссылок заняты, пока ссылка хранится, а после очистки — до конца карантина.
+func (r *LinkRepository) IsShortCodeExist(domainID *int, code string) (bool, error) {
 	var exists bool
 	err := r.DB.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM links WHERE short_code = $1)",
+		"SELECT EXISTS(SELECT 1 FROM links WHERE domain_id IS NOT DISTINCT FROM $1 AND "+r.codeMatch(2)+") "+
+			"OR EXISTS(SELECT 1 FROM link_aliases WHERE domain_id IS NOT DISTINCT FROM $1 AND "+r.codeMatch(2)+") "+
+			"OR EXISTS(SELECT 1 FROM quarantined_codes WHERE domain_id IS NOT DISTINCT FROM $1 AND "+r.codeMatch(2)+" AND released_at > NOW())",
+		domainID,
 		code,
 	).Scan(&exists)
 	return exists, err
 }
+
+// FindReusable ищет действующую ссылку пользователя на домене с тем же нормализованным
+// адресом назначения. Ссылки с вариантами A/B-теста не переиспользуются.
+func (r *LinkRepository) FindReusable(userID int, domainID *int, normalizedURL string) (*models.Link, error) {
+	query := `
+        SELECT ` + linkColumns + ` 
+        FROM links 
+        WHERE user_id = $1 
+          AND domain_id IS NOT DISTINCT FROM $2 
+          AND normalized_url = $3 
+          AND deleted_at IS NULL 
+          AND (expires_at IS NULL OR expires_at > NOW()) 
+          AND NOT EXISTS (SELECT 1 FROM link_destinations d WHERE d.link_id = links.id AND d.retired_at IS NULL) 
+        ORDER BY id 
+        LIMIT 1
+    `
+	link, err := scanLink(r.DB.QueryRow(query, userID, domainID, normalizedURL))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	return link, err
+}
+
+// BackfillNormalizedURLs заполняет normalized_url у ссылок, созданных до его появления,
+// пачками по batchSize. Адреса, которые не удалось разобрать, сохраняются как есть.
+// Возвращает количество обновленных ссылок.
+func (r *LinkRepository) BackfillNormalizedURLs(normalize func(string) (string, error), batchSize int) (int, error) {
+	total := 0
+	for {
+		rows, err := r.DB.Query(
+			"SELECT id, original_url FROM links WHERE normalized_url IS NULL ORDER BY id LIMIT $1",
+			batchSize,
+		)
+		if err != nil {
+			return total, err
+		}
+
+		type pending struct {
+			id  int
+			url string
+		}
+		var batch []pending
+		for rows.Next() {
+			var p pending
+			if err := rows.Scan(&p.id, &p.url); err != nil {
+				rows.Close()
+				return total, err
+			}
+			batch = append(batch, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		for _, p := range batch {
+			normalized, err := normalize(p.url)
+			if err != nil {
+				normalized = p.url
+			}
+			if _, err := r.DB.Exec("UPDATE links SET normalized_url = $1 WHERE id = $2", normalized, p.id); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+}
+
+// FindByAlias ищет ссылку по алиасу и возвращает ее вместе с кодом алиаса
+// в том виде, в котором он сохранен.
+func (r *LinkRepository) FindByAlias(domainID *int, code string) (*models.Link, string, error) {
+	var linkID int
+	var alias string
+	err := r.DB.QueryRow(
+		"SELECT link_id, short_code FROM link_aliases WHERE domain_id IS NOT DISTINCT FROM $1 AND "+r.codeMatch(2),
+		domainID,
+		code,
+	).Scan(&linkID, &alias)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	link, err := scanLink(r.DB.QueryRow("SELECT "+linkColumns+" FROM links WHERE id = $1 AND deleted_at IS NULL", linkID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrLinkNotFound
+	}
+	return link, alias, err
+}
+
+// caseInsensitiveIndexes — уникальные индексы, которые действуют только в режиме CaseInsensitive.
+var caseInsensitiveIndexes = []struct{ Name, Table string }{
+	{"links_domain_short_code_ci_key", "links"},
+	{"link_aliases_domain_short_code_ci_key", "link_aliases"},
+}
+
+// CaseConflictError — включить CaseInsensitive мешают коды, различающиеся только регистром.
+type CaseConflictError struct {
+	Conflicts []models.CaseConflict
+}
+
+func (e *CaseConflictError) Error() string {
+	return fmt.Sprintf("найдено %d групп кодов, различающихся только регистром", len(e.Conflicts))
+}
+
+// ApplyCaseMode приводит уникальные индексы в соответствие с режимом: в CaseInsensitive
+// создает индексы по LOWER(short_code) для ссылок и алиасов, в CaseSensitive удаляет их. Если включению
+// мешают конфликтующие коды, возвращает *CaseConflictError со списком конфликтов.
+func (r *LinkRepository) ApplyCaseMode() error {
+	if r.CaseMode != models.CaseInsensitive {
+		for _, index := range caseInsensitiveIndexes {
+			if _, err := r.DB.Exec("DROP INDEX IF EXISTS " + index.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	conflicts, err := r.FindCaseConflicts()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &CaseConflictError{Conflicts: conflicts}
+	}
+
+	for _, index := range caseInsensitiveIndexes {
+		_, err := r.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS " + index.Name + " ON " + index.Table +
+			" (COALESCE(domain_id, 0), LOWER(short_code))")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindCaseConflicts возвращает коды, совпадающие без учета регистра в пределах домена.
+func (r *LinkRepository) FindCaseConflicts() ([]models.CaseConflict, error) {
+	rows, err := r.DB.Query("SELECT domain, codes FROM short_code_case_conflicts ORDER BY domain")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []models.CaseConflict
+	for rows.Next() {
+		var c models.CaseConflict
+		if err := rows.Scan(&c.Domain, pq.Array(&c.Codes)); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
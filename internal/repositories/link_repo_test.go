@@ -1,12 +1,17 @@
 package repositories_test
 
 import (
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 	"url-short/internal/models"
 	"url-short/internal/repositories"
+	"url-short/internal/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLinkRepository_CreateLink(t *testing.T) {
@@ -24,7 +29,7 @@ func TestLinkRepository_CreateLink(t *testing.T) {
 	}
 
 	mock.ExpectQuery("INSERT INTO links").
-		WithArgs(link.UserID, link.OriginalURL, link.ShortCode).
+		WithArgs(link.UserID, link.OriginalURL, link.ShortCode, link.TrackConversions, nil, nil, "", "", "", "", "", nil, nil, "active", nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	err = repo.CreateLink(link)
@@ -42,14 +47,288 @@ func TestLinkRepository_FindByShortCode(t *testing.T) {
 		UserID:      1,
 		OriginalURL: "https://example.com",
 		ShortCode:   "test123",
+		Status:      models.LinkStatusActive,
 	}
 
-	mock.ExpectQuery("SELECT id, user_id, original_url, short_code, created_at FROM links WHERE short_code = ?").
-		WithArgs("test123").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "original_url", "short_code", "created_at"}).
-			AddRow(expectedLink.ID, expectedLink.UserID, expectedLink.OriginalURL, expectedLink.ShortCode, expectedLink.CreatedAt))
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id IS NOT DISTINCT FROM \\$1 AND short_code = \\$2").
+		WithArgs(nil, "test123").
+		WillReturnRows(sqlmock.NewRows(linkColumns).
+			AddRow(expectedLink.ID, expectedLink.UserID, expectedLink.OriginalURL, expectedLink.ShortCode, 0, expectedLink.CreatedAt, false, nil, nil, "", "", "", "", "", nil, "", "active", nil, ""))
 
-	link, err := repo.FindByShortCode("test123")
+	link, err := repo.FindByShortCode(nil, "test123")
 	assert.NoError(t, err)
 	assert.Equal(t, expectedLink, link)
 }
+
+func TestLinkRepository_IncrementClickCount(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	mock.ExpectQuery("UPDATE links SET click_count = click_count \\+ 1 WHERE id = \\$1 RETURNING click_count").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"click_count"}).AddRow(100))
+	mock.ExpectQuery("UPDATE links SET click_count").
+		WithArgs(2).
+		WillReturnError(sql.ErrNoRows)
+
+	count, err := repo.IncrementClickCount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, count)
+
+	_, err = repo.IncrementClickCount(2)
+	assert.ErrorIs(t, err, repositories.ErrLinkNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_CaseInsensitive(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+	repo.CaseMode = models.CaseInsensitive
+
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE domain_id IS NOT DISTINCT FROM \\$1 AND LOWER\\(short_code\\) = LOWER\\(\\$2\\)").
+		WithArgs(nil, "ABC").
+		WillReturnRows(sqlmock.NewRows(linkColumns).
+			AddRow(1, 1, "https://example.com", "abc", 0, time.Time{}, false, nil, nil, "", "", "", "", "", nil, "", "active", nil, ""))
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM links WHERE domain_id IS NOT DISTINCT FROM \\$1 AND LOWER\\(short_code\\) = LOWER\\(\\$2\\)\\)").
+		WithArgs(nil, "Abc").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	link, err := repo.FindByShortCode(nil, "ABC")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", link.ShortCode)
+
+	exists, err := repo.IsShortCodeExist(nil, "Abc")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_ApplyCaseMode(t *testing.T) {
+	t.Run("Reports conflicts instead of creating the index", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repositories.NewLinkRepository(db)
+		repo.CaseMode = models.CaseInsensitive
+
+		mock.ExpectQuery("SELECT domain, codes FROM short_code_case_conflicts").
+			WillReturnRows(sqlmock.NewRows([]string{"domain", "codes"}).
+				AddRow("", "{abC,ABC}").
+				AddRow("go.brand.com", "{Sale,sale}"))
+
+		err := repo.ApplyCaseMode()
+		var conflictErr *repositories.CaseConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, []models.CaseConflict{
+			{Domain: "", Codes: []string{"abC", "ABC"}},
+			{Domain: "go.brand.com", Codes: []string{"Sale", "sale"}},
+		}, conflictErr.Conflicts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Creates the case-insensitive index", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repositories.NewLinkRepository(db)
+		repo.CaseMode = models.CaseInsensitive
+
+		mock.ExpectQuery("SELECT domain, codes FROM short_code_case_conflicts").
+			WillReturnRows(sqlmock.NewRows([]string{"domain", "codes"}))
+		mock.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS links_domain_short_code_ci_key ON links \\(COALESCE\\(domain_id, 0\\), LOWER\\(short_code\\)\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS link_aliases_domain_short_code_ci_key ON link_aliases").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.ApplyCaseMode())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Drops the index in case-sensitive mode", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+
+		repo := repositories.NewLinkRepository(db)
+
+		mock.ExpectExec("DROP INDEX IF EXISTS links_domain_short_code_ci_key").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DROP INDEX IF EXISTS link_aliases_domain_short_code_ci_key").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.ApplyCaseMode())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLinkRepository_CreateLinksAtomic(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	links := []*models.Link{
+		{UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one", Tags: []string{"sale"}},
+		{UserID: 1, OriginalURL: "https://example.com/2", ShortCode: "two"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO links").
+		WithArgs(1, "https://example.com/1", "one", false, nil, nil, "", "", "", "", "", nil, nil, "active", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery("INSERT INTO tags").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO link_tags").
+		WithArgs(10, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO links").
+		WithArgs(1, "https://example.com/2", "two", false, nil, nil, "", "", "", "", "", nil, nil, "active", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectCommit()
+
+	err := repo.CreateLinksAtomic(links)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, links[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_CreateLinksAtomic_Rollback(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO links").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery("INSERT INTO links").
+		WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectRollback()
+
+	err := repo.CreateLinksAtomic([]*models.Link{
+		{UserID: 1, OriginalURL: "https://example.com/1", ShortCode: "one"},
+		{UserID: 1, OriginalURL: "https://example.com/2", ShortCode: "one"},
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_CreateLinkAtomic(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockClosure func(mock sqlmock.Sqlmock)
+		wantErr     bool
+	}{
+		{
+			name: "Link, destinations and tags in one transaction",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+				mock.ExpectExec("UPDATE link_destinations SET retired_at").
+					WithArgs(10).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO link_destinations").
+					WithArgs(10, "https://a.example.com", 100).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO tags").
+					WithArgs(1, "sale").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectExec("INSERT INTO link_tags").
+					WithArgs(10, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Destination failure rolls back the link",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO links").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+				mock.ExpectExec("UPDATE link_destinations SET retired_at").
+					WithArgs(10).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO link_destinations").
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repositories.NewLinkRepository(db)
+			tt.mockClosure(mock)
+
+			link := &models.Link{UserID: 1, OriginalURL: "https://example.com", ShortCode: "ab", Tags: []string{"sale"}}
+			err := repo.CreateLinkAtomic(link, []models.LinkDestination{{URL: "https://a.example.com", Weight: 100}})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 10, link.ID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLinkRepository_FindByUserID(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	createdAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM links WHERE links.user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(append(linkColumns, "tags")).
+			AddRow(10, 1, "https://example.com/1", "one", 42, createdAt, false, nil, nil, "", "", "", "", "", nil, "", "active", nil, "", "{sale,summer}").
+			AddRow(11, 1, "https://example.com/2", "two", 0, createdAt, false, nil, nil, "", "", "", "", "", 5, "go.brand.com", "paused", nil, "", "{}"))
+
+	links, err := repo.FindByUserID(1, models.LinkFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+	assert.Equal(t, 42, links[0].ClickCount)
+	assert.Equal(t, []string{"sale", "summer"}, links[0].Tags)
+	assert.Empty(t, links[1].Tags)
+	assert.Nil(t, links[0].DomainID)
+	assert.Equal(t, "go.brand.com", links[1].Domain)
+	assert.Equal(t, models.LinkStatusPaused, links[1].Status)
+}
+
+func TestLinkRepository_BackfillNormalizedURLs(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	mock.ExpectQuery("SELECT id, original_url FROM links WHERE normalized_url IS NULL").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "original_url"}).
+			AddRow(1, "HTTPS://Example.com/").
+			AddRow(2, "::bad"))
+	mock.ExpectExec("UPDATE links SET normalized_url = \\$1 WHERE id = \\$2").
+		WithArgs("https://example.com/", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE links SET normalized_url = \\$1 WHERE id = \\$2").
+		WithArgs("::bad", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, original_url FROM links WHERE normalized_url IS NULL").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "original_url"}))
+
+	n, err := repo.BackfillNormalizedURLs(utils.NormalizeURL, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// SoftDelete помечает ссылку удаленной. Ссылка перестает открываться,
+// но ее клики и код сохраняются до очистки.
+func (r *LinkRepository) SoftDelete(linkID int) error {
+	res, err := r.DB.Exec("UPDATE links SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", linkID)
+	return requireAffected(res, err, ErrLinkNotFound)
+}
+
+// Restore возвращает удаленную ссылку, если ее еще не удалила очистка.
+func (r *LinkRepository) Restore(linkID int) error {
+	res, err := r.DB.Exec("UPDATE links SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", linkID)
+	return requireAffected(res, err, ErrLinkNotFound)
+}
+
+// FindDeleted возвращает удаленные ссылки пользователя, недавно удаленные первыми.
+func (r *LinkRepository) FindDeleted(userID int) ([]models.Link, error) {
+	rows, err := r.DB.Query(`
+        SELECT `+linkColumns+`, deleted_at 
+        FROM links 
+        WHERE user_id = $1 AND deleted_at IS NOT NULL 
+        ORDER BY deleted_at DESC, id DESC 
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.Link{}
+	for rows.Next() {
+		var deletedAt time.Time
+		link, err := scanLink(rows, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		link.DeletedAt = &deletedAt
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+// FindDeletedByShortCode ищет удаленную ссылку по коду в пределах домена.
+func (r *LinkRepository) FindDeletedByShortCode(domainID *int, shortCode string) (*models.Link, error) {
+	var deletedAt time.Time
+	link, err := scanLink(r.DB.QueryRow(`
+        SELECT `+linkColumns+`, deleted_at 
+        FROM links 
+        WHERE domain_id IS NOT DISTINCT FROM $1 AND `+r.codeMatch(2)+` AND deleted_at IS NOT NULL`,
+		domainID, shortCode,
+	), &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	link.DeletedAt = &deletedAt
+	return link, nil
+}
+
+// PurgeDeleted окончательно удаляет ссылки, удаленные раньше retention назад, вместе
+// с кликами и остальными связанными данными, пачками по batchSize. Коды ссылок и их
+// алиасов переносятся в карантин, если с момента удаления прошло меньше quarantine.
+// Заодно удаляются записи карантина, срок которых истек. Возвращает число удаленных ссылок.
+func (r *LinkRepository) PurgeDeleted(retention, quarantine time.Duration, batchSize int) (int, error) {
+	total := 0
+	for {
+		n, err := r.purgeBatch(retention, quarantine, batchSize)
+		total += n
+		if err != nil || n < batchSize {
+			if err == nil {
+				_, err = r.DB.Exec("DELETE FROM quarantined_codes WHERE released_at <= NOW()")
+			}
+			return total, err
+		}
+	}
+}
+
+func (r *LinkRepository) purgeBatch(retention, quarantine time.Duration, batchSize int) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+        SELECT id FROM links 
+        WHERE deleted_at < NOW() - make_interval(secs => $1) 
+        ORDER BY id 
+        LIMIT $2 
+        FOR UPDATE SKIP LOCKED 
+    `, retention.Seconds(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO quarantined_codes (domain_id, short_code, released_at) 
+        SELECT c.domain_id, c.short_code, l.deleted_at + make_interval(secs => $2) 
+        FROM links l 
+        JOIN ( 
+            SELECT id AS link_id, domain_id, short_code FROM links WHERE id = ANY($1)
+            UNION ALL
+            SELECT link_id, domain_id, short_code FROM link_aliases WHERE link_id = ANY($1)
+        ) c ON c.link_id = l.id 
+        WHERE l.deleted_at + make_interval(secs => $2) > NOW() 
+    `, pq.Array(ids), quarantine.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	// Клики, алиасы, варианты и остальные связанные строки удаляются каскадом
+	if _, err := tx.Exec("DELETE FROM links WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,76 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkRepository_SoftDelete(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	mock.ExpectExec("UPDATE links SET deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.SoftDelete(7)
+	assert.ErrorIs(t, err, repositories.ErrLinkNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_PurgeDeleted(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+	retention := 30 * 24 * time.Hour
+	quarantine := 90 * 24 * time.Hour
+
+	// Первая пачка заполнена целиком, поэтому запрашивается следующая
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM links WHERE deleted_at < NOW\\(\\) - make_interval\\(secs => \\$1\\)").
+		WithArgs(retention.Seconds(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(5))
+	mock.ExpectExec("INSERT INTO quarantined_codes").
+		WithArgs(pq.Array([]int64{3, 5}), quarantine.Seconds()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM links WHERE id = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]int64{3, 5})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM links WHERE deleted_at").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	mock.ExpectExec("DELETE FROM quarantined_codes WHERE released_at <= NOW\\(\\)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := repo.PurgeDeleted(retention, quarantine, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLinkRepository_IsShortCodeExist_Quarantine(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewLinkRepository(db)
+
+	mock.ExpectQuery("OR EXISTS\\(SELECT 1 FROM quarantined_codes WHERE domain_id IS NOT DISTINCT FROM \\$1 AND short_code = \\$2 AND released_at > NOW\\(\\)\\)").
+		WithArgs(nil, "promo").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.IsShortCodeExist(nil, "promo")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"url-short/internal/models"
+)
+
+var ErrVersionNotFound = errors.New("версия ссылки не найдена")
+
+// SaveVersion применяет к ссылке настройки next и записывает их в историю новой версией
+// от имени userID. При первой правке в историю сначала попадает исходное состояние prev
+// как версия 1. Нормализованный адрес берется из link.NormalizedURL.
+func (r *LinkRepository) SaveVersion(link *models.Link, prev, next models.LinkSettings, userID int) (*models.LinkVersion, error) {
+	prevDestinations, err := json.Marshal(prev.Destinations)
+	if err != nil {
+		return nil, err
+	}
+	nextDestinations, err := json.Marshal(next.Destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current int
+	if err := tx.QueryRow("SELECT version FROM links WHERE id = $1 FOR UPDATE", link.ID).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	if current == 1 {
+		// Исходное состояние приписывается владельцу ссылки и моменту ее создания
+		_, err = tx.Exec(`
+            INSERT INTO link_versions (link_id, version, user_id, original_url, destinations, expires_at, track_conversions, created_at) 
+            VALUES ($1, 1, $2, $3, $4, $5, $6, $7) 
+            ON CONFLICT (link_id, version) DO NOTHING 
+        `, link.ID, link.UserID, prev.OriginalURL, prevDestinations, prev.ExpiresAt, prev.TrackConversions, link.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// С новым сроком действия ссылка снова попадет в событие link.expired
+	_, err = tx.Exec(`
+        UPDATE links 
+        SET original_url = $1, normalized_url = $2, expires_at = $3, track_conversions = $4, version = $5, 
+            expiry_notified_at = CASE WHEN expires_at IS DISTINCT FROM $3 THEN NULL ELSE expiry_notified_at END 
+        WHERE id = $6 
+    `, next.OriginalURL, nullString(link.NormalizedURL), next.ExpiresAt, next.TrackConversions, current+1, link.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Варианты пересоздаются, только если они изменились: клики привязаны к их id
+	if !reflect.DeepEqual(prev.Destinations, next.Destinations) {
+		destinations := make([]models.LinkDestination, 0, len(next.Destinations))
+		for _, d := range next.Destinations {
+			destinations = append(destinations, models.LinkDestination{URL: d.URL, Weight: d.Weight})
+		}
+		if err := replaceDestinations(tx, link.ID, destinations); err != nil {
+			return nil, err
+		}
+	}
+
+	version := &models.LinkVersion{Version: current + 1, UserID: &userID, Settings: next}
+	err = tx.QueryRow(`
+        INSERT INTO link_versions (link_id, version, user_id, original_url, destinations, expires_at, track_conversions) 
+        VALUES ($1, $2, $3, $4, $5, $6, $7) 
+        RETURNING created_at 
+    `, link.ID, version.Version, userID, next.OriginalURL, nextDestinations, next.ExpiresAt, next.TrackConversions).Scan(&version.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	link.OriginalURL = next.OriginalURL
+	link.ExpiresAt = next.ExpiresAt
+	link.TrackConversions = next.TrackConversions
+	return version, nil
+}
+
+const versionColumns = "version, user_id, original_url, destinations, expires_at, track_conversions, created_at"
+
+func scanVersion(row rowScanner) (*models.LinkVersion, error) {
+	var v models.LinkVersion
+	var destinations []byte
+	err := row.Scan(
+		&v.Version,
+		&v.UserID,
+		&v.Settings.OriginalURL,
+		&destinations,
+		&v.Settings.ExpiresAt,
+		&v.Settings.TrackConversions,
+		&v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(destinations, &v.Settings.Destinations); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindVersions возвращает историю версий ссылки по возрастанию номера.
+// Пока ссылку не правили, история пуста.
+func (r *LinkRepository) FindVersions(linkID int) ([]models.LinkVersion, error) {
+	rows, err := r.DB.Query("SELECT "+versionColumns+" FROM link_versions WHERE link_id = $1 ORDER BY version", linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.LinkVersion{}
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+// FindVersion возвращает одну версию ссылки.
+func (r *LinkRepository) FindVersion(linkID, version int) (*models.LinkVersion, error) {
+	v, err := scanVersion(r.DB.QueryRow(
+		"SELECT "+versionColumns+" FROM link_versions WHERE link_id = $1 AND version = $2",
+		linkID,
+		version,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	return v, err
+}
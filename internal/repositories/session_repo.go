@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("сессия не найдена")
+
+type SessionRepository struct {
+	DB *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+// Create сохраняет новую сессию сроком на ttl. ID и UserID задает вызывающий,
+// время создания и истечения заполняются из базы.
+func (r *SessionRepository) Create(s *models.Session, ttl time.Duration) error {
+	return r.DB.QueryRow(`
+        INSERT INTO user_sessions (id, user_id, user_agent, ip_address, expires_at) 
+        VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5)) 
+        RETURNING created_at, last_seen_at, expires_at
+    `, s.ID, s.UserID, s.UserAgent, s.IPAddress, ttl.Seconds()).Scan(&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+}
+
+// SessionActive сообщает, что сессия пользователя не отозвана и не истекла, и не чаще
+// раза в минуту обновляет время ее последней активности.
+func (r *SessionRepository) SessionActive(userID int, sessionID string) (bool, error) {
+	var stale bool
+	err := r.DB.QueryRow(`
+        SELECT last_seen_at < NOW() - interval '1 minute' 
+        FROM user_sessions 
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+    `, sessionID, userID).Scan(&stale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if stale {
+		if _, err := r.DB.Exec("UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1", sessionID); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// FindActive возвращает действующие сессии пользователя, недавно активные первыми.
+func (r *SessionRepository) FindActive(userID int) ([]models.Session, error) {
+	rows, err := r.DB.Query(`
+        SELECT id, user_agent, ip_address, created_at, last_seen_at, expires_at 
+        FROM user_sessions 
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() 
+        ORDER BY last_seen_at DESC
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s := models.Session{UserID: userID}
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke отзывает сессию пользователя.
+func (r *SessionRepository) Revoke(userID int, sessionID string) error {
+	res, err := r.DB.Exec(
+		"UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()",
+		sessionID, userID,
+	)
+	return requireAffected(res, err, ErrSessionNotFound)
+}
+
+// DeleteExpired удаляет истекшие и отозванные сессии и возвращает их число.
+func (r *SessionRepository) DeleteExpired() (int64, error) {
+	res, err := r.DB.Exec("DELETE FROM user_sessions WHERE expires_at <= NOW() OR revoked_at IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_SessionActive(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockClosure func(mock sqlmock.Sqlmock)
+		expected    bool
+	}{
+		{
+			name: "Recently seen",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_sessions WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL AND expires_at > NOW\\(\\)").
+					WithArgs("sid", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"stale"}).AddRow(false))
+			},
+			expected: true,
+		},
+		{
+			name: "Stale activity is updated",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_sessions").
+					WithArgs("sid", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"stale"}).AddRow(true))
+				mock.ExpectExec("UPDATE user_sessions SET last_seen_at = NOW\\(\\) WHERE id = \\$1").
+					WithArgs("sid").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expected: true,
+		},
+		{
+			name: "Revoked or expired",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_sessions").
+					WithArgs("sid", 1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+			tt.mockClosure(mock)
+
+			active, err := repositories.NewSessionRepository(db).SessionActive(1, "sid")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
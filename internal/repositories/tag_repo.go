@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"url-short/internal/models"
+)
+
+type TagRepository struct {
+	DB *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{DB: db}
+}
+
+var (
+	ErrTagNotFound = errors.New("тег не найден")
+	ErrTagExists   = errors.New("тег с таким именем уже существует")
+)
+
+func (r *TagRepository) FindByUserID(userID int) ([]models.Tag, error) {
+	query := `
+        SELECT t.id, t.name, COUNT(lt.link_id) 
+        FROM tags t 
+        LEFT JOIN link_tags lt ON lt.tag_id = t.id 
+        WHERE t.user_id = $1 
+        GROUP BY t.id, t.name 
+        ORDER BY t.name
+    `
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.LinkCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (r *TagRepository) Create(userID int, tag *models.Tag) error {
+	err := r.DB.QueryRow(
+		"INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING id",
+		userID,
+		tag.Name,
+	).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return err
+}
+
+func (r *TagRepository) Rename(userID, tagID int, name string) error {
+	res, err := r.DB.Exec(
+		"UPDATE tags SET name = $1 WHERE id = $2 AND user_id = $3",
+		name,
+		tagID,
+		userID,
+	)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return requireAffected(res, err, ErrTagNotFound)
+}
+
+// Delete удаляет тег и снимает его со всех ссылок.
+func (r *TagRepository) Delete(userID, tagID int) error {
+	res, err := r.DB.Exec("DELETE FROM tags WHERE id = $1 AND user_id = $2", tagID, userID)
+	return requireAffected(res, err, ErrTagNotFound)
+}
+
+// AttachToLink привязывает к ссылке теги по именам, создавая недостающие.
+func (r *TagRepository) AttachToLink(userID, linkID int, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := attachTags(tx, userID, linkID, names); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceForLink заменяет набор тегов ссылки; пустой список снимает все теги.
+func (r *TagRepository) ReplaceForLink(userID, linkID int, names []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM link_tags WHERE link_id = $1", linkID); err != nil {
+		return err
+	}
+	if err := attachTags(tx, userID, linkID, names); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func attachTags(q DBTX, userID, linkID int, names []string) error {
+	for _, name := range names {
+		var tagID int
+		err := q.QueryRow(`
+            INSERT INTO tags (user_id, name) VALUES ($1, $2)
+            ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+            RETURNING id
+        `, userID, name).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.Exec(
+			"INSERT INTO link_tags (link_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			linkID,
+			tagID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repositories_test
+
+import (
+	"testing"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagRepository_FindByUserID(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewTagRepository(db)
+
+	mock.ExpectQuery("SELECT t.id, t.name, COUNT\\(lt.link_id\\) FROM tags t").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "count"}).
+			AddRow(1, "sale", 3).
+			AddRow(2, "summer", 0))
+
+	tags, err := repo.FindByUserID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Tag{{ID: 1, Name: "sale", LinkCount: 3}, {ID: 2, Name: "summer"}}, tags)
+}
+
+func TestTagRepository_Create_Duplicate(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewTagRepository(db)
+
+	mock.ExpectQuery("INSERT INTO tags").
+		WithArgs(1, "sale").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err := repo.Create(1, &models.Tag{Name: "sale"})
+	assert.ErrorIs(t, err, repositories.ErrTagExists)
+}
+
+func TestTagRepository_Rename_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewTagRepository(db)
+
+	mock.ExpectExec("UPDATE tags SET name = \\$1 WHERE id = \\$2 AND user_id = \\$3").
+		WithArgs("new", 5, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Rename(1, 5, "new")
+	assert.ErrorIs(t, err, repositories.ErrTagNotFound)
+}
+
+func TestTagRepository_ReplaceForLink(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewTagRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM link_tags WHERE link_id = \\$1").
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("INSERT INTO tags").
+		WithArgs(1, "sale").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO link_tags").
+		WithArgs(10, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.ReplaceForLink(1, 10, []string{"sale"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
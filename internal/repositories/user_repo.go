@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 	"url-short/internal/models"
 )
 
@@ -46,3 +47,108 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	}
 	return user, nil
 }
+
+// FindByID возвращает пользователя вместе с хешем пароля, датой регистрации,
+// ожидающим подтверждения email и выбранным языком.
+func (r *UserRepository) FindByID(id int) (*models.User, error) {
+	user := &models.User{}
+	err := r.DB.QueryRow(
+		"SELECT id, username, email, password_hash, created_at, COALESCE(pending_email, ''), locale FROM users WHERE id = $1",
+		id,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.PendingEmail, &user.Locale)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	return user, nil
+}
+
+var (
+	ErrUsernameTaken     = errors.New("имя пользователя уже занято")
+	ErrEmailTaken        = errors.New("email уже используется")
+	ErrInvalidEmailToken = errors.New("ссылка подтверждения недействительна или устарела")
+)
+
+// UpdateUsername меняет имя пользователя.
+func (r *UserRepository) UpdateUsername(userID int, username string) error {
+	res, err := r.DB.Exec("UPDATE users SET username = $2 WHERE id = $1", userID, username)
+	if isUniqueViolation(err) {
+		return ErrUsernameTaken
+	}
+	return requireAffected(res, err, ErrUserNotFound)
+}
+
+// FindLocale возвращает язык, выбранный пользователем; пустая строка — не выбран.
+func (r *UserRepository) FindLocale(userID int) (string, error) {
+	var locale string
+	err := r.DB.QueryRow("SELECT locale FROM users WHERE id = $1", userID).Scan(&locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	return locale, err
+}
+
+// UpdateLocale сохраняет выбранный пользователем язык.
+func (r *UserRepository) UpdateLocale(userID int, locale string) error {
+	res, err := r.DB.Exec("UPDATE users SET locale = $2 WHERE id = $1", userID, locale)
+	return requireAffected(res, err, ErrUserNotFound)
+}
+
+// RequestEmailChange запоминает новый email и SHA-256 токена подтверждения,
+// который действует ttl. Предыдущий неподтвержденный запрос заменяется.
+func (r *UserRepository) RequestEmailChange(userID int, email, tokenHash string, ttl time.Duration) error {
+	res, err := r.DB.Exec(`
+        UPDATE users 
+        SET pending_email = $2, email_token_hash = $3, email_token_expires_at = NOW() + make_interval(secs => $4) 
+        WHERE id = $1
+    `, userID, email, tokenHash, ttl.Seconds())
+	return requireAffected(res, err, ErrUserNotFound)
+}
+
+// ConfirmEmail применяет новый email по SHA-256 токена из письма и возвращает
+// обновленного пользователя.
+func (r *UserRepository) ConfirmEmail(tokenHash string) (*models.User, error) {
+	user := &models.User{}
+	err := r.DB.QueryRow(`
+        UPDATE users 
+        SET email = pending_email, pending_email = NULL, email_token_hash = NULL, email_token_expires_at = NULL 
+        WHERE email_token_hash = $1 AND email_token_expires_at > NOW() 
+        RETURNING id, username, email, created_at, locale
+    `, tokenHash).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidEmailToken
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword сохраняет новый хеш пароля и отзывает все сессии пользователя,
+// кроме keepSession.
+func (r *UserRepository) ChangePassword(userID int, passwordHash, keepSession string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE users SET password_hash = $2 WHERE id = $1", userID, passwordHash)
+	if err := requireAffected(res, err, ErrUserNotFound); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL",
+		userID, keepSession,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -85,3 +85,94 @@ func TestUserRepository_FindByEmail_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "пользователь не найден")
 }
+
+func TestUserRepository_FindByID_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewUserRepository(db)
+
+	mock.ExpectQuery("SELECT id, username, email, password_hash, created_at, (.+) FROM users WHERE id = ?").
+		WithArgs(7).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.FindByID(7)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func TestUserRepository_FindLocale(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewUserRepository(db)
+
+	mock.ExpectQuery("SELECT locale FROM users WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectQuery("SELECT locale FROM users WHERE id = ?").
+		WithArgs(7).
+		WillReturnError(sql.ErrNoRows)
+
+	locale, err := repo.FindLocale(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "en", locale)
+
+	_, err = repo.FindLocale(7)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func TestUserRepository_UpdateLocale_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewUserRepository(db)
+
+	mock.ExpectExec("UPDATE users SET locale = (.+) WHERE id = (.+)").
+		WithArgs(7, "en").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateLocale(7, "en")
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func TestUserRepository_DeleteAccount_ReturnsExportFiles(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM links WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("DELETE FROM export_jobs WHERE user_id = \\$1 RETURNING file_path").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"file_path"}).AddRow("/exports/1.csv").AddRow(""))
+	mock.ExpectExec("DELETE FROM users WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Без карантина коды ссылок освобождаются сразу
+	paths, err := repo.DeleteAccount(1, nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/exports/1.csv"}, paths)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_DeleteAccount_NotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM links").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("DELETE FROM export_jobs").WillReturnRows(sqlmock.NewRows([]string{"file_path"}))
+	mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.DeleteAccount(1, nil, 0)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
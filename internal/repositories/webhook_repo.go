@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"url-short/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepository struct {
+	DB *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{DB: db}
+}
+
+var (
+	ErrWebhookNotFound  = errors.New("вебхук не найден")
+	ErrDeliveryNotFound = errors.New("доставка не найдена")
+)
+
+const webhookColumns = "id, user_id, url, secret, events, milestones, created_at"
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var w models.Webhook
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, pq.Array(&w.Events), pq.Array(&w.Milestones), &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *WebhookRepository) queryWebhooks(query string, args ...any) ([]models.Webhook, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *w)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) FindByUserID(userID int) ([]models.Webhook, error) {
+	return r.queryWebhooks("SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 ORDER BY id", userID)
+}
+
+// FindSubscribed возвращает вебхуки пользователя, подписанные хотя бы на одно из событий.
+func (r *WebhookRepository) FindSubscribed(userID int, events ...string) ([]models.Webhook, error) {
+	return r.queryWebhooks(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE user_id = $1 AND events && $2 ORDER BY id",
+		userID,
+		pq.Array(events),
+	)
+}
+
+func (r *WebhookRepository) FindByID(userID, id int) (*models.Webhook, error) {
+	return scanWebhook(r.DB.QueryRow(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = $1 AND user_id = $2",
+		id,
+		userID,
+	))
+}
+
+func (r *WebhookRepository) Create(w *models.Webhook) error {
+	if w.Milestones == nil {
+		w.Milestones = []int64{}
+	}
+	return r.DB.QueryRow(`
+        INSERT INTO webhooks (user_id, url, secret, events, milestones) 
+        VALUES ($1, $2, $3, $4, $5) 
+        RETURNING id, created_at
+    `, w.UserID, w.URL, w.Secret, pq.Array(w.Events), pq.Array(w.Milestones)).Scan(&w.ID, &w.CreatedAt)
+}
+
+func (r *WebhookRepository) Delete(userID, id int) error {
+	res, err := r.DB.Exec("DELETE FROM webhooks WHERE id = $1 AND user_id = $2", id, userID)
+	return requireAffected(res, err, ErrWebhookNotFound)
+}
+
+const deliveryColumns = "id, webhook_id, event, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at"
+
+func scanDelivery(row rowScanner, extra ...any) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(append([]any{
+		&d.ID,
+		&d.WebhookID,
+		&d.Event,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseCode,
+		&d.Error,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
+
+// CreateDelivery записывает доставку в состоянии pending; первая попытка доступна сразу.
+func (r *WebhookRepository) CreateDelivery(d *models.WebhookDelivery) error {
+	d.Status = models.DeliveryPending
+	return r.DB.QueryRow(`
+        INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at) 
+        VALUES ($1, $2, $3, $4, NOW()) 
+        RETURNING id, next_attempt_at, created_at
+    `, d.WebhookID, d.Event, []byte(d.Payload), d.Status).Scan(&d.ID, &d.NextAttemptAt, &d.CreatedAt)
+}
+
+// DueDelivery — доставка вместе с адресом и секретом ее вебхука.
+type DueDelivery struct {
+	models.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// ClaimDue забирает до limit доставок, время попытки которых наступило, и откладывает
+// их следующую попытку на lease, чтобы другой обработчик не отправил их повторно.
+// Если обработчик не успеет сохранить результат, доставка вернется в очередь.
+func (r *WebhookRepository) ClaimDue(limit int, lease time.Duration) ([]DueDelivery, error) {
+	rows, err := r.DB.Query(`
+        WITH claimed AS ( 
+            UPDATE webhook_deliveries 
+            SET next_attempt_at = NOW() + make_interval(secs => $2) 
+            WHERE id IN ( 
+                SELECT id FROM webhook_deliveries 
+                WHERE status = 'pending' AND next_attempt_at <= NOW() 
+                ORDER BY next_attempt_at 
+                LIMIT $1 
+                FOR UPDATE SKIP LOCKED 
+            ) 
+            RETURNING `+deliveryColumns+`
+        ) 
+        SELECT claimed.*, w.url, w.secret 
+        FROM claimed JOIN webhooks w ON w.id = claimed.webhook_id
+    `, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []DueDelivery
+	for rows.Next() {
+		var due DueDelivery
+		d, err := scanDelivery(rows, &due.URL, &due.Secret)
+		if err != nil {
+			return nil, err
+		}
+		due.WebhookDelivery = *d
+		deliveries = append(deliveries, due)
+	}
+	return deliveries, rows.Err()
+}
+
+// SaveAttempt сохраняет результат попытки: статус, число попыток, код ответа, ошибку
+// и время следующей попытки.
+func (r *WebhookRepository) SaveAttempt(d *models.WebhookDelivery) error {
+	_, err := r.DB.Exec(`
+        UPDATE webhook_deliveries 
+        SET status = $1, attempts = $2, response_code = $3, error = $4, next_attempt_at = $5, delivered_at = $6 
+        WHERE id = $7
+    `, d.Status, d.Attempts, d.ResponseCode, d.Error, d.NextAttemptAt, d.DeliveredAt, d.ID)
+	return err
+}
+
+// FindDeliveries возвращает последние limit доставок вебхука, новые первыми.
+func (r *WebhookRepository) FindDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.DB.Query(`
+        SELECT `+deliveryColumns+`
+        FROM webhook_deliveries 
+        WHERE webhook_id = $1 
+        ORDER BY id DESC 
+        LIMIT $2
+    `, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver ставит в очередь новую доставку с тем же событием и телом, что и deliveryID.
+// Исходная запись журнала не меняется.
+func (r *WebhookRepository) Redeliver(webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	original, err := scanDelivery(r.DB.QueryRow(
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2",
+		deliveryID,
+		webhookID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &models.WebhookDelivery{WebhookID: webhookID, Event: original.Event, Payload: original.Payload}
+	if err := r.CreateDelivery(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
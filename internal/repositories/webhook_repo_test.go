@@ -0,0 +1,112 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var deliveryColumns = []string{
+	"id", "webhook_id", "event", "payload", "status", "attempts", "response_code", "error",
+	"next_attempt_at", "created_at", "delivered_at",
+}
+
+func TestWebhookRepository_FindSubscribed(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewWebhookRepository(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM webhooks WHERE user_id = \\$1 AND events && \\$2").
+		WithArgs(7, pq.Array([]string{"click", "click.milestone"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "url", "secret", "events", "milestones", "created_at"}).
+			AddRow(3, 7, "https://crm.example.com/hook", "s3cret", "{click.milestone}", "{100,1000}", time.Now()))
+
+	hooks, err := repo.FindSubscribed(7, models.EventClick, models.EventClickMilestone)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, []string{"click.milestone"}, hooks[0].Events)
+	assert.True(t, hooks[0].HasMilestone(1000))
+	assert.Equal(t, "s3cret", hooks[0].Secret)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDue(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	repo := repositories.NewWebhookRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("UPDATE webhook_deliveries SET next_attempt_at = NOW\\(\\) \\+ make_interval\\(secs => \\$2\\) "+
+		"WHERE id IN \\( SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW\\(\\)(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(100, 60.0).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, deliveryColumns...), "url", "secret")).
+			AddRow(42, 3, "click", []byte(`{"event":"click"}`), "pending", 1, 500, "ответ 500", now, now, nil, "https://crm.example.com/hook", "s3cret"))
+
+	due, err := repo.ClaimDue(100, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 42, due[0].ID)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, "https://crm.example.com/hook", due[0].URL)
+	assert.Equal(t, "s3cret", due[0].Secret)
+	assert.JSONEq(t, `{"event":"click"}`, string(due[0].Payload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_Redeliver(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockClosure func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "Copies event and payload",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE id = \\$1 AND webhook_id = \\$2").
+					WithArgs(42, 3).
+					WillReturnRows(sqlmock.NewRows(deliveryColumns).
+						AddRow(42, 3, "link.created", []byte(`{"event":"link.created"}`), "failed", 8, 500, "ответ 500", nil, time.Now(), nil))
+				mock.ExpectQuery("INSERT INTO webhook_deliveries").
+					WithArgs(3, "link.created", []byte(`{"event":"link.created"}`), "pending").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at"}).AddRow(43, time.Now(), time.Now()))
+			},
+		},
+		{
+			name: "Delivery of another webhook",
+			mockClosure: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries WHERE id = \\$1 AND webhook_id = \\$2").
+					WithArgs(42, 3).
+					WillReturnRows(sqlmock.NewRows(deliveryColumns))
+			},
+			expectedErr: repositories.ErrDeliveryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			defer db.Close()
+
+			repo := repositories.NewWebhookRepository(db)
+			tt.mockClosure(mock)
+
+			delivery, err := repo.Redeliver(3, 42)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, 43, delivery.ID)
+				assert.Equal(t, models.DeliveryPending, delivery.Status)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package stream раздает события кликов подписчикам внутри процесса: каждый
+// подписчик получает события своих ссылок, а недавние события хранятся для
+// продолжения потока после переподключения.
+package stream
+
+import (
+	"sync"
+	"time"
+	"url-short/internal/models"
+)
+
+const (
+	DefaultHistorySize = 1000
+	DefaultBufferSize  = 64
+)
+
+// Event — клик, разосланный подписчикам. ID растут монотонно.
+type Event struct {
+	ID     uint64
+	UserID int
+	LinkID int
+	Click  models.ClickEvent
+}
+
+// Subscription — подписка на клики пользователя или одной его ссылки.
+// Канал C закрывается, если подписчик не успевает читать события или
+// подписку закрыли через Close.
+type Subscription struct {
+	C <-chan Event
+
+	hub    *Hub
+	userID int
+	linkID int
+	ch     chan Event
+	closed bool
+}
+
+// Matches сообщает, относится ли событие к подписке.
+func (s *Subscription) Matches(e Event) bool {
+	return e.UserID == s.userID && (s.linkID == 0 || e.LinkID == s.linkID)
+}
+
+// Close отписывает подписчика. Повторный вызов безопасен.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+// Hub хранит подписки и кольцевой буфер недавних событий.
+type Hub struct {
+	// BufferSize — сколько событий ждут медленного подписчика, прежде чем его отключат
+	BufferSize int
+
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	next        int
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		BufferSize: DefaultBufferSize,
+		// Номера начинаются с текущего времени в микросекундах, поэтому после перезапуска
+		// они продолжают расти и Last-Event-ID клиента не совпадет с новыми событиями
+		lastID:      uint64(time.Now().UnixMicro()),
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish присваивает событию номер, сохраняет его в истории и рассылает подписчикам.
+// Публикация не блокируется: подписчик с переполненным буфером отключается
+// и может продолжить с последнего полученного события.
+func (h *Hub) Publish(userID, linkID int, click models.ClickEvent) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e := Event{ID: h.lastID, UserID: userID, LinkID: linkID, Click: click}
+	if cap(h.history) > 0 {
+		if len(h.history) < cap(h.history) {
+			h.history = append(h.history, e)
+		} else {
+			h.history[h.next] = e
+			h.next = (h.next + 1) % cap(h.history)
+		}
+	}
+
+	for s := range h.subscribers {
+		if !s.Matches(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			h.drop(s)
+		}
+	}
+	return e
+}
+
+// Subscribe подписывает на клики пользователя; linkID == 0 — на клики всех его ссылок.
+// Вместе с подпиской возвращаются события из истории с номером больше afterID,
+// подходящие подписке. События, пришедшие после подписки, в историю не попадут.
+func (h *Hub) Subscribe(userID, linkID int, afterID uint64) (*Subscription, []Event) {
+	ch := make(chan Event, max(h.BufferSize, 1))
+	s := &Subscription{C: ch, hub: h, userID: userID, linkID: linkID, ch: ch}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[s] = struct{}{}
+
+	var backlog []Event
+	if afterID == 0 {
+		return s, backlog
+	}
+	for i := range h.history {
+		e := h.history[(h.next+i)%len(h.history)]
+		if e.ID > afterID && s.Matches(e) {
+			backlog = append(backlog, e)
+		}
+	}
+	return s, backlog
+}
+
+// Subscribers возвращает число активных подписок.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// drop вызывается под h.mu.
+func (h *Hub) drop(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(h.subscribers, s)
+	close(s.ch)
+}
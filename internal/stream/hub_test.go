@@ -0,0 +1,68 @@
+package stream_test
+
+import (
+	"testing"
+	"url-short/internal/models"
+	"url-short/internal/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_PublishFiltersSubscribers(t *testing.T) {
+	hub := stream.NewHub(10)
+
+	all, _ := hub.Subscribe(1, 0, 0)
+	one, _ := hub.Subscribe(1, 5, 0)
+	other, _ := hub.Subscribe(2, 0, 0)
+
+	first := hub.Publish(1, 5, models.ClickEvent{ShortCode: "five"})
+	second := hub.Publish(1, 6, models.ClickEvent{ShortCode: "six"})
+	assert.Greater(t, second.ID, first.ID)
+
+	assert.Equal(t, "five", (<-all.C).Click.ShortCode)
+	assert.Equal(t, "six", (<-all.C).Click.ShortCode)
+	assert.Equal(t, "five", (<-one.C).Click.ShortCode)
+	assert.Len(t, one.C, 0)
+	assert.Len(t, other.C, 0)
+}
+
+func TestHub_SubscribeResumesFromHistory(t *testing.T) {
+	hub := stream.NewHub(3)
+
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, hub.Publish(1, 5, models.ClickEvent{ClickCount: i + 1}).ID)
+	}
+	hub.Publish(2, 9, models.ClickEvent{})
+
+	// В истории остались три последних события; чужое событие не возвращается
+	_, backlog := hub.Subscribe(1, 0, ids[0])
+	require.Len(t, backlog, 2)
+	assert.Equal(t, ids[3], backlog[0].ID)
+	assert.Equal(t, ids[4], backlog[1].ID)
+
+	_, backlog = hub.Subscribe(1, 0, ids[4])
+	assert.Empty(t, backlog)
+
+	_, backlog = hub.Subscribe(1, 0, 0)
+	assert.Empty(t, backlog, "без Last-Event-ID история не отправляется")
+}
+
+func TestHub_SlowSubscriberIsDisconnected(t *testing.T) {
+	hub := stream.NewHub(10)
+	hub.BufferSize = 2
+
+	sub, _ := hub.Subscribe(1, 0, 0)
+	for i := 0; i < 3; i++ {
+		hub.Publish(1, 5, models.ClickEvent{})
+	}
+
+	assert.Equal(t, 0, hub.Subscribers())
+	<-sub.C
+	<-sub.C
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	sub.Close()
+}
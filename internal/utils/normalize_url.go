@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL приводит адрес к виду, в котором сравниваются дубликаты:
+// схема и хост в нижнем регистре, порт по умолчанию убран, параметры запроса
+// отсортированы по имени (порядок значений одного параметра сохраняется),
+// пустой путь заменен на «/», а завершающий слеш в остальных путях убран.
+// Результат — только ключ для сравнения; переходы идут по исходному адресу.
+func NormalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimSuffix(host, ".")
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 без порта по-прежнему пишется в скобках
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+	} else if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+	u.RawPath = ""
+
+	// Encode сортирует параметры по имени
+	u.RawQuery = u.Query().Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
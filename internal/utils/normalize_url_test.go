@@ -0,0 +1,33 @@
+package utils_test
+
+import (
+	"testing"
+	"url-short/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "https://example.com", expected: "https://example.com/"},
+		{input: "HTTPS://Example.COM/Path", expected: "https://example.com/Path"},
+		{input: "https://example.com:443/a/", expected: "https://example.com/a"},
+		{input: "http://example.com:80/", expected: "http://example.com/"},
+		{input: "http://example.com:8080/", expected: "http://example.com:8080/"},
+		{input: "https://example.com/?b=2&a=1&a=0", expected: "https://example.com/?a=1&a=0&b=2"},
+		{input: "https://example.com/page?", expected: "https://example.com/page"},
+		{input: "https://example.com./page#top", expected: "https://example.com/page#top"},
+		{input: "http://[::1]:80/x", expected: "http://[::1]/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			normalized, err := utils.NormalizeURL(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, normalized)
+		})
+	}
+}
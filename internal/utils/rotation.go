@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"math/rand"
+	"url-short/internal/models"
+)
+
+// PickDestination выбирает вариант назначения случайно, пропорционально весам.
+// Возвращает nil, если список пуст или сумма весов не положительна.
+func PickDestination(destinations []models.LinkDestination) *models.LinkDestination {
+	total := 0
+	for _, d := range destinations {
+		if d.Weight > 0 {
+			total += d.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Intn(total)
+	for i := range destinations {
+		if destinations[i].Weight <= 0 {
+			continue
+		}
+		if n < destinations[i].Weight {
+			return &destinations[i]
+		}
+		n -= destinations[i].Weight
+	}
+	return nil
+}
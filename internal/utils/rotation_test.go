@@ -0,0 +1,37 @@
+package utils_test
+
+import (
+	"testing"
+	"url-short/internal/models"
+	"url-short/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickDestination(t *testing.T) {
+	t.Run("Empty list", func(t *testing.T) {
+		assert.Nil(t, utils.PickDestination(nil))
+	})
+
+	t.Run("Zero weights", func(t *testing.T) {
+		assert.Nil(t, utils.PickDestination([]models.LinkDestination{{ID: 1, Weight: 0}}))
+	})
+
+	t.Run("Respects weights", func(t *testing.T) {
+		destinations := []models.LinkDestination{
+			{ID: 1, URL: "https://a.example.com", Weight: 1},
+			{ID: 2, URL: "https://b.example.com", Weight: 0},
+			{ID: 3, URL: "https://c.example.com", Weight: 3},
+		}
+
+		counts := map[int]int{}
+		for i := 0; i < 4000; i++ {
+			d := utils.PickDestination(destinations)
+			assert.NotNil(t, d)
+			counts[d.ID]++
+		}
+
+		assert.Zero(t, counts[2])
+		assert.InDelta(t, 3.0, float64(counts[3])/float64(counts[1]), 0.6)
+	})
+}
@@ -0,0 +1,324 @@
+// Package webhooks отправляет события пользователя на его вебхуки: подписывает
+// запросы, повторяет неудачные доставки с экспоненциальной задержкой и ведет журнал.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"url-short/internal/metadata"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+)
+
+const (
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxAttempts = 8
+	DefaultBaseDelay   = 30 * time.Second
+	DefaultMaxDelay    = 6 * time.Hour
+	DefaultWorkers     = 4
+
+	queueSize    = 1000
+	pollInterval = 15 * time.Second
+	claimBatch   = 100
+
+	// Длина фрагмента тела ответа, который попадает в журнал при ошибке
+	maxErrorBody = 512
+)
+
+// Store хранит вебхуки и журнал доставок.
+type Store interface {
+	FindSubscribed(userID int, events ...string) ([]models.Webhook, error)
+	CreateDelivery(d *models.WebhookDelivery) error
+	ClaimDue(limit int, lease time.Duration) ([]repositories.DueDelivery, error)
+	SaveAttempt(d *models.WebhookDelivery) error
+}
+
+type event struct {
+	userID int
+	name   string
+	data   any
+	at     time.Time
+}
+
+// Dispatcher принимает события из обработчиков запросов и доставляет их в фоне.
+// Доставки сначала записываются в журнал, поэтому переживают перезапуск сервера.
+type Dispatcher struct {
+	Store       Store
+	Timeout     time.Duration
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Workers     int
+
+	// AllowPrivateNetworks отключает защиту от SSRF. Только для тестов и локальной разработки.
+	AllowPrivateNetworks bool
+
+	events     chan event
+	wake       chan struct{}
+	once       sync.Once
+	client     *http.Client
+	clientOnce sync.Once
+}
+
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store:       store,
+		Timeout:     DefaultTimeout,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		Workers:     DefaultWorkers,
+	}
+}
+
+func (d *Dispatcher) init() {
+	d.events = make(chan event, queueSize)
+	d.wake = make(chan struct{}, 1)
+}
+
+// Emit ставит событие в очередь и не блокирует запрос. Если очередь переполнена,
+// событие отбрасывается: переход по ссылке важнее уведомления о нем.
+func (d *Dispatcher) Emit(userID int, name string, data any) {
+	d.once.Do(d.init)
+	select {
+	case d.events <- event{userID: userID, name: name, data: data, at: time.Now()}:
+	default:
+		log.Printf("[WARN] Очередь вебхуков переполнена, событие %s отброшено | Пользователь: %d", name, userID)
+	}
+}
+
+// Wake сообщает, что в журнале появились доставки, которые можно отправить сразу.
+func (d *Dispatcher) Wake() {
+	d.once.Do(d.init)
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start запускает разбор очереди событий и отправку доставок.
+func (d *Dispatcher) Start() {
+	d.once.Do(d.init)
+	go d.record()
+	go d.deliverDue()
+}
+
+// record записывает в журнал доставки для вебхуков, подписанных на событие.
+func (d *Dispatcher) record() {
+	for e := range d.events {
+		n, err := d.enqueue(e)
+		if err != nil {
+			log.Printf("[ERROR] Ошибка записи доставок вебхуков: %v | Событие: %s", err, e.name)
+		}
+		if n > 0 {
+			d.Wake()
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(e event) (int, error) {
+	names := []string{e.name}
+	click, isClick := e.data.(models.ClickEvent)
+	if isClick {
+		names = append(names, models.EventClickMilestone)
+	}
+
+	hooks, err := d.Store.FindSubscribed(e.userID, names...)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, hook := range hooks {
+		for _, name := range names {
+			if !hook.Subscribed(name) {
+				continue
+			}
+			if name == models.EventClickMilestone && !hook.HasMilestone(click.ClickCount) {
+				continue
+			}
+			payload, err := json.Marshal(models.WebhookEvent{Event: name, CreatedAt: e.at, Data: e.data})
+			if err != nil {
+				return n, err
+			}
+			delivery := &models.WebhookDelivery{WebhookID: hook.ID, Event: name, Payload: payload}
+			if err := d.Store.CreateDelivery(delivery); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// deliverDue забирает из журнала доставки, время которых наступило, и раздает их
+// обработчикам. Доставки проверяются по таймеру, а новые — сразу после Wake.
+func (d *Dispatcher) deliverDue() {
+	jobs := make(chan repositories.DueDelivery)
+	for i := 0; i < max(d.Workers, 1); i++ {
+		go func() {
+			for due := range jobs {
+				d.Deliver(due)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		// Аренда с запасом перекрывает отправку всей пачки
+		due, err := d.Store.ClaimDue(claimBatch, 2*d.Timeout*time.Duration(claimBatch))
+		if err != nil {
+			log.Printf("[ERROR] Ошибка выборки доставок вебхуков: %v", err)
+		}
+		for _, delivery := range due {
+			jobs <- delivery
+		}
+		if len(due) == claimBatch {
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Deliver отправляет доставку и сохраняет результат попытки. Неудачная доставка
+// повторяется с экспоненциальной задержкой, пока не исчерпаны попытки.
+func (d *Dispatcher) Deliver(due repositories.DueDelivery) {
+	delivery := due.WebhookDelivery
+	delivery.Attempts++
+
+	code, err := d.send(due)
+	delivery.ResponseCode = nil
+	delivery.Error = ""
+	if code != 0 {
+		delivery.ResponseCode = &code
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = err.Error()
+		log.Printf("[WARN] Доставка вебхука %d не удалась после %d попыток: %v", due.WebhookID, delivery.Attempts, err)
+	default:
+		next := now.Add(d.Backoff(delivery.Attempts))
+		delivery.Status = models.DeliveryPending
+		delivery.NextAttemptAt = &next
+		delivery.Error = err.Error()
+	}
+
+	if err := d.Store.SaveAttempt(&delivery); err != nil {
+		log.Printf("[ERROR] Ошибка сохранения доставки вебхука: %v | Доставка: %d", err, delivery.ID)
+	}
+}
+
+func (d *Dispatcher) send(due repositories.DueDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, due.URL, bytes.NewReader(due.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-short-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", due.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(due.ID))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(due.Secret, timestamp, due.Payload))
+
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("ответ %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
+
+// Backoff возвращает задержку перед попыткой attempt+1: BaseDelay, удваиваясь
+// с каждой попыткой, но не больше MaxDelay.
+func (d *Dispatcher) Backoff(attempt int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.MaxDelay {
+			return d.MaxDelay
+		}
+	}
+	return min(delay, d.MaxDelay)
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature: HMAC-SHA256 секрета вебхука
+// от строки "<timestamp>.<тело запроса>". Получатель проверяет подпись тем же способом
+// и отклоняет запросы со старой меткой времени, чтобы их нельзя было повторить.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) httpClient() *http.Client {
+	d.clientOnce.Do(func() {
+		dialer := &net.Dialer{Timeout: d.Timeout}
+		if !d.AllowPrivateNetworks {
+			// Адрес вебхука задает пользователь, поэтому проверяем уже разрешенный IP
+			dialer.Control = func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || !metadata.IsPublicIP(ip) {
+					return metadata.ErrForbiddenAddress
+				}
+				return nil
+			}
+		}
+
+		d.client = &http.Client{
+			Timeout: d.Timeout,
+			Transport: &http.Transport{
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   d.Timeout,
+				ResponseHeaderTimeout: d.Timeout,
+			},
+			// Перенаправления не выполняются: подписанное тело ушло бы на другой адрес
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	})
+	return d.client
+}
@@ -0,0 +1,195 @@
+package webhooks_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"url-short/internal/models"
+	"url-short/internal/repositories"
+	"url-short/internal/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore хранит вебхуки и доставки в памяти.
+type memoryStore struct {
+	mu       sync.Mutex
+	hooks    []models.Webhook
+	created  chan models.WebhookDelivery
+	attempts []models.WebhookDelivery
+}
+
+func (s *memoryStore) FindSubscribed(userID int, events ...string) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	for _, hook := range s.hooks {
+		for _, e := range events {
+			if hook.UserID == userID && hook.Subscribed(e) {
+				hooks = append(hooks, hook)
+				break
+			}
+		}
+	}
+	return hooks, nil
+}
+
+func (s *memoryStore) CreateDelivery(d *models.WebhookDelivery) error {
+	s.created <- *d
+	return nil
+}
+
+func (s *memoryStore) ClaimDue(int, time.Duration) ([]repositories.DueDelivery, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) SaveAttempt(d *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, *d)
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	// Значение посчитано независимо: printf '1700000000.{"event":"click"}' | openssl dgst -sha256 -hmac secret
+	signature := webhooks.Sign("secret", 1700000000, []byte(`{"event":"click"}`))
+	assert.Equal(t, "sha256=579968b775be05f18296280e1d8b0334db1e6e1c8a15f880a89c6bb14fe7c45d", signature)
+}
+
+func TestBackoff(t *testing.T) {
+	d := webhooks.NewDispatcher(nil)
+	d.BaseDelay = 30 * time.Second
+	d.MaxDelay = 10 * time.Minute
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: 30 * time.Second},
+		{attempt: 2, expected: time.Minute},
+		{attempt: 4, expected: 4 * time.Minute},
+		{attempt: 6, expected: 10 * time.Minute},
+		{attempt: 60, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, d.Backoff(tt.attempt), "попытка %d", tt.attempt)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	payload := []byte(`{"event":"link.created","data":{}}`)
+
+	tests := []struct {
+		name           string
+		status         int
+		attempts       int
+		expectedStatus string
+		expectRetry    bool
+	}{
+		{name: "Success", status: http.StatusNoContent, expectedStatus: models.DeliverySucceeded},
+		{name: "Server error is retried", status: http.StatusInternalServerError, expectedStatus: models.DeliveryPending, expectRetry: true},
+		{name: "Last attempt fails", status: http.StatusBadGateway, attempts: 2, expectedStatus: models.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			store := &memoryStore{}
+			d := webhooks.NewDispatcher(store)
+			d.MaxAttempts = 3
+			d.AllowPrivateNetworks = true
+
+			d.Deliver(repositories.DueDelivery{
+				WebhookDelivery: models.WebhookDelivery{ID: 42, WebhookID: 3, Event: models.EventLinkCreated, Payload: payload, Attempts: tt.attempts},
+				URL:             server.URL,
+				Secret:          "secret",
+			})
+
+			require.NotNil(t, received)
+			assert.Equal(t, payload, body)
+			assert.Equal(t, "link.created", received.Header.Get("X-Webhook-Event"))
+			assert.Equal(t, "42", received.Header.Get("X-Webhook-Delivery"))
+			timestamp, err := strconv.ParseInt(received.Header.Get("X-Webhook-Timestamp"), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, webhooks.Sign("secret", timestamp, payload), received.Header.Get("X-Webhook-Signature"))
+
+			require.Len(t, store.attempts, 1)
+			saved := store.attempts[0]
+			assert.Equal(t, tt.expectedStatus, saved.Status)
+			assert.Equal(t, tt.attempts+1, saved.Attempts)
+			assert.Equal(t, tt.status, *saved.ResponseCode)
+			assert.Equal(t, tt.expectRetry, saved.NextAttemptAt != nil)
+			assert.Equal(t, tt.expectedStatus == models.DeliverySucceeded, saved.DeliveredAt != nil)
+		})
+	}
+}
+
+func TestDeliver_BlocksPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("запрос не должен дойти до внутреннего адреса")
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d := webhooks.NewDispatcher(store)
+	d.Deliver(repositories.DueDelivery{
+		WebhookDelivery: models.WebhookDelivery{ID: 1, Payload: []byte(`{}`)},
+		URL:             server.URL,
+	})
+
+	require.Len(t, store.attempts, 1)
+	assert.Equal(t, models.DeliveryPending, store.attempts[0].Status)
+	assert.Contains(t, store.attempts[0].Error, "внутренней сети")
+}
+
+func TestEmit_ClickMilestones(t *testing.T) {
+	store := &memoryStore{
+		hooks: []models.Webhook{
+			{ID: 1, UserID: 7, Events: []string{models.EventClick}},
+			{ID: 2, UserID: 7, Events: []string{models.EventClickMilestone}, Milestones: []int64{100}},
+			{ID: 3, UserID: 8, Events: []string{models.EventClick}},
+		},
+		created: make(chan models.WebhookDelivery, 10),
+	}
+	d := webhooks.NewDispatcher(store)
+	d.Start()
+
+	d.Emit(7, models.EventClick, models.ClickEvent{ShortCode: "abc", ClickCount: 99})
+	d.Emit(7, models.EventClick, models.ClickEvent{ShortCode: "abc", ClickCount: 100})
+
+	var deliveries []models.WebhookDelivery
+	for len(deliveries) < 3 {
+		select {
+		case delivery := <-store.created:
+			deliveries = append(deliveries, delivery)
+		case <-time.After(time.Second):
+			t.Fatalf("получено доставок: %d, ожидалось 3", len(deliveries))
+		}
+	}
+
+	assert.Equal(t, 1, deliveries[0].WebhookID)
+	assert.Equal(t, 1, deliveries[1].WebhookID)
+	assert.Equal(t, 2, deliveries[2].WebhookID)
+	assert.Equal(t, models.EventClickMilestone, deliveries[2].Event)
+
+	var event struct {
+		Event string            `json:"event"`
+		Data  models.ClickEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[2].Payload, &event))
+	assert.Equal(t, models.EventClickMilestone, event.Event)
+	assert.Equal(t, 100, event.Data.ClickCount)
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS link_destinations (
+    id SERIAL PRIMARY KEY,
+    link_id INTEGER REFERENCES links(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_destinations_link_id ON link_destinations(link_id);
+
+ALTER TABLE click_analytics
+    ADD COLUMN IF NOT EXISTS destination_id INTEGER REFERENCES link_destinations(id) ON DELETE SET NULL;
@@ -0,0 +1,17 @@
+ALTER TABLE links
+    ADD COLUMN IF NOT EXISTS track_conversions BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE click_analytics
+    ADD COLUMN IF NOT EXISTS referrer VARCHAR(2048),
+    ADD COLUMN IF NOT EXISTS click_uid VARCHAR(32) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS conversions (
+    id SERIAL PRIMARY KEY,
+    click_id INTEGER REFERENCES click_analytics(id) ON DELETE CASCADE,
+    link_id INTEGER REFERENCES links(id) ON DELETE CASCADE,
+    value NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversions_click_id ON conversions(click_id);
+CREATE INDEX IF NOT EXISTS idx_conversions_link_id ON conversions(link_id);
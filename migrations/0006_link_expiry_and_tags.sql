@@ -0,0 +1,18 @@
+ALTER TABLE links
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS link_tags (
+    link_id INTEGER REFERENCES links(id) ON DELETE CASCADE,
+    tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (link_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_links_user_id ON links(user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE links
+    ADD COLUMN IF NOT EXISTS title VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS image_url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS site_name VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Источник перехода: обычная ссылка или сканирование QR-кода
+ALTER TABLE click_analytics ADD COLUMN source VARCHAR(16) NOT NULL DEFAULT 'link';
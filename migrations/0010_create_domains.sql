@@ -0,0 +1,15 @@
+CREATE TABLE domains (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hostname VARCHAR(253) UNIQUE NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- NULL — основной домен сервиса. Домен со ссылками удалить нельзя.
+ALTER TABLE links ADD COLUMN domain_id INT REFERENCES domains(id) ON DELETE RESTRICT;
+
+-- Короткий код уникален в пределах домена, а не глобально
+ALTER TABLE links DROP CONSTRAINT links_short_code_key;
+CREATE UNIQUE INDEX links_domain_short_code_key ON links (COALESCE(domain_id, 0), short_code);
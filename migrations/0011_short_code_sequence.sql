@@ -0,0 +1,2 @@
+-- Источник номеров для стратегии генерации кодов counter
+CREATE SEQUENCE short_code_seq;
@@ -0,0 +1,19 @@
+-- Коды одного домена, которые совпадают без учета регистра (abC и ABC).
+-- Пока они есть, регистронезависимый режим (SHORT_CODE_CASE=insensitive) не включится.
+CREATE VIEW short_code_case_conflicts AS
+SELECT 
+    COALESCE(d.hostname, '') AS domain,
+    ARRAY_AGG(l.short_code ORDER BY l.short_code) AS codes
+FROM links l
+LEFT JOIN domains d ON d.id = l.domain_id
+GROUP BY COALESCE(l.domain_id, 0), COALESCE(d.hostname, ''), LOWER(l.short_code)
+HAVING COUNT(*) > 1;
+
+DO $$
+DECLARE
+    conflict RECORD;
+BEGIN
+    FOR conflict IN SELECT * FROM short_code_case_conflicts LOOP
+        RAISE WARNING 'Коды отличаются только регистром: домен "%", коды %', conflict.domain, conflict.codes;
+    END LOOP;
+END $$;
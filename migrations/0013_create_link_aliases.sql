@@ -0,0 +1,31 @@
+-- Дополнительные короткие коды ссылки. Алиас живет на домене ссылки и делит
+-- с кодами ссылок одно пространство: занятость проверяется по обеим таблицам.
+CREATE TABLE link_aliases (
+    id SERIAL PRIMARY KEY,
+    link_id INT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    domain_id INT REFERENCES domains(id) ON DELETE RESTRICT,
+    short_code VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX link_aliases_domain_short_code_key ON link_aliases (COALESCE(domain_id, 0), short_code);
+CREATE INDEX link_aliases_link_id_idx ON link_aliases (link_id);
+
+-- Код, по которому пришел посетитель: основной код ссылки или алиас.
+-- Хранится строкой, чтобы история переживала удаление алиаса.
+ALTER TABLE click_analytics ADD COLUMN short_code VARCHAR(20) NOT NULL DEFAULT '';
+UPDATE click_analytics ca SET short_code = l.short_code FROM links l WHERE l.id = ca.link_id;
+
+-- Конфликты регистра ищутся среди кодов ссылок и алиасов вместе
+CREATE OR REPLACE VIEW short_code_case_conflicts AS
+SELECT 
+    COALESCE(d.hostname, '') AS domain,
+    ARRAY_AGG(c.short_code ORDER BY c.short_code) AS codes
+FROM (
+    SELECT domain_id, short_code FROM links
+    UNION ALL
+    SELECT domain_id, short_code FROM link_aliases
+) c
+LEFT JOIN domains d ON d.id = c.domain_id
+GROUP BY COALESCE(c.domain_id, 0), COALESCE(d.hostname, ''), LOWER(c.short_code)
+HAVING COUNT(*) > 1;
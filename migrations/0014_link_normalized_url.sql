@@ -0,0 +1,4 @@
+-- Нормализованный адрес назначения — ключ для поиска дубликатов при reuse_existing.
+-- У ссылок, созданных до миграции, он заполняется при старте сервера.
+ALTER TABLE links ADD COLUMN normalized_url TEXT;
+CREATE INDEX links_user_normalized_url_idx ON links (user_id, normalized_url);
@@ -0,0 +1,18 @@
+-- Жизненный цикл ссылки. expired не хранится: он вычисляется по expires_at,
+-- а scheduled становится active, когда наступает starts_at.
+ALTER TABLE links ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('draft', 'scheduled', 'active', 'paused', 'archived'));
+ALTER TABLE links ADD COLUMN starts_at TIMESTAMP;
+
+-- Журнал смены состояний: кто, когда и из какого состояния в какое перевел ссылку
+CREATE TABLE link_status_events (
+    id SERIAL PRIMARY KEY,
+    link_id INT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    from_status VARCHAR(16) NOT NULL,
+    to_status VARCHAR(16) NOT NULL,
+    starts_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX link_status_events_link_id_idx ON link_status_events (link_id);
@@ -0,0 +1,15 @@
+-- Мягкое удаление: ссылка перестает открываться, но строка и клики остаются
+-- до истечения срока хранения, после чего их удаляет фоновая очистка.
+ALTER TABLE links ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX links_deleted_at_idx ON links (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Коды окончательно удаленных ссылок и их алиасов, которые еще нельзя занять снова
+CREATE TABLE quarantined_codes (
+    id SERIAL PRIMARY KEY,
+    domain_id INT REFERENCES domains(id) ON DELETE CASCADE,
+    short_code VARCHAR(20) NOT NULL,
+    released_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX quarantined_codes_domain_short_code_idx ON quarantined_codes (COALESCE(domain_id, 0), short_code);
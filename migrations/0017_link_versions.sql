@@ -0,0 +1,20 @@
+-- История настроек перехода: каждая правка адреса назначения, вариантов, срока действия
+-- или трекинга конверсий создает новую версию. Версия 1 — состояние до первой правки;
+-- она записывается вместе с ней.
+ALTER TABLE links ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE link_versions (
+    id SERIAL PRIMARY KEY,
+    link_id INT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    original_url VARCHAR(2048) NOT NULL,
+    destinations JSONB NOT NULL DEFAULT '[]',
+    expires_at TIMESTAMP,
+    track_conversions BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (link_id, version)
+);
+
+-- Версия ссылки, действовавшая в момент клика; все прежние клики относятся к версии 1
+ALTER TABLE click_analytics ADD COLUMN link_version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,36 @@
+-- Исходящие вебхуки пользователя. Секрет подписывает тело запроса (HMAC-SHA256).
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events TEXT[] NOT NULL,
+    milestones INT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+
+-- Журнал доставок. Доставка в состоянии pending повторяется, когда наступает
+-- next_attempt_at; после исчерпания попыток она переходит в failed.
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    response_code INT,
+    error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id DESC);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- Момент, когда об истечении ссылки было отправлено событие link.expired.
+-- Уже истекшие ссылки считаются обработанными, чтобы не рассылать старые события.
+ALTER TABLE links ADD COLUMN expiry_notified_at TIMESTAMP;
+UPDATE links SET expiry_notified_at = NOW() WHERE expires_at <= NOW();
@@ -0,0 +1,26 @@
+-- Фоновые выгрузки кликов. Файл результата хранится на диске до expires_at,
+-- после чего его удаляет очистка вместе с записью.
+CREATE TABLE export_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    link_id INT REFERENCES links(id) ON DELETE CASCADE,
+    format VARCHAR(16) NOT NULL,
+    gzip BOOLEAN NOT NULL DEFAULT FALSE,
+    range_from TIMESTAMP NOT NULL,
+    range_to TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    rows_count BIGINT NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    file_path TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX export_jobs_user_id_idx ON export_jobs (user_id, id DESC);
+CREATE INDEX export_jobs_pending_idx ON export_jobs (id) WHERE status IN ('pending', 'running');
+
+-- Выгрузка идет по диапазону дат внутри ссылки или всех ссылок пользователя
+CREATE INDEX IF NOT EXISTS click_analytics_link_id_clicked_at_idx ON click_analytics (link_id, clicked_at);
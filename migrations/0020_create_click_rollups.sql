@@ -0,0 +1,26 @@
+-- Предагрегированные клики: почасовые и посуточные счетчики по ссылке и измерению.
+-- Измерение total хранит общее число кликов с пустым value, остальные —
+-- число кликов по значению: country, location, browser, os, device, referrer.
+-- Счетчики обновляет запись клика, а пересобирает команда rollup-backfill;
+-- клики, записанные до этой миграции, попадают в счетчики только через нее.
+CREATE TABLE click_rollups_hourly (
+    link_id INT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    dimension VARCHAR(16) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, dimension, bucket, value)
+);
+
+CREATE TABLE click_rollups_daily (
+    link_id INT NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    dimension VARCHAR(16) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (link_id, dimension, bucket, value)
+);
+
+-- Пересборка за диапазон дат по всем ссылкам
+CREATE INDEX click_rollups_hourly_bucket_idx ON click_rollups_hourly (bucket);
+CREATE INDEX click_rollups_daily_bucket_idx ON click_rollups_daily (bucket);
@@ -0,0 +1,7 @@
+-- Сырые клики старше CLICK_RETENTION_DAYS удаляются пачками по времени клика.
+CREATE INDEX IF NOT EXISTS click_analytics_clicked_at_idx ON click_analytics (clicked_at);
+
+-- Конверсии — не персональные данные и переживают удаление клика, по которому пришли
+ALTER TABLE conversions DROP CONSTRAINT IF EXISTS conversions_click_id_fkey;
+ALTER TABLE conversions
+    ADD CONSTRAINT conversions_click_id_fkey FOREIGN KEY (click_id) REFERENCES click_analytics(id) ON DELETE SET NULL;
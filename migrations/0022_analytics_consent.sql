@@ -0,0 +1,10 @@
+-- Режим аналитики ссылки: full, respect (учитывать DNT и Sec-GPC) или consent
+-- (нужно согласие в cookie). Пусто — режим сервера ANALYTICS_MODE.
+ALTER TABLE links ADD COLUMN analytics_mode VARCHAR(16) NOT NULL DEFAULT ''
+    CHECK (analytics_mode IN ('', 'full', 'respect', 'consent'));
+
+-- Клик без согласия: засчитан, но IP-адрес, user agent и геолокация не сохранены
+ALTER TABLE click_analytics ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Счетчики кликов получают измерение tracking со значениями full и anonymous;
+-- для уже посчитанных кликов его добавляет rollup-backfill
@@ -0,0 +1,24 @@
+-- Сессии входа: токен содержит id сессии, и отозванная сессия перестает пускать
+-- в API раньше истечения токена. Токены, выданные до этой миграции, недействительны.
+CREATE TABLE user_sessions (
+    id VARCHAR(32) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions (user_id);
+CREATE INDEX user_sessions_expires_at_idx ON user_sessions (expires_at);
+
+-- Новый email применяется только после перехода по ссылке из письма на этот адрес.
+-- Хранится SHA-256 токена, а не сам токен
+ALTER TABLE users
+    ADD COLUMN pending_email VARCHAR(100),
+    ADD COLUMN email_token_hash VARCHAR(64),
+    ADD COLUMN email_token_expires_at TIMESTAMP;
+
+CREATE UNIQUE INDEX users_email_token_hash_key ON users (email_token_hash) WHERE email_token_hash IS NOT NULL;
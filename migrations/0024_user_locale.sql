@@ -0,0 +1,3 @@
+-- Язык интерфейса, ошибок API и писем, выбранный пользователем.
+-- Пусто — язык определяется по заголовку Accept-Language
+ALTER TABLE users ADD COLUMN locale VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Отметка о полной пересборке счетчиков командой rollup-backfill. Пока ее нет,
+-- сервер не удаляет старые сырые клики: записанные до миграции 0020 есть только в них.
+CREATE TABLE click_rollup_backfills (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    completed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Без сохраненных кликов пересобирать нечего
+INSERT INTO click_rollup_backfills (id)
+SELECT TRUE WHERE NOT EXISTS (SELECT 1 FROM click_analytics);
@@ -0,0 +1,4 @@
+-- Счетчики по источнику клика (source) и коду, по которому перешли (short_code).
+-- Для уже записанных кликов их заполнит только rollup-backfill, поэтому отметка
+-- о полной пересборке снимается, и удаление старых кликов ждет новой пересборки.
+DELETE FROM click_rollup_backfills WHERE EXISTS (SELECT 1 FROM click_analytics);
@@ -0,0 +1,7 @@
+-- Счетчики кликов обновляет не запись клика, а фоновый учет пачками.
+-- rolled_up отмечает клики, уже учтенные в счетчиках; существующие клики учтены
+-- при записи или будут учтены командой rollup-backfill.
+ALTER TABLE click_analytics ADD COLUMN rolled_up BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE click_analytics ALTER COLUMN rolled_up SET DEFAULT FALSE;
+
+CREATE INDEX click_analytics_pending_rollup_idx ON click_analytics (id) WHERE NOT rolled_up;
@@ -0,0 +1,6 @@
+-- Имя домена занимает только подтвержденный домен. Неподтвержденных заявок на одно
+-- имя может быть несколько — от разных пользователей; домен получает тот, кто первым
+-- подтвердит владение через DNS.
+ALTER TABLE domains DROP CONSTRAINT domains_hostname_key;
+CREATE UNIQUE INDEX domains_verified_hostname_key ON domains (hostname) WHERE verified_at IS NOT NULL;
+CREATE UNIQUE INDEX domains_user_hostname_key ON domains (user_id, hostname);
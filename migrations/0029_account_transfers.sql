@@ -0,0 +1,11 @@
+-- Передача ссылок удаляемого аккаунта ждет согласия получателя: аккаунт удаляется,
+-- только когда получатель примет передачу. У аккаунта не больше одной передачи.
+CREATE TABLE account_transfers (
+    id SERIAL PRIMARY KEY,
+    from_user_id INT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX account_transfers_to_user_id_idx ON account_transfers (to_user_id);
@@ -0,0 +1,5 @@
+-- Замененные варианты назначения не удаляются, а выводятся из ротации: клики ссылаются
+-- на них через destination_id, и удаление стерло бы историю A/B-теста.
+ALTER TABLE link_destinations ADD COLUMN IF NOT EXISTS retired_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_link_destinations_active ON link_destinations (link_id) WHERE retired_at IS NULL;
@@ -0,0 +1,19 @@
+-- Конверсия хранит срезы, по которым строится отчет: после удаления старых кликов
+-- click_id обнуляется, и связь с кликом для отчета больше не нужна.
+ALTER TABLE conversions
+    ADD COLUMN IF NOT EXISTS click_uid VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS referrer VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS destination_id INTEGER REFERENCES link_destinations(id) ON DELETE SET NULL;
+
+UPDATE conversions cv
+SET click_uid = ca.click_uid,
+    referrer = CASE WHEN COALESCE(ca.referrer, '') = '' THEN 'direct'
+        ELSE COALESCE(left(lower(substring(ca.referrer FROM '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/@]*@)?([^/:?#]+)')), 255), 'unknown') END,
+    destination_id = ca.destination_id
+FROM click_analytics ca
+WHERE ca.id = cv.click_id;
+
+-- Клики по вариантам назначения считаются в счетчиках измерения destination. Для уже
+-- записанных кликов их заполнит только rollup-backfill, поэтому отметка о полной
+-- пересборке снимается, как в 0026.
+DELETE FROM click_rollup_backfills WHERE EXISTS (SELECT 1 FROM click_analytics);
@@ -0,0 +1,4 @@
+-- Счетчики кликов по версиям настроек ссылки (link_version). Для уже записанных
+-- кликов их заполнит только rollup-backfill, поэтому отметка о полной пересборке
+-- снимается, как в 0026.
+DELETE FROM click_rollup_backfills WHERE EXISTS (SELECT 1 FROM click_analytics);
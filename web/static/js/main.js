@@ -1,3 +1,19 @@
+// Переводы строк интерфейса; язык берется из <html lang>, который выставляет сервер
+const messages = {
+    en: {
+        'Сокращаем...': 'Shortening...',
+        'Ошибка сервера': 'Server error',
+        'Короткая ссылка:': 'Short link:',
+        'Ошибка:': 'Error:',
+        'Сократить': 'Shorten'
+    }
+};
+
+function t(text) {
+    const catalog = messages[document.documentElement.lang] || {};
+    return catalog[text] || text;
+}
+
 document.addEventListener('DOMContentLoaded', () => {
     const form = document.getElementById('shortenForm');
     
@@ -11,7 +27,7 @@ document.addEventListener('DOMContentLoaded', () => {
             const submitBtn = form.querySelector('button');
 
             submitBtn.disabled = true;
-            submitBtn.textContent = 'Сокращаем...';
+            submitBtn.textContent = t('Сокращаем...');
 
             try {
                 const response = await fetch('/api/links', {
@@ -29,12 +45,13 @@ document.addEventListener('DOMContentLoaded', () => {
                 const data = await response.json();
                 
                 if (!response.ok) {
-                    throw new Error(data.error || 'Ошибка сервера');
+                    const details = (data.details || []).map(d => d.message).join('; ');
+                    throw new Error(details || data.error || t('Ошибка сервера'));
                 }
 
                 resultDiv.innerHTML = `
                     <div class="success">
-                        ✅ Короткая ссылка: 
+                        ✅ ${t('Короткая ссылка:')}
                         <a href="/${data.short_code}" target="_blank" class="short-link">
                             ${window.location.host}/${data.short_code}
                         </a>
@@ -47,12 +64,12 @@ document.addEventListener('DOMContentLoaded', () => {
             } catch (err) {
                 resultDiv.innerHTML = `
                     <div class="error">
-                        ❌ Ошибка: ${err.message}
+                        ❌ ${t('Ошибка:')} ${err.message}
                     </div>
                 `;
             } finally {
                 submitBtn.disabled = false;
-                submitBtn.textContent = 'Сократить';
+                submitBtn.textContent = t('Сократить');
             }
         });
     }